@@ -0,0 +1,116 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// account is an aws account the cleaners run in, reached either with the default
+// credentials or by assuming a role.
+type account struct {
+	// RoleARN is the role assumed to reach the account, empty for the default credentials.
+	RoleARN string
+	// ExternalID is passed when assuming the role, if set.
+	ExternalID string
+	// ID is the account id, as resolved with GetCallerIdentity.
+	ID string
+	// credentials are shared by all the sessions of the account, so the role is only
+	// assumed again when they expire. They're nil for the default credentials.
+	credentials *credentials.Credentials
+}
+
+// resolveAccounts returns the accounts to clean, one per role to assume or the account of the
+// default credentials if there's none, and logs the identity each one resolved to.
+func (a *action) resolveAccounts(ctx context.Context, input *Input) ([]*account, error) {
+	accounts := []*account{}
+	for _, roleARN := range input.RoleARNs {
+		if roleARN = strings.TrimSpace(roleARN); roleARN != "" {
+			accounts = append(accounts, &account{RoleARN: roleARN, ExternalID: input.RoleExternalID})
+		}
+	}
+	if len(accounts) == 0 {
+		accounts = append(accounts, &account{})
+	}
+
+	expected := parseIDs(input.ExpectedAccountIDs)
+
+	for _, acc := range accounts {
+		if acc.RoleARN != "" {
+			sess, err := a.newSession(globalRegion, &account{})
+			if err != nil {
+				return nil, err
+			}
+			acc.credentials = stscreds.NewCredentials(sess, acc.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+				if acc.ExternalID != "" {
+					p.ExternalID = aws.String(acc.ExternalID)
+				}
+			})
+		}
+
+		sess, err := a.newSession(globalRegion, acc)
+		if err != nil {
+			return nil, err
+		}
+
+		identity, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			if acc.RoleARN != "" {
+				return nil, fmt.Errorf("failed to get caller identity with role %s: %w", acc.RoleARN, err)
+			}
+			return nil, fmt.Errorf("failed to get caller identity: %w", err)
+		}
+		acc.ID = aws.StringValue(identity.Account)
+
+		// nothing was cleaned up yet, so pointing at the wrong account aborts the run
+		// before any resource is touched.
+		if len(expected) > 0 && !expected[acc.ID] {
+			return nil, fmt.Errorf("%w: %s resolved to account %s", ErrUnexpectedAccount, aws.StringValue(identity.Arn), acc.ID)
+		}
+
+		a.logger.Info("Running as %s in account %s", aws.StringValue(identity.Arn), acc.ID)
+		if len(expected) == 0 && a.commit {
+			a.logger.Warn("No expected account ids set, resources will be deleted in account %s", acc.ID)
+		}
+	}
+
+	return accounts, nil
+}
+
+// newSession creates a session for the region using the credentials of the account.
+func (a *action) newSession(region string, acc *account) (*session.Session, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(region),
+		Credentials: acc.credentials,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create aws session for region %s: %w", region, err)
+	}
+
+	// the limiter is shared by all the sessions, so every api request made by
+	// any cleaner in any region waits for its turn.
+	sess.Handlers.Sign.PushFront(func(r *request.Request) {
+		if err := a.limiter.Wait(r.Context()); err != nil {
+			r.Error = err
+		}
+	})
+
+	return sess, nil
+}
+
+// accountIDs returns the ids of the accounts.
+func accountIDs(accounts []*account) []string {
+	ids := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+
+	return ids
+}
@@ -2,83 +2,734 @@ package action
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/acm"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/efs"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
 	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/glue"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/redshift"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"go.uber.org/multierr"
+	"golang.org/x/time/rate"
 )
 
 type AwsJanitorAction interface {
 	Cleanup(ctx context.Context, input *Input) error
 }
 
-func New(commit bool) AwsJanitorAction {
-	return &action{
-		commit: commit,
+// Option configures optional behaviour of the action.
+type Option func(*action)
+
+// WithWorkers sets how many cleaners can run concurrently in each region.
+func WithWorkers(workers int) Option {
+	return func(a *action) {
+		a.workers = workers
+	}
+}
+
+// WithMaxRetries sets how many times a throttled request is retried.
+func WithMaxRetries(maxRetries int) Option {
+	return func(a *action) {
+		a.maxRetries = maxRetries
+	}
+}
+
+// WithRateLimit sets how many aws api requests per second can be made across all cleaners.
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(a *action) {
+		a.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+}
+
+// WithPreview makes the action print what would be marked and deleted once
+// all the cleaners ran. It's meant to be used when not committing.
+func WithPreview(preview bool) Option {
+	return func(a *action) {
+		a.preview = preview
+	}
+}
+
+// WithStackReport makes the action print the cloudformation stacks that keep skipped
+// resources alive once all the cleaners ran.
+func WithStackReport(stackReport bool) Option {
+	return func(a *action) {
+		a.stackReport = stackReport
+	}
+}
+
+// WithConfirmation makes the action ask for a confirmation before deleting anything
+// when committing. The confirmation is read from stdin unless token is set.
+func WithConfirmation(confirm bool, token string) Option {
+	return func(a *action) {
+		a.confirm = confirm
+		a.confirmToken = token
+	}
+}
+
+// WithMaxDeletions caps how many resources a run can delete. When more would be deleted, the
+// run is aborted or only marks resources, depending on exceededAction. A maximum of 0 means no cap.
+func WithMaxDeletions(maxDeletions int, exceededAction string) Option {
+	return func(a *action) {
+		a.maxDeletions = maxDeletions
+		a.maxDeletionsAction = exceededAction
+	}
+}
+
+// WithCostEstimate makes the action estimate the monthly savings brought by the deleted resources,
+// or the ones that would be deleted, from the default hourly prices overridden by prices.
+func WithCostEstimate(estimate bool, prices map[string]float64) Option {
+	return func(a *action) {
+		if estimate {
+			a.costPrices = costPrices(prices)
+		}
+	}
+}
+
+// WithMetrics makes the action count the resources it handles, and the duration
+// of its runs, in metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(a *action) {
+		a.metrics = metrics
+		a.report.metrics = metrics
+	}
+}
+
+// WithWebhook makes the action post a summary of the run to url once it's done,
+// as json or as a slack message depending on format.
+func WithWebhook(url, format string) Option {
+	return func(a *action) {
+		a.webhookURL = url
+		a.webhookFormat = format
+	}
+}
+
+// WithRegionWorkers sets how many regions can be cleaned up concurrently, each of them
+// running up to workers cleaners at once.
+func WithRegionWorkers(workers int) Option {
+	return func(a *action) {
+		a.regionWorkers = workers
+	}
+}
+
+// WithVPCWorkers sets how many vpcs, with their dependencies, can be deleted concurrently in a region.
+func WithVPCWorkers(workers int) Option {
+	return func(a *action) {
+		a.vpcWorkers = workers
+	}
+}
+
+// WithMode sets whether the action marks and deletes resources, only marks them or only
+// deletes the marked ones.
+func WithMode(mode string) Option {
+	return func(a *action) {
+		a.mode = mode
+	}
+}
+
+// WithLogger sets the logger used by the action, instead of writing to stdout.
+func WithLogger(logger Logger) Option {
+	return func(a *action) {
+		a.logger = logger
+	}
+}
+
+// WithPlan makes the action write the resources it would delete to planFile instead of
+// cleaning up, or only delete the resources held by the plan in applyPlanFile.
+func WithPlan(planFile, applyPlanFile string) Option {
+	return func(a *action) {
+		a.planFile = planFile
+		a.applyPlanFile = applyPlanFile
+	}
+}
+
+// WithDeletionTag sets the key of the tag marking the resources for deletion, instead of DeletionTag.
+func WithDeletionTag(key string) Option {
+	return func(a *action) {
+		a.deletionTag = key
+	}
+}
+
+// WithMarkReason sets the reason written, along with the deletion tag, on the resources marked for deletion.
+func WithMarkReason(reason string) Option {
+	return func(a *action) {
+		a.markReason = reason
+	}
+}
+
+func New(commit bool, opts ...Option) AwsJanitorAction {
+	a := &action{
+		commit:               commit,
+		runID:                newRunID(),
+		deletionTag:          DeletionTag,
+		workers:              1,
+		regionWorkers:        1,
+		vpcWorkers:           1,
+		mode:                 ModeMarkAndDelete,
+		maxRetries:           5,
+		maxDeletionsAction:   MaxDeletionsActionAbort,
+		limiter:              rate.NewLimiter(rate.Limit(defaultRateLimit), 1),
+		report:               NewReport(),
+		releasedCertificates: &releasedCertificates{},
+		logger:               stdoutLogger{},
+		stdin:                os.Stdin,
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	a.report.RunID = a.runID
+
+	return a
+}
+
+// newRunID returns an id for a run of the action, made of its start time and a random suffix
+// so runs started at the same time can be told apart, e.g. "20240102T150405Z-1a2b3c4d".
+func newRunID() string {
+	suffix := make([]byte, 4)
+	// the suffix only needs to be unlikely to collide, a failed read leaves it zeroed.
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
 }
 
+const (
+	defaultRateLimit = 5
+	// globalRegion is the region used to reach the endpoints of global services.
+	globalRegion = "us-east-1"
+)
+
 type action struct {
 	commit bool
+	// runID identifies the run, it's written on the resources it marks for deletion.
+	runID string
+	// deletionTag is the key of the tag marking the resources for deletion.
+	deletionTag string
+	// markReason, when set, is written on the resources marked for deletion along with the run id.
+	markReason string
+	// mode is ModeMarkAndDelete, ModeMarkOnly or ModeDeleteOnly, it applies whether committing or not.
+	mode string
+	// workers is how many cleaners can run concurrently in a region.
+	workers int
+	// regionWorkers is how many regions can be cleaned up concurrently.
+	regionWorkers int
+	// vpcWorkers is how many vpcs can be deleted concurrently by a vpc cleaner.
+	vpcWorkers int
+	maxRetries int
+	limiter    *rate.Limiter
+	report     *Report
+	preview    bool
+	// stackReport enables the report of the cloudformation stacks keeping resources alive.
+	stackReport bool
+	// confirm requires the deletions to be confirmed, either by typing the confirmation
+	// word on stdin or by setting confirmToken to it.
+	confirm      bool
+	confirmToken string
+	stdin        io.Reader
+	// approved holds the resources whose deletion was confirmed. It's nil when no
+	// confirmation is required.
+	approved map[string]bool
+	// planFile, when set, is where a dry run writes the resources it would delete.
+	planFile string
+	// applyPlanFile, when set, is the plan whose resources are the only ones deleted by the run.
+	applyPlanFile string
+	// maxDeletions, when greater than 0, is how many resources a run can delete. When more would
+	// be deleted, the run is aborted or only marks resources depending on maxDeletionsAction.
+	maxDeletions       int
+	maxDeletionsAction string
+	metrics            *Metrics
+	// costPrices are the hourly prices the savings are estimated from. It's nil when the
+	// cost isn't estimated.
+	costPrices map[string]float64
+	// releasedCertificates are the acm certificates of the deleted load balancers.
+	releasedCertificates *releasedCertificates
+	// webhookURL, when set, is where the summary of the run is posted to.
+	webhookURL    string
+	webhookFormat string
+	logger        Logger
 }
 
 type Cleaner struct {
+	// Name identifies the type of resources the cleaner handles, so it can be enabled or disabled.
+	Name    string
 	Service string
-	Run     CleanupFunc
+	// Global cleaners handle resources that aren't scoped to a region (e.g. iam),
+	// so they run once instead of once per region.
+	Global bool
+	Run    CleanupFunc
 }
 
 func (a *action) Cleanup(ctx context.Context, input *Input) error {
+	start := time.Now()
+	defer func() { a.metrics.observeRun(time.Since(start)) }()
 
-	// use []Cleaner to keep the order
-	cleaners := []Cleaner{
-		{Service: eks.ServiceName, Run: a.cleanEKSClusters},
-		{Service: autoscaling.ServiceName, Run: a.cleanASGs},
-		{Service: elb.ServiceName, Run: a.cleanLoadBalancers},
-		{Service: elb.ServiceName, Run: a.cleanLoadBalancersV2},
-		{Service: ec2.ServiceName, Run: a.cleanNetworkInterfaces},
-		{Service: ec2.ServiceName, Run: a.cleanSecurityGroups},
-		{Service: cloudformation.ServiceName, Run: a.cleanCfStacks},
-		{Service: ec2.ServiceName, Run: a.cleanVPCs},
+	// the whole run shares the deadline, so stuck waits are aborted once it's exceeded.
+	if input.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, input.Timeout)
+		defer cancel()
 	}
-	inputRegions := strings.Split(input.Regions, ",")
 
-	for _, cleaner := range cleaners {
-		regions := getServiceRegions(cleaner.Service, inputRegions)
-
-		for _, region := range regions {
-			sess, err := session.NewSession(&aws.Config{
-				Region: aws.String(region)},
-			)
-			if err != nil {
-				return fmt.Errorf("failed to create aws session for region %s: %w", region, err)
-			}
+	stages, err := filterStages(a.stages(), input.ResourceTypes)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("Starting run %s", a.runID)
+	// the immediate resource types are only checked, the stages are filtered by the resource types.
+	if _, err := filterStages(a.stages(), input.ImmediateResourceTypes); err != nil {
+		return fmt.Errorf("invalid immediate resource types: %w", err)
+	}
+
+	switch a.mode {
+	case ModeMarkOnly:
+		a.logger.Info("Running in mark-only mode, resources are only marked for deletion and never deleted")
+	case ModeDeleteOnly:
+		a.logger.Info("Running in delete-only mode, only the resources already marked for deletion are deleted")
+	}
+
+	inputRegions := []string{}
+	for _, region := range strings.Split(input.Regions, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			inputRegions = append(inputRegions, region)
+		}
+	}
+
+	inputRegions, err = filterRegions(inputRegions, input.AllowedRegions, input.DeniedRegions)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("Cleaning up resources in regions %s", strings.Join(inputRegions, ", "))
+
+	accounts, err := a.resolveAccounts(ctx, input)
+	if err != nil {
+		a.notify(ctx, nil, inputRegions, err)
+		return err
+	}
+
+	var errs error
+	blocked := false
+	switch {
+	case a.applyPlanFile != "":
+		// the plan was approved when it was written, it replaces the confirmation.
+		errs = multierr.Append(errs, a.loadPlan())
+		blocked = errs != nil
+	case a.commit && a.mode != ModeMarkOnly && (a.confirm || a.maxDeletions > 0):
+		errs = multierr.Append(errs, a.planDeletions(ctx, input, accounts, inputRegions))
+		blocked = errors.Is(errs, ErrTooManyDeletions) && a.maxDeletionsAction != MaxDeletionsActionMarkOnly
+	}
+	switch {
+	case blocked:
+		a.logger.Warn("No resource was marked or deleted")
+	case a.planFile != "":
+		errs = multierr.Append(errs, a.writePlan(ctx, input, stages, accounts, inputRegions))
+	default:
+		errs = multierr.Append(errs, a.runStages(ctx, input, stages, accounts, inputRegions))
+	}
+	// the cleaners log and report the resources they fail to clean up and carry on, these
+	// failures are returned along with the errors of the cleaners so the run fails.
+	errs = multierr.Append(errs, a.report.Err())
+
+	if a.costPrices != nil {
+		a.report.estimateCost(a.costPrices)
+	}
+
+	a.report.PrintSummary(a.logger, a.commit, a.mode)
+	a.logger.Info("Concurrency: up to %d regions at once, each running up to %d cleaners at once, so up to %d cleaners at once overall",
+		a.regionWorkers, a.workers, a.regionWorkers*a.workers)
 
-			scope := &CleanupScope{
-				Session:   sess,
-				Commit:    input.Commit,
-				IgnoreTag: input.IgnoreTag,
+	if a.preview {
+		a.report.PrintPreview(a.logger)
+	}
+
+	if a.stackReport {
+		a.report.PrintStacks(a.logger)
+	}
+
+	if input.Report != "" {
+		errs = multierr.Append(errs, a.report.Write(input.Report))
+	}
+
+	a.notify(ctx, accountIDs(accounts), inputRegions, errs)
+
+	return errs
+}
+
+// stages returns the cleaners of the action, grouped in the stages they run in.
+func (a *action) stages() [][]Cleaner {
+	// use [][]Cleaner to keep the order: stages run one after the other and the
+	// cleaners within a stage don't depend on each other so they can run concurrently.
+	return [][]Cleaner{
+		{
+			{Name: "eks", Service: eks.ServiceName, Run: a.cleanEKSClusters},
+			// beanstalk environments own auto scaling groups, load balancers and network
+			// interfaces, which are deleted along with them.
+			{Name: "beanstalk", Service: elasticbeanstalk.ServiceName, Run: a.cleanBeanstalkEnvironments},
+			// distributions are deleted before their origins, e.g. load balancers and buckets.
+			{Name: "cloudfront", Service: cloudfront.ServiceName, Global: true, Run: a.cleanCloudFrontDistributions},
+			// endpoint services must be deleted before the load balancers they use.
+			{Name: "vpc-endpoint-service", Service: ec2.ServiceName, Run: a.cleanVPCEndpointServices},
+		},
+		{
+			{Name: "asg", Service: autoscaling.ServiceName, Run: a.cleanASGs},
+			{Name: "elb", Service: elb.ServiceName, Run: a.cleanLoadBalancers},
+			{Name: "elbv2", Service: elb.ServiceName, Run: a.cleanLoadBalancersV2},
+			{Name: "rds-instance", Service: rds.ServiceName, Run: a.cleanRDSInstances},
+			{Name: "rds-cluster", Service: rds.ServiceName, Run: a.cleanRDSClusters},
+			{Name: "s3", Service: s3.ServiceName, Run: a.cleanS3Buckets},
+			{Name: "ecr", Service: ecr.ServiceName, Run: a.cleanECRRepositories},
+			{Name: "task-definition", Service: ecs.ServiceName, Run: a.cleanTaskDefinitions},
+			{Name: "sns", Service: sns.ServiceName, Run: a.cleanSNSTopics},
+			{Name: "kinesis", Service: kinesis.ServiceName, Run: a.cleanKinesisStreams},
+			{Name: "eventbridge-rule", Service: eventbridge.ServiceName, Run: a.cleanEventBridgeRules},
+			{Name: "glue", Service: glue.ServiceName, Run: a.cleanGlueResources},
+			{Name: "state-machine", Service: sfn.ServiceName, Run: a.cleanStateMachines},
+			{Name: "dynamodb", Service: dynamodb.ServiceName, Run: a.cleanDynamoDBTables},
+			{Name: "kms", Service: kms.ServiceName, Run: a.cleanKMSKeys},
+			{Name: "secret", Service: secretsmanager.ServiceName, Run: a.cleanSecrets},
+			{Name: "efs", Service: efs.ServiceName, Run: a.cleanEFSFileSystems},
+			{Name: "elasticache-replication-group", Service: elasticache.ServiceName, Run: a.cleanElastiCacheReplicationGroups},
+			{Name: "elasticache-cluster", Service: elasticache.ServiceName, Run: a.cleanElastiCacheClusters},
+			{Name: "redshift-cluster", Service: redshift.ServiceName, Run: a.cleanRedshiftClusters},
+			// spot requests are cancelled before the instances are terminated, so they don't launch them again.
+			{Name: "spot-request", Service: ec2.ServiceName, Run: a.cleanSpotRequests},
+		},
+		{
+			{Name: "target-group", Service: elb.ServiceName, Run: a.cleanTargetGroups},
+			{Name: "instance", Service: ec2.ServiceName, Run: a.cleanInstances},
+			{Name: "redshift-subnet-group", Service: redshift.ServiceName, Run: a.cleanRedshiftSubnetGroups},
+			{Name: "db-subnet-group", Service: rds.ServiceName, Run: a.cleanDBSubnetGroups},
+			{Name: "elasticache-subnet-group", Service: elasticache.ServiceName, Run: a.cleanCacheSubnetGroups},
+			// certificates are released by the deletion of the load balancers using them.
+			{Name: "acm-certificate", Service: acm.ServiceName, Run: a.cleanACMCertificates},
+		},
+		{
+			{Name: "eni", Service: ec2.ServiceName, Run: a.cleanNetworkInterfaces},
+			{Name: "volume", Service: ec2.ServiceName, Run: a.cleanVolumes},
+			{Name: "image", Service: ec2.ServiceName, Run: a.cleanImages},
+			{Name: "launch-template", Service: ec2.ServiceName, Run: a.cleanLaunchTemplates},
+			{Name: "launch-configuration", Service: autoscaling.ServiceName, Run: a.cleanLaunchConfigurations},
+			// placement groups are deleted once the instances in them are terminated.
+			{Name: "placement-group", Service: ec2.ServiceName, Run: a.cleanPlacementGroups},
+			// NAT gateways are deleted before the elastic ips, as they hold some of them.
+			{Name: "nat-gateway", Service: ec2.ServiceName, Run: a.cleanNATGateways},
+		},
+		{
+			{Name: "snapshot", Service: ec2.ServiceName, Run: a.cleanSnapshots},
+			{Name: "eip", Service: ec2.ServiceName, Run: a.cleanElasticIPs},
+		},
+		{
+			// transit gateway attachments keep the subnets of their vpc busy.
+			{Name: "transit-gateway", Service: ec2.ServiceName, Run: a.cleanTransitGateways},
+			{Name: "security-group", Service: ec2.ServiceName, Run: a.cleanSecurityGroups},
+			{Name: "orphan-security-group", Service: ec2.ServiceName, Run: a.cleanOrphanSecurityGroups},
+		},
+		{
+			{Name: "cloudformation", Service: cloudformation.ServiceName, Run: a.cleanCfStacks},
+		},
+		{
+			{Name: "vpc", Service: ec2.ServiceName, Run: a.cleanVPCs},
+			{Name: "iam-role", Service: iam.ServiceName, Global: true, Run: a.cleanIAMRoles},
+			{Name: "route53", Service: route53.ServiceName, Global: true, Run: a.cleanRoute53Zones},
+		},
+		{
+			// instance profiles are cleaned up once the roles, which leave them behind, are deleted.
+			{Name: "instance-profile", Service: iam.ServiceName, Global: true, Run: a.cleanInstanceProfiles},
+		},
+	}
+}
+
+// runStages runs the stages one after the other in each account, one account after the
+// other, stopping when the context is done.
+func (a *action) runStages(ctx context.Context, input *Input, stages [][]Cleaner, accounts []*account, inputRegions []string) error {
+	var errs error
+	for _, acc := range accounts {
+		a.logger.Info("Cleaning up resources in account %s", acc.ID)
+		for _, stage := range stages {
+			if err := ctx.Err(); err != nil {
+				return multierr.Append(errs, fmt.Errorf("stopped before running all the cleaners: %w", err))
 			}
+			errs = multierr.Append(errs, a.runStage(ctx, input, stage, acc, inputRegions))
+		}
+	}
+
+	return errs
+}
+
+// filterStages removes the cleaners whose name isn't in resourceTypes, and the stages left
+// empty. All the cleaners are kept if resourceTypes is empty.
+func filterStages(stages [][]Cleaner, resourceTypes []string) ([][]Cleaner, error) {
+	if len(resourceTypes) == 0 {
+		return stages, nil
+	}
+
+	enabled := map[string]bool{}
+	for _, resourceType := range resourceTypes {
+		enabled[strings.TrimSpace(resourceType)] = true
+	}
 
-			Log("Cleaning up resources for service %s in region %s", cleaner.Service, region)
-			if err := cleaner.Run(ctx, scope); err != nil {
-				return fmt.Errorf("failed running cleanup for service %s: %w", cleaner.Service, err)
+	filtered := [][]Cleaner{}
+	for _, stage := range stages {
+		cleaners := []Cleaner{}
+		for _, cleaner := range stage {
+			if enabled[cleaner.Name] {
+				cleaners = append(cleaners, cleaner)
+				delete(enabled, cleaner.Name)
 			}
 		}
+		if len(cleaners) > 0 {
+			filtered = append(filtered, cleaners)
+		}
+	}
+
+	if len(enabled) > 0 {
+		unknown := []string{}
+		for resourceType := range enabled {
+			unknown = append(unknown, resourceType)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: %s", ErrUnknownResourceType, strings.Join(unknown, ", "))
+	}
+
+	return filtered, nil
+}
+
+// runStage runs every cleaner of the stage in every region it's available in, using a pool
+// of region workers that each run the cleaners of a region with a pool of workers, and
+// returns the combined errors of all of them.
+func (a *action) runStage(ctx context.Context, input *Input, stage []Cleaner, acc *account, inputRegions []string) error {
+	// the global cleaners run once, along with the cleaners of the region their endpoints are in.
+	regions := []string{}
+	cleanersByRegion := map[string][]Cleaner{}
+	addCleaner := func(region string, cleaner Cleaner) {
+		if _, ok := cleanersByRegion[region]; !ok {
+			regions = append(regions, region)
+		}
+		cleanersByRegion[region] = append(cleanersByRegion[region], cleaner)
+	}
+	for _, cleaner := range stage {
+		if cleaner.Global {
+			addCleaner(globalRegion, cleaner)
+			continue
+		}
+
+		for _, region := range getServiceRegions(cleaner.Service, inputRegions) {
+			addCleaner(region, cleaner)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		errs error
+		wg   sync.WaitGroup
+	)
+
+	jobs := make(chan string)
+	for i := 0; i < a.regionWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range jobs {
+				if err := a.runRegion(ctx, input, cleanersByRegion[region], acc, region, inputRegions); err != nil {
+					mu.Lock()
+					errs = multierr.Append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
 	}
 
+	for _, region := range regions {
+		jobs <- region
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// runRegion runs the cleaners in a region using a pool of workers, and returns the
+// combined errors of all of them.
+func (a *action) runRegion(ctx context.Context, input *Input, cleaners []Cleaner, acc *account, region string, inputRegions []string) error {
+	var (
+		mu   sync.Mutex
+		errs error
+		wg   sync.WaitGroup
+	)
+
+	jobs := make(chan Cleaner)
+	for i := 0; i < a.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cleaner := range jobs {
+				if err := a.runCleaner(ctx, input, cleaner, acc, region, inputRegions); err != nil {
+					mu.Lock()
+					errs = multierr.Append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, cleaner := range cleaners {
+		jobs <- cleaner
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+func (a *action) runCleaner(ctx context.Context, input *Input, cleaner Cleaner, acc *account, region string, inputRegions []string) error {
+	// each cleaner gets its own session, and so its own clients.
+	sess, err := a.newSession(region, acc)
+	if err != nil {
+		return err
+	}
+
+	// the ignore tags, the tag selector and the description patterns were already validated
+	// with the rest of the input.
+	ignoreTags, _ := parseIgnoreTags(input.IgnoreTag)
+	tagSelector, _ := parseTagExpression(input.TagSelector)
+	eniIncludeDescriptions, _ := parseDescriptionPatterns(input.ENIIncludeDescriptions)
+	eniExcludeDescriptions, _ := parseDescriptionPatterns(input.ENIExcludeDescriptions)
+
+	var plan map[string]bool
+	if a.applyPlanFile != "" {
+		plan = a.approved
+	}
+
+	scope := &CleanupScope{
+		Session:                    sess,
+		Region:                     region,
+		Regions:                    inputRegions,
+		AccountID:                  acc.ID,
+		RoleARN:                    acc.RoleARN,
+		ExternalID:                 acc.ExternalID,
+		Commit:                     input.Commit,
+		Mode:                       a.mode,
+		DeletionTag:                a.deletionTag,
+		IgnoreTags:                 ignoreTags,
+		MinAge:                     input.MinAge,
+		GracePeriod:                input.GracePeriod,
+		FirstSeen:                  input.TrackFirstSeen,
+		Immediate:                  parseIDs(input.ImmediateResourceTypes)[cleaner.Name],
+		Plan:                       plan,
+		ExcludeIDs:                 parseIDs(input.ExcludeIDs),
+		RequiredTags:               input.RequiredTags,
+		NATGatewayTimeout:          input.NATGatewayTimeout,
+		TagSelector:                tagSelector,
+		CloudFrontTimeout:          input.CloudFrontTimeout,
+		ECRRepositoryPrefix:        input.ECRRepositoryPrefix,
+		GluePrefix:                 input.GluePrefix,
+		TaskDefinitionPrefix:       input.TaskDefinitionPrefix,
+		TaskDefinitionKeep:         input.TaskDefinitionKeep,
+		PlacementGroupPrefix:       input.PlacementGroupPrefix,
+		KMSPendingWindow:           input.KMSPendingWindow,
+		SecretsRecoveryWindow:      input.SecretsRecoveryWindow,
+		SecretsForceDelete:         input.SecretsForceDelete,
+		DeleteACMCertificates:      input.DeleteACMCertificates,
+		ForceDetachENIs:            input.ForceDetachENIs,
+		DisableELBv2Protection:     input.DisableELBv2Protection,
+		TerminateSpotInstances:     input.TerminateSpotInstances,
+		StopStateMachineExecutions: input.StopStateMachineExecutions,
+		EventBridgeBuses:           input.EventBridgeBuses,
+		ENIVPCIDs:                  splitList(input.ENIVPCIDs),
+		ENIRequesterIDs:            splitList(input.ENIRequesterIDs),
+		ENIInterfaceTypes:          splitList(input.ENIInterfaceTypes),
+		Report:                     a.report,
+		ENIIncludeDescriptions:     eniIncludeDescriptions,
+		ENIExcludeDescriptions:     eniExcludeDescriptions,
+		Logger:                     a.logger,
+	}
+
+	// a stuck cleaner only stops itself, the next ones still run unless the whole run times out.
+	cleanerCtx := ctx
+	if input.CleanerTimeout > 0 {
+		var cancel context.CancelFunc
+		cleanerCtx, cancel = context.WithTimeout(ctx, input.CleanerTimeout)
+		defer cancel()
+	}
+
+	a.logger.Info("Cleaning up resources for service %s in region %s of account %s", cleaner.Service, region, acc.ID)
+	err = cleaner.Run(cleanerCtx, scope)
+	// cleaners log the failures of single resources and carry on, so a timeout doesn't
+	// necessarily make them return an error.
+	if ctx.Err() == nil && errors.Is(cleanerCtx.Err(), context.DeadlineExceeded) {
+		a.logger.Error("cleanup for service %s in region %s of account %s timed out after %s", cleaner.Service, region, acc.ID, input.CleanerTimeout)
+		a.report.cleanerFailed(region, cleaner.Name, fmt.Sprintf("timed out after %s", input.CleanerTimeout))
+		err = multierr.Append(err, fmt.Errorf("cleaner %s timed out: %w", cleaner.Name, cleanerCtx.Err()))
+	}
+	if err != nil {
+		return fmt.Errorf("failed running cleanup for service %s in region %s of account %s: %w", cleaner.Service, region, acc.ID, err)
+	}
+	a.logger.Info("Finished cleaning up resources for service %s in region %s of account %s", cleaner.Service, region, acc.ID)
+
 	return nil
 }
 
+// filterRegions removes the regions that aren't allowed, when allowed is set, and the denied
+// ones, which takes precedence. "*" is expanded to all the regions first so they can be filtered.
+func filterRegions(inputRegions, allowed, denied []string) ([]string, error) {
+	if len(inputRegions) == 1 && inputRegions[0] == "*" {
+		inputRegions = []string{}
+		for id := range endpoints.AwsPartition().Regions() {
+			inputRegions = append(inputRegions, id)
+		}
+		sort.Strings(inputRegions)
+	}
+
+	allowedRegions := parseIDs(allowed)
+	deniedRegions := parseIDs(denied)
+
+	regions := []string{}
+	for _, region := range inputRegions {
+		if len(allowedRegions) > 0 && !allowedRegions[region] {
+			continue
+		}
+		if deniedRegions[region] {
+			continue
+		}
+		regions = append(regions, region)
+	}
+
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoRegionLeft, strings.Join(inputRegions, ", "))
+	}
+
+	return regions, nil
+}
+
 func getServiceRegions(service string, inputRegions []string) []string {
 	regions := []string{}
-	allRegions := inputRegions[0] == "*"
+	allRegions := len(inputRegions) == 1 && inputRegions[0] == "*"
 
 	sr, exists := endpoints.RegionsForService(endpoints.DefaultPartitions(), endpoints.AwsPartitionID, service)
 	if exists {
@@ -2,18 +2,430 @@ package action
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 )
 
 const (
+	// DeletionTag is the default key of the tag marking the resources for deletion.
 	DeletionTag = "aws-janitor/marked-for-deletion"
+	// FirstSeenTag is set, when first seen, on the resources that don't expose their creation
+	// time, so their age can be told on the next runs.
+	FirstSeenTag = "janitor/first-seen"
+	// RunIDTag is set along with the deletion tag to the id of the run marking the resource.
+	RunIDTag = "janitor/run-id"
+	// ReasonTag is set along with the deletion tag to the reason of the marking, when one is given.
+	ReasonTag = "janitor/reason"
+)
+
+const (
+	// ModeMarkAndDelete marks the resources for future deletion and deletes the marked ones.
+	ModeMarkAndDelete = "mark-and-delete"
+	// ModeMarkOnly only marks the resources for future deletion, the marked ones are never deleted.
+	ModeMarkOnly = "mark-only"
+	// ModeDeleteOnly only deletes the resources already marked, e.g. by another process, and never marks any.
+	ModeDeleteOnly = "delete-only"
 )
 
 type CleanupScope struct {
-	Session   *session.Session
-	Commit    bool
-	IgnoreTag string
+	Session *session.Session
+	// Region is the region the session is scoped to.
+	Region string
+	// Regions are all the regions being cleaned up, for the global cleaners that depend on regional resources.
+	Regions []string
+	// AccountID is the account the session is in.
+	AccountID string
+	// RoleARN is the role assumed, with ExternalID if set, to create the session. It's empty
+	// when the default credentials are used.
+	RoleARN    string
+	ExternalID string
+	Commit     bool
+	// Mode is ModeMarkAndDelete, ModeMarkOnly or ModeDeleteOnly.
+	Mode string
+	// DeletionTag is the key of the tag marking the resources for deletion, DeletionTag by default.
+	DeletionTag string
+	// IgnoreTags are the tags that protect a resource from being cleaned up.
+	IgnoreTags []IgnoreTag
+	MinAge     time.Duration
+	// FirstSeen stamps the resources that don't expose their creation time with the first-seen
+	// tag, whose value is then used as their creation time.
+	FirstSeen bool
+	// GracePeriod is how long a resource stays marked for deletion before it's deleted.
+	GracePeriod time.Duration
+	// Immediate deletes the resources on the first run, without marking them first, when marking
+	// and deleting resources.
+	Immediate bool
+	// Plan holds the approval keys of the resources of the plan being applied, it's nil otherwise.
+	Plan map[string]bool
+	// ExcludeIDs are the ids, or arns, of the resources that must never be cleaned up.
+	ExcludeIDs map[string]bool
+	// RequiredTags are the tags, with their values, a resource must carry to be considered for cleanup.
+	RequiredTags map[string]string
+	// TagSelector is the tag expression a resource must match to be considered for cleanup.
+	TagSelector tagExpression
+	// NATGatewayTimeout is how long to wait for NAT gateways to be deleted, either those of a vpc
+	// or those cleaned up on their own.
+	NATGatewayTimeout time.Duration
+	// CloudFrontTimeout is how long to wait for a disabled cloudfront distribution to be deployed
+	// before deleting it, it's left for a next run otherwise.
+	CloudFrontTimeout time.Duration
+	// ECRRepositoryPrefix restricts the cleanup of ecr repositories to the ones whose name starts with it.
+	ECRRepositoryPrefix string
+	// GluePrefix restricts the cleanup of the glue jobs, crawlers and databases to the ones whose name starts with it.
+	GluePrefix string
+	// TaskDefinitionPrefix restricts the cleanup of the task definitions to the families whose name starts with it.
+	TaskDefinitionPrefix string
+	// TaskDefinitionKeep is how many of the latest revisions of each task definition family are never deregistered.
+	TaskDefinitionKeep int
+	// PlacementGroupPrefix selects the placement groups that can't be tagged, which are only cleaned up
+	// when their name starts with it.
+	PlacementGroupPrefix string
+	// KMSPendingWindow is the number of days after which the kms keys scheduled for deletion are deleted.
+	KMSPendingWindow int64
+	// SecretsRecoveryWindow is the number of days during which a deleted secret can still be restored.
+	SecretsRecoveryWindow int64
+	// SecretsForceDelete deletes the secrets right away, without any recovery window.
+	SecretsForceDelete bool
+	// ForceDetachENIs force-detaches the lingering attachments of the available network
+	// interfaces so they can be deleted.
+	ForceDetachENIs bool
+	// DisableELBv2Protection disables the deletion protection of the v2 load balancers being
+	// deleted, which are skipped otherwise.
+	DisableELBv2Protection bool
+	// TerminateSpotInstances terminates the instances launched by the cancelled spot requests.
+	TerminateSpotInstances bool
+	// StopStateMachineExecutions stops the running executions of the state machines being deleted,
+	// which are otherwise left to finish before the deletion completes.
+	StopStateMachineExecutions bool
+	// EventBridgeBuses is which event buses have their rules cleaned up: the default one, the custom
+	// ones or all of them.
+	EventBridgeBuses string
+	// ENIVPCIDs, ENIRequesterIDs and ENIInterfaceTypes restrict the cleanup of the network interfaces
+	// to the ones in these vpcs, created by these requesters (e.g. "amazon-elb") or of these types
+	// (e.g. "lambda"), when set.
+	ENIVPCIDs         []string
+	ENIRequesterIDs   []string
+	ENIInterfaceTypes []string
+	// ENIIncludeDescriptions and ENIExcludeDescriptions select the network interfaces by their
+	// description: it must match one of the include patterns, when set, and none of the exclude ones.
+	ENIIncludeDescriptions []descriptionPattern
+	ENIExcludeDescriptions []descriptionPattern
+	// DeleteACMCertificates enables the cleanup of the acm certificates released by the deleted load balancers.
+	DeleteACMCertificates bool
+	Report                *Report
+	Logger                Logger
+}
+
+// errAlreadyDeleted is returned by the delete helpers when the resource was already gone,
+// e.g. deleted by an overlapping run.
+var errAlreadyDeleted = errors.New("already deleted")
+
+// notFoundErrorCodes are the aws error codes returned when a resource doesn't exist, besides
+// the ec2 ones ending with ".NotFound".
+var notFoundErrorCodes = map[string]struct{}{
+	"NatGatewayNotFound":            {},
+	"LoadBalancerNotFound":          {},
+	"ListenerNotFound":              {},
+	"TargetGroupNotFound":           {},
+	"NoSuchDistribution":            {},
+	"InvalidPlacementGroup.Unknown": {},
+	"NoSuchEntity":                  {},
+	"EntityNotFoundException":       {},
+	"StateMachineDoesNotExist":      {},
+	"DBSubnetGroupNotFoundFault":    {},
+}
+
+func isNotFoundCode(code string) bool {
+	if strings.HasSuffix(code, ".NotFound") {
+		return true
+	}
+	_, ok := notFoundErrorCodes[code]
+	return ok
+}
+
+func isNotFoundError(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && isNotFoundCode(aerr.Code())
+}
+
+// isAlreadyDeleted returns true, and logs it, when err means the resource was already deleted,
+// which is as good as deleting it.
+func isAlreadyDeleted(logger Logger, err error, resourceType, id string) bool {
+	if !isNotFoundError(err) {
+		return false
+	}
+	logger.Debug("%s %s was already deleted", resourceType, id)
+	return true
 }
 
 type CleanupFunc func(ctx context.Context, input *CleanupScope) error
+
+// resource describes an aws resource considered for cleanup.
+type resource struct {
+	// Type is the kind of resource, as used in logs (e.g. "vpc").
+	Type string
+	ID   string
+	// ARN is only set for the resources that have one, so they can be excluded by it too.
+	ARN string
+	// Name is only set for the resources identified by their arn, so they can be excluded by it too.
+	Name string
+	Tags Tags
+	// CreatedAt is zero for resources whose api doesn't expose their creation time.
+	CreatedAt time.Time
+	// Stampable is set by the cleaners of the resources whose api doesn't expose their creation
+	// time, which handle verdictStamp by setting the first-seen tag.
+	Stampable bool
+}
+
+type verdict int
+
+const (
+	// verdictSkip means the resource must be left untouched.
+	verdictSkip verdict = iota
+	// verdictMark means the resource should be marked for future deletion.
+	verdictMark
+	// verdictDelete means the resource was marked by a previous run and should be deleted.
+	verdictDelete
+	// verdictStamp means the resource doesn't expose its creation time and was seen for the first
+	// time, it should be stamped with the first-seen tag and left untouched otherwise.
+	verdictStamp
+)
+
+// evaluate decides what should be done with a resource based on its tags and age.
+func (s *CleanupScope) evaluate(r resource) verdict {
+	if s.ExcludeIDs[r.ID] || (r.ARN != "" && s.ExcludeIDs[r.ARN]) || (r.Name != "" && s.ExcludeIDs[r.Name]) {
+		s.Logger.Debug("%s %s is excluded by id, skipping cleanup", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "excluded by id")
+		return verdictSkip
+	}
+
+	if !s.hasRequiredTags(r.Tags) {
+		s.Logger.Debug("%s %s doesn't have the required tags, skipping cleanup", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "missing required tags")
+		return verdictSkip
+	}
+
+	if !s.TagSelector.matches(r.Tags) {
+		s.Logger.Debug("%s %s doesn't match the tag selector, skipping cleanup", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "not matching tag selector")
+		return verdictSkip
+	}
+
+	if s.isIgnored(r.Tags) {
+		s.Logger.Debug("%s %s has ignore tag, skipping cleanup", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "has ignore tag")
+		return verdictSkip
+	}
+
+	// the resources that don't expose their creation time are as old as their first-seen tag.
+	if r.CreatedAt.IsZero() && s.FirstSeen {
+		if firstSeen, ok := parseDeletionTagValue(r.Tags[FirstSeenTag]); ok {
+			r.CreatedAt = firstSeen
+		}
+	}
+
+	if !r.CreatedAt.IsZero() && time.Since(r.CreatedAt) < s.MinAge {
+		s.Logger.Debug("%s %s was created less than %s ago, skipping cleanup", r.Type, r.ID, s.MinAge)
+		s.Report.skipped(s.Region, r.Type, r.ID, "younger than min age")
+		return verdictSkip
+	}
+
+	value, marked := r.Tags[s.DeletionTag]
+	// the min age of the resources that don't expose their creation time is checked against
+	// their deletion tag, so they're still marked first.
+	immediate := s.Immediate && (!r.CreatedAt.IsZero() || s.MinAge == 0)
+	// a plan holds the resources of the immediate types while they're still unmarked, they're
+	// deleted as long as their type is still deleted immediately.
+	if !marked && immediate && s.Plan[approvalKey(s.AccountID, s.Region, r.Type, r.ID)] {
+		s.Logger.Debug("%s %s isn't marked for deletion, deleting it right away as it's in the plan and its type is deleted immediately", r.Type, r.ID)
+		return verdictDelete
+	}
+	if !marked && s.Mode == ModeDeleteOnly {
+		s.Logger.Debug("%s %s isn't marked for deletion, skipping cleanup as running in delete-only mode", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "not marked, delete-only mode")
+		return verdictSkip
+	}
+	if _, stamped := r.Tags[FirstSeenTag]; !marked && !stamped && r.Stampable && r.CreatedAt.IsZero() && s.FirstSeen && s.MinAge > 0 {
+		s.Logger.Debug("%s %s is seen for the first time, stamping it and skipping cleanup", r.Type, r.ID)
+		return verdictStamp
+	}
+	if !marked {
+		if immediate && s.Mode == ModeMarkAndDelete {
+			s.Logger.Debug("%s %s isn't marked for deletion, deleting it right away as its type is deleted immediately", r.Type, r.ID)
+			return verdictDelete
+		}
+		return verdictMark
+	}
+
+	// resources marked by older versions don't know when they were marked, they're
+	// eligible for deletion straight away.
+	markedAt, ok := parseDeletionTagValue(value)
+	if !ok {
+		return verdictDelete
+	}
+
+	if time.Since(markedAt) < s.GracePeriod {
+		s.Logger.Debug("%s %s was marked for deletion less than %s ago, skipping cleanup", r.Type, r.ID, s.GracePeriod)
+		s.Report.skipped(s.Region, r.Type, r.ID, "within grace period")
+		return verdictSkip
+	}
+
+	// resources that don't expose their creation time are considered as old as their deletion tag.
+	if r.CreatedAt.IsZero() && time.Since(markedAt) < s.MinAge {
+		s.Logger.Debug("%s %s was marked for deletion less than %s ago, skipping cleanup", r.Type, r.ID, s.MinAge)
+		s.Report.skipped(s.Region, r.Type, r.ID, "younger than min age")
+		return verdictSkip
+	}
+
+	if s.Mode == ModeMarkOnly {
+		s.Logger.Debug("%s %s is marked for deletion, skipping deletion as running in mark-only mode", r.Type, r.ID)
+		s.Report.skipped(s.Region, r.Type, r.ID, "mark-only mode")
+		return verdictSkip
+	}
+
+	return verdictDelete
+}
+
+// wouldMark logs and reports a resource that would be marked for future deletion, in place of
+// marking it when not committing, so a dry-run previews the marking too.
+func (s *CleanupScope) wouldMark(resourceType, id string) {
+	s.Logger.Info("Would mark %s %s for future deletion", resourceType, id)
+	s.Report.wouldMark(s.Region, resourceType, id)
+}
+
+// markForDeletion marks a resource for future deletion with mark when committing, and only
+// reports that it would be marked otherwise.
+func (a *action) markForDeletion(input *CleanupScope, resourceType, id string, mark func() error) {
+	if !a.commit {
+		input.wouldMark(resourceType, id)
+		return
+	}
+
+	a.logger.Debug("%s %s does not have deletion tag, marking for future deletion and skipping cleanup", resourceType, id)
+	if err := mark(); err != nil {
+		a.logger.Error("failed to mark %s %s for future deletion: %s", resourceType, id, err.Error())
+		input.Report.failed(input.Region, resourceType, id, err.Error())
+		return
+	}
+	input.Report.marked(input.Region, resourceType, id)
+}
+
+// canDelete returns true if a resource can be deleted now. It can't when not committing, in which
+// case it's reported as a resource that would be deleted, or when its deletion wasn't confirmed.
+func (a *action) canDelete(input *CleanupScope, resourceType, id string) bool {
+	if !a.commit {
+		a.logger.Debug("skipping deletion of %s %s as running in dry-mode", resourceType, id)
+		input.Report.wouldDelete(input.Region, resourceType, id)
+		return false
+	}
+
+	if !a.isConfirmed(input.AccountID, input.Region, resourceType, id) {
+		a.logger.Debug("skipping deletion of %s %s as it wasn't confirmed", resourceType, id)
+		input.Report.skipped(input.Region, resourceType, id, "deletion not confirmed")
+		return false
+	}
+
+	return true
+}
+
+// stampFirstSeen sets the first-seen tag on a resource when committing, with tag, which is the
+// call used to mark the resource.
+func (a *action) stampFirstSeen(input *CleanupScope, resourceType, id string, tag func(tags Tags) error) {
+	if !a.commit {
+		a.logger.Info("Would stamp %s %s as first seen", resourceType, id)
+		input.Report.skipped(input.Region, resourceType, id, "first seen, would be stamped")
+		return
+	}
+
+	a.logger.Info("Stamping %s %s as first seen", resourceType, id)
+	// the first-seen tag has the same format as the deletion tag.
+	if err := tag(Tags{FirstSeenTag: deletionTagValue()}); err != nil {
+		a.logger.Error("failed to stamp %s %s as first seen: %s", resourceType, id, err.Error())
+		input.Report.failed(input.Region, resourceType, id, err.Error())
+		return
+	}
+	input.Report.skipped(input.Region, resourceType, id, "first seen")
+}
+
+// IgnoreTag is a tag that protects a resource from being cleaned up. An empty
+// value or "*" matches any value.
+type IgnoreTag struct {
+	Key   string
+	Value string
+}
+
+// condition returns the tag condition matching the resources protected by the ignore tag.
+func (t IgnoreTag) condition() tagCondition {
+	if t.Value == "" || t.Value == "*" {
+		return tagCondition{Key: t.Key, Operator: tagExists}
+	}
+	return tagCondition{Key: t.Key, Operator: tagIn, Values: []string{t.Value}}
+}
+
+// isIgnored returns true if the tags include any of the ignore tags.
+func (s *CleanupScope) isIgnored(tags Tags) bool {
+	// the ignore tags are alternatives, each one is a conjunction of a single condition.
+	expression := tagExpression{}
+	for _, ignoreTag := range s.IgnoreTags {
+		expression = append(expression, []tagCondition{ignoreTag.condition()})
+	}
+
+	return len(expression) > 0 && expression.matches(tags)
+}
+
+// hasRequiredTags returns true if the tags include all the required tags with the same values.
+func (s *CleanupScope) hasRequiredTags(tags Tags) bool {
+	conditions := []tagCondition{}
+	for key, value := range s.RequiredTags {
+		conditions = append(conditions, tagCondition{Key: key, Operator: tagIn, Values: []string{value}})
+	}
+	return allTagConditions(conditions, tags)
+}
+
+// markTags returns the tags set on a resource being marked for deletion now: the deletion tag,
+// the id of the run and the reason, if any.
+func (a *action) markTags() Tags {
+	tags := Tags{a.deletionTag: deletionTagValue(), RunIDTag: a.runID}
+	if a.markReason != "" {
+		tags[ReasonTag] = a.markReason
+	}
+	return tags
+}
+
+// deletionTagValue returns the value of the deletion tag for a resource being marked now.
+func deletionTagValue() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// parseDeletionTagValue returns when a resource was marked for deletion. Resources marked
+// by older versions have "true" as value, in which case it returns false.
+func parseDeletionTagValue(value string) (time.Time, bool) {
+	markedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return markedAt, true
+}
+
+// regionalARN returns the arn of a resource of the scope's region and account, for the services
+// whose api doesn't return it but needs it to tag the resource.
+func regionalARN(input *CleanupScope, service, resource string) string {
+	partition := endpoints.AwsPartitionID
+	if p, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), input.Region); ok {
+		partition = p.ID()
+	}
+
+	return arn.ARN{
+		Partition: partition,
+		Service:   service,
+		Region:    input.Region,
+		AccountID: input.AccountID,
+		Resource:  resource,
+	}.String()
+}
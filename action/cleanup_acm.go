@@ -0,0 +1,167 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+)
+
+// releasedCertificates tracks the acm certificates that were attached to the listeners of the
+// deleted load balancers, so they can be cleaned up along with them. It's safe for concurrent use.
+type releasedCertificates struct {
+	mu sync.Mutex
+	// certificates holds, per account and region, the load balancers each certificate was released by.
+	certificates map[string]map[string][]string
+}
+
+func (r *releasedCertificates) add(accountID, region, lbArn string, certificateArns []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.certificates == nil {
+		r.certificates = map[string]map[string][]string{}
+	}
+	key := accountID + "/" + region
+	if r.certificates[key] == nil {
+		r.certificates[key] = map[string][]string{}
+	}
+	for _, certificateArn := range certificateArns {
+		r.certificates[key][certificateArn] = append(r.certificates[key][certificateArn], lbArn)
+	}
+}
+
+// releasedBy returns the load balancers the certificate was released by, if it was.
+func (r *releasedCertificates) releasedBy(accountID, region, certificateArn string) ([]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lbArns, ok := r.certificates[accountID+"/"+region][certificateArn]
+	return lbArns, ok
+}
+
+// cleanACMCertificates cleans up the certificates released by the load balancers deleted in this run,
+// once marked, and the ones marked by a previous run. Other certificates are never considered.
+func (a *action) cleanACMCertificates(ctx context.Context, input *CleanupScope) error {
+	if !input.DeleteACMCertificates {
+		a.logger.Debug("acm certificates cleanup isn't enabled, skipping")
+		return nil
+	}
+
+	client := acm.New(input.Session)
+
+	certificatesToDelete := []*string{}
+	pageFunc := func(page *acm.ListCertificatesOutput, _ bool) bool {
+		for _, certificate := range page.CertificateSummaryList {
+			certificateArn := aws.StringValue(certificate.CertificateArn)
+
+			tagOut, err := client.ListTagsForCertificateWithContext(ctx, &acm.ListTagsForCertificateInput{CertificateArn: certificate.CertificateArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for acm certificate %s: %s", certificateArn, err.Error())
+				continue
+			}
+			tags := acmTags(tagOut.Tags)
+
+			releasedBy, released := a.releasedCertificates.releasedBy(input.AccountID, input.Region, certificateArn)
+			if _, marked := tags[input.DeletionTag]; !released && !marked {
+				continue
+			}
+
+			// a certificate can be shared, e.g. a wildcard one, so it's only cleaned up once
+			// nothing but the deleted load balancers uses it.
+			inUseBy, err := a.getCertificateUsers(ctx, certificateArn, releasedBy, client)
+			if err != nil {
+				a.logger.Error("failed getting acm certificate %s: %s", certificateArn, err.Error())
+				continue
+			}
+			if len(inUseBy) > 0 {
+				a.logger.Debug("acm certificate %s is in use by %s, skipping cleanup", certificateArn, strings.Join(inUseBy, ", "))
+				input.Report.skipped(input.Region, "acm certificate", certificateArn, "in use by "+strings.Join(inUseBy, ", "))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "acm certificate", ID: certificateArn, ARN: certificateArn, Tags: tags, CreatedAt: aws.TimeValue(certificate.CreatedAt)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "acm certificate", certificateArn, func() error {
+					return a.markCertificateForFutureDeletion(ctx, certificateArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding acm certificate %s to delete list", certificateArn)
+			certificatesToDelete = append(certificatesToDelete, certificate.CertificateArn)
+		}
+
+		return true
+	}
+
+	// only the RSA 2048 certificates are listed unless all the key types are asked for.
+	listInput := &acm.ListCertificatesInput{Includes: &acm.Filters{KeyTypes: aws.StringSlice(acm.KeyAlgorithm_Values())}}
+	if err := client.ListCertificatesPagesWithContext(ctx, listInput, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of acm certificates: %w", err)
+	}
+
+	if len(certificatesToDelete) == 0 {
+		a.logger.Info("no acm certificates to delete")
+		return nil
+	}
+
+	for _, certificateArn := range certificatesToDelete {
+		if !a.canDelete(input, "acm certificate", *certificateArn) {
+			continue
+		}
+
+		if err := a.deleteCertificate(ctx, *certificateArn, client); err != nil {
+			a.logger.Error("failed to delete acm certificate %s: %s", *certificateArn, err.Error())
+			input.Report.failed(input.Region, "acm certificate", *certificateArn, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "acm certificate", *certificateArn)
+	}
+
+	return nil
+}
+
+// getCertificateUsers returns the resources using the certificate, other than the deleted load balancers.
+func (a *action) getCertificateUsers(ctx context.Context, certificateArn string, deletedLBs []string, client *acm.ACM) ([]string, error) {
+	out, err := client.DescribeCertificateWithContext(ctx, &acm.DescribeCertificateInput{CertificateArn: &certificateArn})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := parseIDs(deletedLBs)
+	users := []string{}
+	for _, user := range aws.StringValueSlice(out.Certificate.InUseBy) {
+		if !deleted[user] {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+func (a *action) markCertificateForFutureDeletion(ctx context.Context, certificateArn string, client *acm.ACM) error {
+	a.logger.Info("Marking ACM certificate %s for future deletion", certificateArn)
+
+	_, err := client.AddTagsToCertificateWithContext(ctx, &acm.AddTagsToCertificateInput{
+		CertificateArn: &certificateArn,
+		Tags:           acmTagList(a.markTags()),
+	})
+
+	return err
+}
+
+func (a *action) deleteCertificate(ctx context.Context, certificateArn string, client *acm.ACM) error {
+	a.logger.Info("Deleting ACM certificate %s", certificateArn)
+
+	if _, err := client.DeleteCertificateWithContext(ctx, &acm.DeleteCertificateInput{CertificateArn: &certificateArn}); err != nil {
+		return fmt.Errorf("failed to delete acm certificate %s: %w", certificateArn, err)
+	}
+
+	return nil
+}
@@ -14,33 +14,17 @@ func (a *action) cleanASGs(ctx context.Context, input *CleanupScope) error {
 	asgToDelete := []*autoscaling.Group{}
 	pageFunc := func(page *autoscaling.DescribeAutoScalingGroupsOutput, _ bool) bool {
 		for _, asg := range page.AutoScalingGroups {
-			var ignore, markedForDeletion bool
-			for _, tag := range asg.Tags {
-				switch aws.StringValue(tag.Key) {
-				case input.IgnoreTag:
-					ignore = true
-				case DeletionTag:
-					markedForDeletion = true
-				}
-			}
-
-			if ignore {
-				LogDebug("asg %s has ignore tag, skipping cleanup", *asg.AutoScalingGroupName)
+			switch input.evaluate(resource{Type: "asg", ID: *asg.AutoScalingGroupName, Tags: autoscalingTags(asg.Tags), CreatedAt: aws.TimeValue(asg.CreatedTime)}) {
+			case verdictSkip:
 				continue
-			}
-
-			if !markedForDeletion {
-				// NOTE: only mark for future deletion if we're not running in dry-mode
-				if a.commit {
-					LogDebug("asg %s does not have deletion tag, marking for future deletion and skipping cleanup", *asg.AutoScalingGroupName)
-					if err := a.markAsgForFutureDeletion(ctx, *asg.AutoScalingGroupName, client); err != nil {
-						LogError("failed to mark asg %s for future deletion: %s", *asg.AutoScalingGroupName, err.Error())
-					}
-				}
+			case verdictMark:
+				a.markForDeletion(input, "asg", *asg.AutoScalingGroupName, func() error {
+					return a.markAsgForFutureDeletion(ctx, *asg.AutoScalingGroupName, client)
+				})
 				continue
 			}
 
-			LogDebug("adding asg %s to delete list", *asg.AutoScalingGroupName)
+			a.logger.Debug("adding asg %s to delete list", *asg.AutoScalingGroupName)
 			asgToDelete = append(asgToDelete, asg)
 		}
 
@@ -52,23 +36,24 @@ func (a *action) cleanASGs(ctx context.Context, input *CleanupScope) error {
 	}
 
 	if len(asgToDelete) == 0 {
-		Log("no autoscaling groups to delete")
+		a.logger.Info("no autoscaling groups to delete")
 		return nil
 	}
 
 	deletedNames := []*string{}
 	for _, asg := range asgToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of asg %s as running in dry-mode", *asg.AutoScalingGroupName)
-			return nil
+		if !a.canDelete(input, "asg", *asg.AutoScalingGroupName) {
+			continue
 		}
 
-		Log("Deleting asg %s", *asg.AutoScalingGroupName)
+		a.logger.Info("Deleting asg %s", *asg.AutoScalingGroupName)
 		if _, err := client.DeleteAutoScalingGroupWithContext(ctx, &autoscaling.DeleteAutoScalingGroupInput{AutoScalingGroupName: asg.AutoScalingGroupName}); err != nil {
-			LogError("failed to delete asg %s: %s", *asg.AutoScalingGroupName, err.Error())
+			a.logger.Error("failed to delete asg %s: %s", *asg.AutoScalingGroupName, err.Error())
+			input.Report.failed(input.Region, "asg", *asg.AutoScalingGroupName, err.Error())
 			continue
 		}
 
+		input.Report.deleted(input.Region, "asg", *asg.AutoScalingGroupName)
 		deletedNames = append(deletedNames, asg.AutoScalingGroupName)
 	}
 
@@ -76,7 +61,7 @@ func (a *action) cleanASGs(ctx context.Context, input *CleanupScope) error {
 		if err := client.WaitUntilGroupNotExistsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
 			AutoScalingGroupNames: deletedNames,
 		}); err != nil {
-			LogError("failed to wait for asg to be deleted: %s", err.Error())
+			a.logger.Error("failed to wait for asg to be deleted: %s", err.Error())
 		}
 	}
 
@@ -84,17 +69,21 @@ func (a *action) cleanASGs(ctx context.Context, input *CleanupScope) error {
 }
 
 func (a *action) markAsgForFutureDeletion(ctx context.Context, asgName string, client *autoscaling.AutoScaling) error {
-	Log("Marking ASG %s for future deletion", asgName)
+	a.logger.Info("Marking ASG %s for future deletion", asgName)
 
-	_, err := client.CreateOrUpdateTagsWithContext(ctx, &autoscaling.CreateOrUpdateTagsInput{Tags: []*autoscaling.Tag{
-		{
-			Key:               aws.String(DeletionTag),
+	markTags := a.markTags()
+	tags := []*autoscaling.Tag{}
+	for _, key := range markTags.keys() {
+		tags = append(tags, &autoscaling.Tag{
+			Key:               aws.String(key),
 			PropagateAtLaunch: aws.Bool(true),
 			ResourceId:        aws.String(asgName),
 			ResourceType:      aws.String("auto-scaling-group"),
-			Value:             aws.String("true"),
-		},
-	}})
+			Value:             aws.String(markTags[key]),
+		})
+	}
+
+	_, err := client.CreateOrUpdateTagsWithContext(ctx, &autoscaling.CreateOrUpdateTagsInput{Tags: tags})
 
 	return err
 }
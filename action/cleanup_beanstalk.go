@@ -0,0 +1,187 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
+)
+
+func (a *action) cleanBeanstalkEnvironments(ctx context.Context, input *CleanupScope) error {
+	client := elasticbeanstalk.New(input.Session)
+
+	environments, err := a.getBeanstalkEnvironments(ctx, "", client)
+	if err != nil {
+		return fmt.Errorf("failed getting list of beanstalk environments: %w", err)
+	}
+
+	environmentsToDelete := []*elasticbeanstalk.EnvironmentDescription{}
+	for _, env := range environments {
+		name := aws.StringValue(env.EnvironmentName)
+
+		// environments being launched or updated can't be terminated, and the
+		// ones already being terminated are left alone.
+		if status := aws.StringValue(env.Status); status != elasticbeanstalk.EnvironmentStatusReady {
+			a.logger.Debug("beanstalk environment %s is %s, skipping cleanup", name, status)
+			continue
+		}
+
+		tagOut, err := client.ListTagsForResourceWithContext(ctx, &elasticbeanstalk.ListTagsForResourceInput{ResourceArn: env.EnvironmentArn})
+		if err != nil {
+			a.logger.Error("failed getting tags for beanstalk environment %s: %s", name, err.Error())
+			continue
+		}
+
+		switch input.evaluate(resource{Type: "beanstalk environment", ID: name, ARN: aws.StringValue(env.EnvironmentArn), Tags: beanstalkTags(tagOut.ResourceTags), CreatedAt: aws.TimeValue(env.DateCreated)}) {
+		case verdictSkip:
+			continue
+		case verdictMark:
+			a.markForDeletion(input, "beanstalk environment", name, func() error {
+				return a.markBeanstalkResourceForFutureDeletion(ctx, aws.StringValue(env.EnvironmentArn), client)
+			})
+			continue
+		}
+
+		a.logger.Debug("adding beanstalk environment %s to delete list", name)
+		environmentsToDelete = append(environmentsToDelete, env)
+	}
+
+	if len(environmentsToDelete) == 0 {
+		a.logger.Info("no beanstalk environments to delete")
+		return nil
+	}
+
+	// the applications are only deleted once all their environments are gone, so keep
+	// track of the ones whose environments were terminated.
+	applications := map[string]bool{}
+	for _, env := range environmentsToDelete {
+		name := aws.StringValue(env.EnvironmentName)
+		if !a.canDelete(input, "beanstalk environment", name) {
+			continue
+		}
+
+		if err := a.terminateBeanstalkEnvironment(ctx, env, client); err != nil {
+			a.logger.Error("failed to delete beanstalk environment %s: %s", name, err.Error())
+			input.Report.failed(input.Region, "beanstalk environment", name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "beanstalk environment", name)
+		applications[aws.StringValue(env.ApplicationName)] = true
+	}
+
+	for application := range applications {
+		if err := a.deleteEmptyBeanstalkApplication(ctx, application, input, client); err != nil {
+			a.logger.Error("failed to delete beanstalk application %s: %s", application, err.Error())
+			input.Report.failed(input.Region, "beanstalk application", application, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// getBeanstalkEnvironments returns the environments that weren't terminated, of the application
+// if set or of all of them otherwise.
+func (a *action) getBeanstalkEnvironments(ctx context.Context, application string, client *elasticbeanstalk.ElasticBeanstalk) ([]*elasticbeanstalk.EnvironmentDescription, error) {
+	environments := []*elasticbeanstalk.EnvironmentDescription{}
+	params := &elasticbeanstalk.DescribeEnvironmentsInput{IncludeDeleted: aws.Bool(false)}
+	if application != "" {
+		params.ApplicationName = &application
+	}
+	for {
+		out, err := client.DescribeEnvironmentsWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		environments = append(environments, out.Environments...)
+
+		if out.NextToken == nil {
+			return environments, nil
+		}
+		params.NextToken = out.NextToken
+	}
+}
+
+func (a *action) markBeanstalkResourceForFutureDeletion(ctx context.Context, arn string, client *elasticbeanstalk.ElasticBeanstalk) error {
+	a.logger.Info("Marking Beanstalk resource %s for future deletion", arn)
+
+	_, err := client.UpdateTagsForResourceWithContext(ctx, &elasticbeanstalk.UpdateTagsForResourceInput{
+		ResourceArn: &arn,
+		TagsToAdd:   beanstalkTagList(a.markTags()),
+	})
+
+	return err
+}
+
+// terminateBeanstalkEnvironment terminates the environment along with the resources it owns
+// (e.g. auto scaling groups, load balancers), and waits for its termination.
+func (a *action) terminateBeanstalkEnvironment(ctx context.Context, env *elasticbeanstalk.EnvironmentDescription, client *elasticbeanstalk.ElasticBeanstalk) error {
+	name := aws.StringValue(env.EnvironmentName)
+	a.logger.Info("Terminating Beanstalk environment %s of application %s", name, aws.StringValue(env.ApplicationName))
+
+	if _, err := client.TerminateEnvironmentWithContext(ctx, &elasticbeanstalk.TerminateEnvironmentInput{
+		EnvironmentId:      env.EnvironmentId,
+		TerminateResources: aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("failed to terminate beanstalk environment %s: %w", name, err)
+	}
+
+	if err := waitUntil(ctx, 20*time.Minute, 20*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeEnvironmentsWithContext(ctx, &elasticbeanstalk.DescribeEnvironmentsInput{EnvironmentIds: []*string{env.EnvironmentId}})
+		if err != nil {
+			a.logger.Warn("error while waiting for beanstalk environment %s termination: %s", name, err.Error())
+			return false, nil
+		}
+		for _, current := range out.Environments {
+			if aws.StringValue(current.Status) != elasticbeanstalk.EnvironmentStatusTerminated {
+				return false, nil
+			}
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for beanstalk environment %s termination: %w", name, err)
+	}
+
+	return nil
+}
+
+// deleteEmptyBeanstalkApplication deletes the application if it has no environments left,
+// unless it's protected.
+func (a *action) deleteEmptyBeanstalkApplication(ctx context.Context, application string, input *CleanupScope, client *elasticbeanstalk.ElasticBeanstalk) error {
+	environments, err := a.getBeanstalkEnvironments(ctx, application, client)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+	if len(environments) > 0 {
+		a.logger.Debug("beanstalk application %s still has %d environments, skipping deletion", application, len(environments))
+		return nil
+	}
+
+	out, err := client.DescribeApplicationsWithContext(ctx, &elasticbeanstalk.DescribeApplicationsInput{ApplicationNames: []*string{&application}})
+	if err != nil {
+		return fmt.Errorf("failed to describe application: %w", err)
+	}
+	if len(out.Applications) == 0 {
+		return nil
+	}
+	for _, app := range out.Applications {
+		tagOut, err := client.ListTagsForResourceWithContext(ctx, &elasticbeanstalk.ListTagsForResourceInput{ResourceArn: app.ApplicationArn})
+		if err != nil {
+			return fmt.Errorf("failed to get tags: %w", err)
+		}
+		if input.isIgnored(beanstalkTags(tagOut.ResourceTags)) || input.ExcludeIDs[application] || input.ExcludeIDs[aws.StringValue(app.ApplicationArn)] {
+			a.logger.Debug("beanstalk application %s is protected, skipping deletion", application)
+			input.Report.skipped(input.Region, "beanstalk application", application, "has ignore tag or is excluded")
+			return nil
+		}
+	}
+
+	a.logger.Info("Deleting Beanstalk application %s", application)
+	if _, err := client.DeleteApplicationWithContext(ctx, &elasticbeanstalk.DeleteApplicationInput{ApplicationName: &application}); err != nil {
+		return err
+	}
+	input.Report.deleted(input.Region, "beanstalk application", application)
+
+	return nil
+}
@@ -3,8 +3,11 @@ package action
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	cf "github.com/aws/aws-sdk-go/service/cloudformation"
 )
 
@@ -15,58 +18,49 @@ func (a *action) cleanCfStacks(ctx context.Context, input *CleanupScope) error {
 	pageFunc := func(page *cf.DescribeStacksOutput, _ bool) bool {
 		for _, stack := range page.Stacks {
 			if aws.StringValue(stack.StackName) == "cluster-api-provider-aws-sigs-k8s-io" {
-				LogDebug("cloudformation stack %s is hardcoded to be skipped, skipping cleanup", aws.StringValue(stack.StackName))
+				a.logger.Debug("cloudformation stack %s is hardcoded to be skipped, skipping cleanup", aws.StringValue(stack.StackName))
 				continue
 			}
 
-			var ignore, markedForDeletion bool
-			for _, tag := range stack.Tags {
-				switch aws.StringValue(tag.Key) {
-				case input.IgnoreTag:
-					ignore = true
-				case DeletionTag:
-					markedForDeletion = true
-				}
+			verdict := input.evaluate(resource{Type: "cloudformation stack", ID: *stack.StackName, Tags: cloudformationTags(stack.Tags), CreatedAt: aws.TimeValue(stack.CreationTime)})
+			if verdict == verdictSkip {
+				continue
 			}
 
-			if ignore {
-				LogDebug("cloudformation stack %s has ignore tag, skipping cleanup", *stack.StackName)
+			status := aws.StringValue(stack.StackStatus)
+			if status == cf.StackStatusDeleteInProgress {
+				a.logger.Debug("cloudformation stack %s is already being deleted, adding to delete list to wait for it", *stack.StackName)
+				stacksToDelete = append(stacksToDelete, stack.StackName)
 				continue
 			}
 
-			status := aws.StringValue(stack.StackStatus)
-			if !markedForDeletion {
+			if verdict == verdictMark {
 				switch status {
 				case cf.StackStatusDeleteFailed,
 					cf.StackStatusRollbackComplete,
 					cf.StackStatusRollbackFailed,
 					cf.StackStatusUpdateRollbackFailed,
 					cf.StackStatusUpdateRollbackComplete:
-					LogWarning("cloudformation stack %s is in terminal/rollback state %s; will attempt deletion without tagging", *stack.StackName, status)
+					a.logger.Warn("cloudformation stack %s is in terminal/rollback state %s; will attempt deletion without tagging", *stack.StackName, status)
 				default:
-					// NOTE: only mark for future deletion if we're not running in dry-mode
-					if a.commit {
-						LogDebug("cloudformation stack %s does not have deletion tag, marking for future deletion and skipping cleanup", *stack.StackName)
-						if err := a.markCfStackForFutureDeletion(ctx, stack, client); err != nil {
-							LogError("failed to mark cloudformation stack %s for future deletion: %s", *stack.StackName, err.Error())
-						}
-					}
+					a.markForDeletion(input, "cloudformation stack", *stack.StackName, func() error {
+						return a.markCfStackForFutureDeletion(ctx, stack, client)
+					})
 					continue
 				}
 			}
 
 			switch status {
-			case cf.ResourceStatusDeleteComplete,
-				cf.ResourceStatusDeleteInProgress:
-				LogDebug("cloudformation stack %s is already deleted/deleting, skipping cleanup", *stack.StackName)
+			case cf.StackStatusDeleteComplete:
+				a.logger.Debug("cloudformation stack %s is already deleted, skipping cleanup", *stack.StackName)
 				continue
 			case cf.StackStatusDeleteFailed:
-				LogDebug("cloudformation stack %s is in DELETE_FAILED state, adding to delete list", *stack.StackName)
+				a.logger.Debug("cloudformation stack %s is in DELETE_FAILED state, adding to delete list", *stack.StackName)
 				stacksToDelete = append(stacksToDelete, stack.StackName)
 				continue
 			}
 
-			LogDebug("adding cloudformation stack %s to delete list", *stack.StackName)
+			a.logger.Debug("adding cloudformation stack %s to delete list", *stack.StackName)
 			stacksToDelete = append(stacksToDelete, stack.StackName)
 		}
 
@@ -78,30 +72,37 @@ func (a *action) cleanCfStacks(ctx context.Context, input *CleanupScope) error {
 	}
 
 	if len(stacksToDelete) == 0 {
-		Log("no cloudformation stacks to delete")
+		a.logger.Info("no cloudformation stacks to delete")
 		return nil
 	}
 
 	for _, stackName := range stacksToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of cloudformation stack %s as running in dry-mode", *stackName)
+		if !a.canDelete(input, "cloudformation stack", *stackName) {
 			continue
 		}
 
 		if err := a.deleteCfStack(ctx, *stackName, client); err != nil {
-			LogError("failed to delete cloudformation stack %s: %s", *stackName, err.Error())
+			a.logger.Error("failed to delete cloudformation stack %s: %s", *stackName, err.Error())
+			input.Report.failed(input.Region, "cloudformation stack", *stackName, err.Error())
+			continue
 		}
+		input.Report.deleted(input.Region, "cloudformation stack", *stackName)
 	}
 
 	return nil
 }
 
 func (a *action) markCfStackForFutureDeletion(ctx context.Context, stack *cf.Stack, client *cf.CloudFormation) error {
-	Log("Marking CloudFormation stack %s for future deletion", *stack.StackName)
+	a.logger.Info("Marking CloudFormation stack %s for future deletion", *stack.StackName)
 
-	stack.SetTags(append(stack.Tags, &cf.Tag{Key: aws.String(DeletionTag), Value: aws.String("true")}))
+	// NOTE: the stack update replaces its tags, so the existing ones must be kept.
+	tags := cloudformationTags(stack.Tags)
+	for key, value := range a.markTags() {
+		tags[key] = value
+	}
+	stack.SetTags(cloudformationTagList(tags))
 
-	LogDebug("Updating tags for cloudformation stack %s", *stack.StackName)
+	a.logger.Debug("Updating tags for cloudformation stack %s", *stack.StackName)
 
 	status := aws.StringValue(stack.StackStatus)
 	switch status {
@@ -110,7 +111,7 @@ func (a *action) markCfStackForFutureDeletion(ctx context.Context, stack *cf.Sta
 		cf.StackStatusRollbackFailed,
 		cf.StackStatusUpdateRollbackFailed,
 		cf.StackStatusUpdateRollbackComplete:
-		LogWarning("stack %s is in status %s; skipping tag update and relying on direct deletion", *stack.StackName, status)
+		a.logger.Warn("stack %s is in status %s; skipping tag update and relying on direct deletion", *stack.StackName, status)
 		return nil
 	}
 
@@ -130,8 +131,10 @@ func (a *action) markCfStackForFutureDeletion(ctx context.Context, stack *cf.Sta
 	return nil
 }
 
+// deleteCfStack deletes the stack, or continues its deletion if it previously failed, and waits
+// until it's deleted. Stacks already being deleted are only waited on.
 func (a *action) deleteCfStack(ctx context.Context, stackName string, client *cf.CloudFormation) error {
-	Log("Deleting CloudFormation stack %s", stackName)
+	a.logger.Info("Deleting CloudFormation stack %s", stackName)
 
 	stacks, err := client.DescribeStacksWithContext(ctx, &cf.DescribeStacksInput{StackName: &stackName})
 	if err != nil {
@@ -140,8 +143,10 @@ func (a *action) deleteCfStack(ctx context.Context, stackName string, client *cf
 
 	if len(stacks.Stacks) > 0 {
 		stackStatus := aws.StringValue(stacks.Stacks[0].StackStatus)
-		if stackStatus == cf.StackStatusDeleteFailed {
-			Log("Stack %s is in DELETE_FAILED state, attempting to continue deletion", stackName)
+		if stackStatus == cf.StackStatusDeleteInProgress {
+			a.logger.Info("Stack %s is already being deleted, waiting for it", stackName)
+		} else if stackStatus == cf.StackStatusDeleteFailed {
+			a.logger.Info("Stack %s is in DELETE_FAILED state, attempting to continue deletion", stackName)
 
 			if _, err := client.DeleteStackWithContext(ctx, &cf.DeleteStackInput{
 				StackName:       &stackName,
@@ -156,8 +161,29 @@ func (a *action) deleteCfStack(ctx context.Context, stackName string, client *cf
 		}
 	}
 
-	if err := client.WaitUntilStackDeleteCompleteWithContext(ctx, &cf.DescribeStacksInput{StackName: &stackName}); err != nil {
-		return fmt.Errorf("failed to wait for cloudformation stack %s to delete: %w", stackName, err)
+	if err := waitUntil(ctx, 30*time.Minute, 15*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeStacksWithContext(ctx, &cf.DescribeStacksInput{StackName: &stackName})
+		if err != nil {
+			// NOTE: once deleted, stacks can't be described by name anymore.
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "ValidationError" && strings.Contains(aerr.Message(), "does not exist") {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for cloudformation stack %s deletion: %s", stackName, err.Error())
+			return false, nil
+		}
+		if len(out.Stacks) == 0 {
+			return true, nil
+		}
+
+		switch aws.StringValue(out.Stacks[0].StackStatus) {
+		case cf.StackStatusDeleteComplete:
+			return true, nil
+		case cf.StackStatusDeleteFailed:
+			return false, fmt.Errorf("stack deletion failed: %s", aws.StringValue(out.Stacks[0].StackStatusReason))
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for cloudformation stack %s deletion: %w", stackName, err)
 	}
 
 	return nil
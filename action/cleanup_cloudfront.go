@@ -0,0 +1,176 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+)
+
+// cloudFrontDeployed is the status of the distributions whose changes were deployed to all the edge locations.
+const cloudFrontDeployed = "Deployed"
+
+func (a *action) cleanCloudFrontDistributions(ctx context.Context, input *CleanupScope) error {
+	client := cloudfront.New(input.Session)
+
+	distributionsToDelete := []*cloudfront.DistributionSummary{}
+	pageFunc := func(page *cloudfront.ListDistributionsOutput, _ bool) bool {
+		if page.DistributionList == nil {
+			return true
+		}
+
+		for _, distribution := range page.DistributionList.Items {
+			id := aws.StringValue(distribution.Id)
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &cloudfront.ListTagsForResourceInput{Resource: distribution.ARN})
+			if err != nil {
+				a.logger.Error("failed getting tags for cloudfront distribution %s: %s", id, err.Error())
+				continue
+			}
+
+			tags := Tags{}
+			if tagOut.Tags != nil {
+				tags = cloudfrontTags(tagOut.Tags.Items)
+			}
+
+			switch input.evaluate(resource{Type: "cloudfront distribution", ID: id, ARN: aws.StringValue(distribution.ARN), Tags: tags, Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "cloudfront distribution", id, func(tags Tags) error {
+					return a.tagDistribution(ctx, aws.StringValue(distribution.ARN), tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "cloudfront distribution", id, func() error {
+					return a.markDistributionForFutureDeletion(ctx, aws.StringValue(distribution.ARN), client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding cloudfront distribution %s to delete list", id)
+			distributionsToDelete = append(distributionsToDelete, distribution)
+		}
+
+		return true
+	}
+
+	if err := client.ListDistributionsPagesWithContext(ctx, &cloudfront.ListDistributionsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of cloudfront distributions: %w", err)
+	}
+
+	if len(distributionsToDelete) == 0 {
+		a.logger.Info("no cloudfront distributions to delete")
+		return nil
+	}
+
+	for _, distribution := range distributionsToDelete {
+		id := aws.StringValue(distribution.Id)
+		if !a.canDelete(input, "cloudfront distribution", id) {
+			continue
+		}
+
+		deleted, err := a.deleteDistribution(ctx, id, input, client)
+		if errors.Is(err, errAlreadyDeleted) {
+			input.Report.skipped(input.Region, "cloudfront distribution", id, "already deleted")
+			continue
+		}
+		if err != nil {
+			a.logger.Error("failed to delete cloudfront distribution %s: %s", id, err.Error())
+			input.Report.failed(input.Region, "cloudfront distribution", id, err.Error())
+			continue
+		}
+		if !deleted {
+			input.Report.scheduled(input.Region, "cloudfront distribution", id, "disabled, deleted by a next run once deployed")
+			continue
+		}
+		input.Report.deleted(input.Region, "cloudfront distribution", id)
+	}
+
+	return nil
+}
+
+func (a *action) markDistributionForFutureDeletion(ctx context.Context, arn string, client *cloudfront.CloudFront) error {
+	a.logger.Info("Marking CloudFront distribution %s for future deletion", arn)
+
+	return a.tagDistribution(ctx, arn, a.markTags(), client)
+}
+
+func (a *action) tagDistribution(ctx context.Context, arn string, tags Tags, client *cloudfront.CloudFront) error {
+	_, err := client.TagResourceWithContext(ctx, &cloudfront.TagResourceInput{
+		Resource: &arn,
+		Tags:     &cloudfront.Tags{Items: cloudfrontTagList(tags)},
+	})
+
+	return err
+}
+
+// deleteDistribution disables the distribution, waits for it to be deployed and deletes it. Deploying
+// can take a long time, so when it isn't done within the cloudfront timeout the distribution is left
+// disabled, and false is returned, for a next run to delete it.
+func (a *action) deleteDistribution(ctx context.Context, id string, input *CleanupScope, client *cloudfront.CloudFront) (bool, error) {
+	out, err := client.GetDistributionConfigWithContext(ctx, &cloudfront.GetDistributionConfigInput{Id: &id})
+	if err != nil {
+		if isAlreadyDeleted(a.logger, err, "cloudfront distribution", id) {
+			return false, errAlreadyDeleted
+		}
+		return false, fmt.Errorf("failed to get distribution config: %w", err)
+	}
+	etag := out.ETag
+
+	if aws.BoolValue(out.DistributionConfig.Enabled) {
+		a.logger.Info("Disabling CloudFront distribution %s", id)
+		out.DistributionConfig.Enabled = aws.Bool(false)
+		updateOut, err := client.UpdateDistributionWithContext(ctx, &cloudfront.UpdateDistributionInput{
+			Id:                 &id,
+			IfMatch:            etag,
+			DistributionConfig: out.DistributionConfig,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to disable distribution: %w", err)
+		}
+		etag = updateOut.ETag
+	}
+
+	// a distribution can only be deleted once it's disabled everywhere, the etag of
+	// the deployed distribution is the one the deletion must match.
+	isDeployed := func(ctx context.Context) (bool, error) {
+		out, err := client.GetDistributionWithContext(ctx, &cloudfront.GetDistributionInput{Id: &id})
+		if err != nil {
+			return false, err
+		}
+		etag = out.ETag
+		return aws.StringValue(out.Distribution.Status) == cloudFrontDeployed, nil
+	}
+
+	deployed, err := isDeployed(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get distribution: %w", err)
+	}
+	if !deployed && input.CloudFrontTimeout > 0 {
+		a.logger.Debug("Waiting up to %s for cloudfront distribution %s to be deployed", input.CloudFrontTimeout, id)
+		if err := waitUntil(ctx, input.CloudFrontTimeout, 30*time.Second, func(ctx context.Context) (bool, error) {
+			deployed, err = isDeployed(ctx)
+			return deployed, err
+		}); err != nil && ctx.Err() != nil {
+			return false, err
+		}
+	}
+	if !deployed {
+		a.logger.Info("CloudFront distribution %s is disabled but not deployed yet, leaving it for a next run", id)
+		return false, nil
+	}
+
+	a.logger.Info("Deleting CloudFront distribution %s", id)
+	if _, err := client.DeleteDistributionWithContext(ctx, &cloudfront.DeleteDistributionInput{Id: &id, IfMatch: etag}); err != nil {
+		if isAlreadyDeleted(a.logger, err, "cloudfront distribution", id) {
+			return false, errAlreadyDeleted
+		}
+		return false, fmt.Errorf("failed to delete distribution: %w", err)
+	}
+
+	return true, nil
+}
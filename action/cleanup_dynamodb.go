@@ -0,0 +1,145 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func (a *action) cleanDynamoDBTables(ctx context.Context, input *CleanupScope) error {
+	client := dynamodb.New(input.Session)
+
+	tablesToDelete := []*dynamodb.TableDescription{}
+	pageFunc := func(page *dynamodb.ListTablesOutput, _ bool) bool {
+		for _, name := range page.TableNames {
+			out, err := client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: name})
+			if err != nil {
+				a.logger.Warn("failed getting dynamodb table %s: %s", *name, err.Error())
+				continue
+			}
+			table := out.Table
+
+			switch aws.StringValue(table.TableStatus) {
+			case dynamodb.TableStatusCreating, dynamodb.TableStatusUpdating:
+				a.logger.Warn("dynamodb table %s is %s, skipping cleanup", *name, aws.StringValue(table.TableStatus))
+				input.Report.skipped(input.Region, "dynamodb table", *name, "table is "+aws.StringValue(table.TableStatus))
+				continue
+			case dynamodb.TableStatusDeleting:
+				a.logger.Debug("dynamodb table %s is already being deleted, skipping cleanup", *name)
+				continue
+			}
+
+			tags, err := a.getTableTags(ctx, *table.TableArn, client)
+			if err != nil {
+				a.logger.Error("failed getting tags for dynamodb table %s: %s", *name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "dynamodb table", ID: *name, ARN: aws.StringValue(table.TableArn), Tags: tags, CreatedAt: aws.TimeValue(table.CreationDateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "dynamodb table", *name, func() error {
+					return a.markTableForFutureDeletion(ctx, *table.TableArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding dynamodb table %s to delete list", *name)
+			tablesToDelete = append(tablesToDelete, table)
+		}
+
+		return true
+	}
+
+	if err := client.ListTablesPagesWithContext(ctx, &dynamodb.ListTablesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of dynamodb tables: %w", err)
+	}
+
+	if len(tablesToDelete) == 0 {
+		a.logger.Info("no dynamodb tables to delete")
+		return nil
+	}
+
+	for _, table := range tablesToDelete {
+		if !a.canDelete(input, "dynamodb table", *table.TableName) {
+			continue
+		}
+
+		if err := a.deleteTable(ctx, table, client); err != nil {
+			a.logger.Error("failed to delete dynamodb table %s: %s", *table.TableName, err.Error())
+			input.Report.failed(input.Region, "dynamodb table", *table.TableName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "dynamodb table", *table.TableName)
+	}
+
+	return nil
+}
+
+func (a *action) getTableTags(ctx context.Context, arn string, client *dynamodb.DynamoDB) (Tags, error) {
+	tags := Tags{}
+	params := &dynamodb.ListTagsOfResourceInput{ResourceArn: &arn}
+	for {
+		out, err := client.ListTagsOfResourceWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range out.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+
+		if out.NextToken == nil {
+			return tags, nil
+		}
+		params.NextToken = out.NextToken
+	}
+}
+
+func (a *action) markTableForFutureDeletion(ctx context.Context, arn string, client *dynamodb.DynamoDB) error {
+	a.logger.Info("Marking DynamoDB table %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &dynamodb.TagResourceInput{
+		ResourceArn: &arn,
+		Tags:        dynamodbTagList(a.markTags()),
+	})
+
+	return err
+}
+
+func (a *action) deleteTable(ctx context.Context, table *dynamodb.TableDescription, client *dynamodb.DynamoDB) error {
+	name := *table.TableName
+	a.logger.Info("Deleting DynamoDB table %s (%d items)", name, aws.Int64Value(table.ItemCount))
+
+	if aws.BoolValue(table.DeletionProtectionEnabled) {
+		a.logger.Debug("Disabling deletion protection for dynamodb table %s", name)
+		if _, err := client.UpdateTableWithContext(ctx, &dynamodb.UpdateTableInput{
+			TableName:                 &name,
+			DeletionProtectionEnabled: aws.Bool(false),
+		}); err != nil {
+			return fmt.Errorf("failed to disable deletion protection for dynamodb table %s: %w", name, err)
+		}
+	}
+
+	if _, err := client.DeleteTableWithContext(ctx, &dynamodb.DeleteTableInput{TableName: &name}); err != nil {
+		return fmt.Errorf("failed to delete dynamodb table %s: %w", name, err)
+	}
+
+	if err := waitUntil(ctx, 10*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+		if _, err := client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: &name}); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for dynamodb table %s deletion: %s", name, err.Error())
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for dynamodb table %s deletion: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecr"
+)
+
+func (a *action) cleanECRRepositories(ctx context.Context, input *CleanupScope) error {
+	client := ecr.New(input.Session)
+
+	reposToDelete := []*ecr.Repository{}
+	pageFunc := func(page *ecr.DescribeRepositoriesOutput, _ bool) bool {
+		for _, repo := range page.Repositories {
+			if !strings.HasPrefix(*repo.RepositoryName, input.ECRRepositoryPrefix) {
+				a.logger.Debug("ecr repository %s doesn't match prefix %s, skipping cleanup", *repo.RepositoryName, input.ECRRepositoryPrefix)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &ecr.ListTagsForResourceInput{ResourceArn: repo.RepositoryArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for ecr repository %s: %s", *repo.RepositoryName, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "ecr repository", ID: *repo.RepositoryName, ARN: aws.StringValue(repo.RepositoryArn), Tags: ecrTags(tagOut.Tags), CreatedAt: aws.TimeValue(repo.CreatedAt)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "ecr repository", *repo.RepositoryName, func() error {
+					return a.markECRRepositoryForFutureDeletion(ctx, *repo.RepositoryArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding ecr repository %s to delete list", *repo.RepositoryName)
+			reposToDelete = append(reposToDelete, repo)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeRepositoriesPagesWithContext(ctx, &ecr.DescribeRepositoriesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of ecr repositories: %w", err)
+	}
+
+	if len(reposToDelete) == 0 {
+		a.logger.Info("no ecr repositories to delete")
+		return nil
+	}
+
+	for _, repo := range reposToDelete {
+		images, err := a.countRepositoryImages(ctx, *repo.RepositoryName, client)
+		if err != nil {
+			a.logger.Warn("failed to count images of ecr repository %s: %s", *repo.RepositoryName, err.Error())
+		}
+
+		if !a.canDelete(input, "ecr repository", *repo.RepositoryName) {
+			continue
+		}
+
+		a.logger.Info("Deleting ECR repository %s and purging its %d images", *repo.RepositoryName, images)
+		// force deletes the images in the repository along with it.
+		if _, err := client.DeleteRepositoryWithContext(ctx, &ecr.DeleteRepositoryInput{
+			RepositoryName: repo.RepositoryName,
+			RegistryId:     repo.RegistryId,
+			Force:          aws.Bool(true),
+		}); err != nil {
+			a.logger.Error("failed to delete ecr repository %s: %s", *repo.RepositoryName, err.Error())
+			input.Report.failed(input.Region, "ecr repository", *repo.RepositoryName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "ecr repository", *repo.RepositoryName)
+	}
+
+	return nil
+}
+
+// countRepositoryImages returns how many images, tagged or not, are stored in the repository.
+func (a *action) countRepositoryImages(ctx context.Context, repoName string, client *ecr.ECR) (int, error) {
+	count := 0
+	pageFunc := func(page *ecr.ListImagesOutput, _ bool) bool {
+		count += len(page.ImageIds)
+		return true
+	}
+
+	if err := client.ListImagesPagesWithContext(ctx, &ecr.ListImagesInput{RepositoryName: &repoName}, pageFunc); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (a *action) markECRRepositoryForFutureDeletion(ctx context.Context, arn string, client *ecr.ECR) error {
+	a.logger.Info("Marking ECR repository %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &ecr.TagResourceInput{
+		ResourceArn: &arn,
+		Tags:        ecrTagList(a.markTags()),
+	})
+
+	return err
+}
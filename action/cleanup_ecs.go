@@ -0,0 +1,144 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// taskDefinitionRevision is a revision of a task definition family, e.g. "web:12".
+type taskDefinitionRevision struct {
+	Family string
+	Name   string
+	ARN    string
+}
+
+// cleanTaskDefinitions deregisters the active revisions of the task definition families, keeping
+// the latest input.TaskDefinitionKeep ones of each family.
+func (a *action) cleanTaskDefinitions(ctx context.Context, input *CleanupScope) error {
+	client := ecs.New(input.Session)
+
+	families := []string{}
+	familiesPageFunc := func(page *ecs.ListTaskDefinitionFamiliesOutput, _ bool) bool {
+		families = append(families, aws.StringValueSlice(page.Families)...)
+		return true
+	}
+
+	familiesInput := &ecs.ListTaskDefinitionFamiliesInput{Status: aws.String(ecs.TaskDefinitionFamilyStatusActive)}
+	if input.TaskDefinitionPrefix != "" {
+		familiesInput.FamilyPrefix = aws.String(input.TaskDefinitionPrefix)
+	}
+	if err := client.ListTaskDefinitionFamiliesPagesWithContext(ctx, familiesInput, familiesPageFunc); err != nil {
+		return fmt.Errorf("failed getting list of task definition families: %w", err)
+	}
+
+	revisionsToDelete := []taskDefinitionRevision{}
+	for _, family := range families {
+		revisions, err := a.getTaskDefinitionRevisions(ctx, family, client)
+		if err != nil {
+			a.logger.Error("failed getting revisions of task definition family %s: %s", family, err.Error())
+			continue
+		}
+
+		for i, revision := range revisions {
+			if i < input.TaskDefinitionKeep {
+				a.logger.Debug("task definition %s is one of the latest %d revisions of its family, skipping cleanup", revision.Name, input.TaskDefinitionKeep)
+				input.Report.skipped(input.Region, "task definition", revision.Name, fmt.Sprintf("one of the latest %d revisions", input.TaskDefinitionKeep))
+				continue
+			}
+
+			out, err := client.DescribeTaskDefinitionWithContext(ctx, &ecs.DescribeTaskDefinitionInput{
+				TaskDefinition: aws.String(revision.ARN),
+				Include:        aws.StringSlice([]string{ecs.TaskDefinitionFieldTags}),
+			})
+			if err != nil {
+				a.logger.Error("failed describing task definition %s: %s", revision.Name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "task definition", ID: revision.Name, ARN: revision.ARN, Tags: ecsTags(out.Tags), CreatedAt: aws.TimeValue(out.TaskDefinition.RegisteredAt)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "task definition", revision.Name, func() error {
+					return a.markTaskDefinitionForFutureDeletion(ctx, revision.ARN, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding task definition %s to deregister list", revision.Name)
+			revisionsToDelete = append(revisionsToDelete, revision)
+		}
+	}
+
+	if len(revisionsToDelete) == 0 {
+		a.logger.Info("no task definitions to deregister")
+		return nil
+	}
+
+	deregistered := map[string]int{}
+	for _, revision := range revisionsToDelete {
+		if !a.canDelete(input, "task definition", revision.Name) {
+			continue
+		}
+
+		a.logger.Info("Deregistering Task Definition %s", revision.Name)
+		if _, err := client.DeregisterTaskDefinitionWithContext(ctx, &ecs.DeregisterTaskDefinitionInput{TaskDefinition: aws.String(revision.ARN)}); err != nil {
+			if isAlreadyDeleted(a.logger, err, "task definition", revision.Name) {
+				input.Report.skipped(input.Region, "task definition", revision.Name, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to deregister task definition %s: %s", revision.Name, err.Error())
+			input.Report.failed(input.Region, "task definition", revision.Name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "task definition", revision.Name)
+		deregistered[revision.Family]++
+	}
+
+	for _, family := range families {
+		if count := deregistered[family]; count > 0 {
+			a.logger.Info("Deregistered %d revisions of task definition family %s", count, family)
+		}
+	}
+
+	return nil
+}
+
+// getTaskDefinitionRevisions returns the active revisions of the task definition family, the latest first.
+func (a *action) getTaskDefinitionRevisions(ctx context.Context, family string, client *ecs.ECS) ([]taskDefinitionRevision, error) {
+	revisions := []taskDefinitionRevision{}
+	pageFunc := func(page *ecs.ListTaskDefinitionsOutput, _ bool) bool {
+		for _, arn := range page.TaskDefinitionArns {
+			revisionArn := aws.StringValue(arn)
+			// the arns end with task-definition/<family>:<revision>.
+			name := revisionArn[strings.LastIndex(revisionArn, "/")+1:]
+			revisions = append(revisions, taskDefinitionRevision{Family: family, Name: name, ARN: revisionArn})
+		}
+		return true
+	}
+
+	if err := client.ListTaskDefinitionsPagesWithContext(ctx, &ecs.ListTaskDefinitionsInput{
+		FamilyPrefix: aws.String(family),
+		Status:       aws.String(ecs.TaskDefinitionStatusActive),
+		Sort:         aws.String(ecs.SortOrderDesc),
+	}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (a *action) markTaskDefinitionForFutureDeletion(ctx context.Context, arn string, client *ecs.ECS) error {
+	a.logger.Info("Marking Task Definition %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &ecs.TagResourceInput{
+		ResourceArn: &arn,
+		Tags:        ecsTagList(a.markTags()),
+	})
+
+	return err
+}
@@ -0,0 +1,130 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/efs"
+)
+
+func (a *action) cleanEFSFileSystems(ctx context.Context, input *CleanupScope) error {
+	client := efs.New(input.Session)
+
+	fileSystemsToDelete := []*efs.FileSystemDescription{}
+	pageFunc := func(page *efs.DescribeFileSystemsOutput, _ bool) bool {
+		for _, fs := range page.FileSystems {
+			if aws.StringValue(fs.LifeCycleState) != efs.LifeCycleStateAvailable {
+				a.logger.Debug("efs file system %s is %s, skipping cleanup", *fs.FileSystemId, aws.StringValue(fs.LifeCycleState))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "efs file system", ID: *fs.FileSystemId, ARN: aws.StringValue(fs.FileSystemArn), Tags: efsTags(fs.Tags), CreatedAt: aws.TimeValue(fs.CreationTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "efs file system", *fs.FileSystemId, func() error {
+					return a.markFileSystemForFutureDeletion(ctx, *fs.FileSystemId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding efs file system %s to delete list", *fs.FileSystemId)
+			fileSystemsToDelete = append(fileSystemsToDelete, fs)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeFileSystemsPagesWithContext(ctx, &efs.DescribeFileSystemsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of efs file systems: %w", err)
+	}
+
+	if len(fileSystemsToDelete) == 0 {
+		a.logger.Info("no efs file systems to delete")
+		return nil
+	}
+
+	for _, fs := range fileSystemsToDelete {
+		if !a.canDelete(input, "efs file system", *fs.FileSystemId) {
+			continue
+		}
+
+		if err := a.deleteFileSystem(ctx, *fs.FileSystemId, client); err != nil {
+			a.logger.Error("failed to delete efs file system %s: %s", *fs.FileSystemId, err.Error())
+			input.Report.failed(input.Region, "efs file system", *fs.FileSystemId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "efs file system", *fs.FileSystemId)
+	}
+
+	return nil
+}
+
+func (a *action) markFileSystemForFutureDeletion(ctx context.Context, fileSystemId string, client *efs.EFS) error {
+	a.logger.Info("Marking EFS file system %s for future deletion", fileSystemId)
+
+	_, err := client.TagResourceWithContext(ctx, &efs.TagResourceInput{
+		ResourceId: &fileSystemId,
+		Tags:       efsTagList(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteFileSystem deletes the access points and the mount targets of the file system,
+// waiting for the network interfaces of the mount targets to be released, then deletes it.
+func (a *action) deleteFileSystem(ctx context.Context, fileSystemId string, client *efs.EFS) error {
+	a.logger.Info("Deleting EFS file system %s", fileSystemId)
+
+	accessPoints := []*string{}
+	if err := client.DescribeAccessPointsPagesWithContext(ctx, &efs.DescribeAccessPointsInput{FileSystemId: &fileSystemId}, func(page *efs.DescribeAccessPointsOutput, _ bool) bool {
+		for _, accessPoint := range page.AccessPoints {
+			accessPoints = append(accessPoints, accessPoint.AccessPointId)
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list access points: %w", err)
+	}
+
+	for _, accessPointId := range accessPoints {
+		a.logger.Debug("Deleting access point %s of efs file system %s", *accessPointId, fileSystemId)
+		if _, err := client.DeleteAccessPointWithContext(ctx, &efs.DeleteAccessPointInput{AccessPointId: accessPointId}); err != nil {
+			return fmt.Errorf("failed to delete access point %s: %w", *accessPointId, err)
+		}
+	}
+
+	out, err := client.DescribeMountTargetsWithContext(ctx, &efs.DescribeMountTargetsInput{FileSystemId: &fileSystemId})
+	if err != nil {
+		return fmt.Errorf("failed to list mount targets: %w", err)
+	}
+
+	for _, mountTarget := range out.MountTargets {
+		a.logger.Debug("Deleting mount target %s (network interface %s) of efs file system %s", *mountTarget.MountTargetId, aws.StringValue(mountTarget.NetworkInterfaceId), fileSystemId)
+		if _, err := client.DeleteMountTargetWithContext(ctx, &efs.DeleteMountTargetInput{MountTargetId: mountTarget.MountTargetId}); err != nil {
+			return fmt.Errorf("failed to delete mount target %s: %w", *mountTarget.MountTargetId, err)
+		}
+	}
+
+	// the file system can't be deleted until its mount targets, and so their network
+	// interfaces, are gone.
+	if len(out.MountTargets) > 0 {
+		if err := waitUntil(ctx, 10*time.Minute, 15*time.Second, func(ctx context.Context) (bool, error) {
+			out, err := client.DescribeMountTargetsWithContext(ctx, &efs.DescribeMountTargetsInput{FileSystemId: &fileSystemId})
+			if err != nil {
+				a.logger.Warn("error while waiting for the mount targets of efs file system %s deletion: %s", fileSystemId, err.Error())
+				return false, nil
+			}
+			return len(out.MountTargets) == 0, nil
+		}); err != nil {
+			return fmt.Errorf("failed waiting for mount targets deletion: %w", err)
+		}
+	}
+
+	if _, err := client.DeleteFileSystemWithContext(ctx, &efs.DeleteFileSystemInput{FileSystemId: &fileSystemId}); err != nil {
+		return fmt.Errorf("failed to delete efs file system %s: %w", fileSystemId, err)
+	}
+
+	return nil
+}
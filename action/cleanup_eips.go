@@ -0,0 +1,94 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanElasticIPs(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	out, err := client.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return fmt.Errorf("failed to describe addresses: %w", err)
+	}
+
+	addressesToDelete := []*ec2.Address{}
+	for _, address := range out.Addresses {
+		if address.AssociationId != nil || address.InstanceId != nil {
+			continue
+		}
+
+		switch input.evaluate(resource{Type: "elastic ip", ID: aws.StringValue(address.PublicIp), Tags: ec2Tags(address.Tags), Stampable: true}) {
+		case verdictSkip:
+			continue
+		case verdictStamp:
+			a.stampFirstSeen(input, "elastic ip", aws.StringValue(address.PublicIp), func(tags Tags) error {
+				// only addresses allocated for use in a vpc have an allocation id that can be tagged.
+				if address.AllocationId == nil {
+					return fmt.Errorf("elastic ip %s has no allocation id and can't be tagged", aws.StringValue(address.PublicIp))
+				}
+				return a.tagEC2Resource(ctx, *address.AllocationId, tags, client)
+			})
+			continue
+		case verdictMark:
+			a.markForDeletion(input, "elastic ip", aws.StringValue(address.PublicIp), func() error {
+				return a.markElasticIPForFutureDeletion(ctx, address, client)
+			})
+			continue
+		}
+
+		a.logger.Debug("adding elastic ip %s to delete list", aws.StringValue(address.PublicIp))
+		addressesToDelete = append(addressesToDelete, address)
+	}
+
+	if len(addressesToDelete) == 0 {
+		a.logger.Info("no unassociated elastic ips to release")
+		return nil
+	}
+
+	for _, address := range addressesToDelete {
+		if !a.canDelete(input, "elastic ip", aws.StringValue(address.PublicIp)) {
+			continue
+		}
+
+		if err := a.releaseElasticIP(ctx, address, client); err != nil {
+			a.logger.Error("failed to release elastic ip %s: %s", aws.StringValue(address.PublicIp), err.Error())
+			input.Report.failed(input.Region, "elastic ip", aws.StringValue(address.PublicIp), err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "elastic ip", aws.StringValue(address.PublicIp))
+	}
+
+	return nil
+}
+
+func (a *action) markElasticIPForFutureDeletion(ctx context.Context, address *ec2.Address, client *ec2.EC2) error {
+	if address.AllocationId == nil {
+		return fmt.Errorf("elastic ip %s has no allocation id and can't be tagged", aws.StringValue(address.PublicIp))
+	}
+
+	a.logger.Info("Marking Elastic IP %s for future deletion", aws.StringValue(address.PublicIp))
+
+	return a.tagEC2Resource(ctx, *address.AllocationId, a.markTags(), client)
+}
+
+func (a *action) releaseElasticIP(ctx context.Context, address *ec2.Address, client *ec2.EC2) error {
+	a.logger.Info("Releasing Elastic IP %s", aws.StringValue(address.PublicIp))
+
+	releaseInput := &ec2.ReleaseAddressInput{}
+	if aws.StringValue(address.Domain) == ec2.DomainTypeVpc {
+		releaseInput.AllocationId = address.AllocationId
+	} else {
+		releaseInput.PublicIp = address.PublicIp
+	}
+
+	if _, err := client.ReleaseAddressWithContext(ctx, releaseInput); err != nil {
+		return fmt.Errorf("failed to release elastic ip %s: %w", aws.StringValue(address.PublicIp), err)
+	}
+
+	return nil
+}
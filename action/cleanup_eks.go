@@ -3,8 +3,10 @@ package action
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/eks"
 )
 
@@ -18,27 +20,21 @@ func (a *action) cleanEKSClusters(ctx context.Context, input *CleanupScope) erro
 				Name: name,
 			})
 			if err != nil {
-				LogWarning("failed getting cluster %s: %s", *name, err.Error())
+				a.logger.Warn("failed getting cluster %s: %s", *name, err.Error())
 				continue
 			}
 
-			if _, ok := cluster.Cluster.Tags[input.IgnoreTag]; ok {
-				LogDebug("eks cluster %s has ignore tag, skipping cleanup", *name)
+			switch input.evaluate(resource{Type: "eks cluster", ID: *name, ARN: aws.StringValue(cluster.Cluster.Arn), Tags: Tags(aws.StringValueMap(cluster.Cluster.Tags)), CreatedAt: aws.TimeValue(cluster.Cluster.CreatedAt)}) {
+			case verdictSkip:
 				continue
-			}
-
-			if _, ok := cluster.Cluster.Tags[DeletionTag]; !ok {
-				// NOTE: only mark for future deletion if we're not running in dry-mode
-				if a.commit {
-					LogDebug("eks cluster %s does not have deletion tag, marking for future deletion and skipping cleanup", *name)
-					if err := a.markEKSClusterForFutureDeletion(ctx, *cluster.Cluster.Arn, client); err != nil {
-						LogError("failed to mark cluster %s for future deletion: %s", *cluster.Cluster.Arn, err.Error())
-					}
-				}
+			case verdictMark:
+				a.markForDeletion(input, "eks cluster", *name, func() error {
+					return a.markEKSClusterForFutureDeletion(ctx, *cluster.Cluster.Arn, client)
+				})
 				continue
 			}
 
-			LogDebug("adding eks cluster %s to delete list", *name)
+			a.logger.Debug("adding eks cluster %s to delete list", *name)
 			clustersToDelete = append(clustersToDelete, cluster.Cluster)
 		}
 
@@ -50,62 +46,124 @@ func (a *action) cleanEKSClusters(ctx context.Context, input *CleanupScope) erro
 	}
 
 	if len(clustersToDelete) == 0 {
-		Log("no eks clusters to delete")
+		a.logger.Info("no eks clusters to delete")
 		return nil
 	}
 
 	for _, clusterObj := range clustersToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of eks cluster %s as running in dry-mode", *clusterObj.Name)
+		if !a.canDelete(input, "eks cluster", *clusterObj.Name) {
 			continue
 		}
 
 		if err := a.deleteEKSCluster(ctx, *clusterObj.Name, client); err != nil {
-			LogError("failed to delete cluster %s: %s", *clusterObj.Name, err.Error())
+			a.logger.Error("failed to delete cluster %s: %s", *clusterObj.Name, err.Error())
+			input.Report.failed(input.Region, "eks cluster", *clusterObj.Name, err.Error())
+			continue
 		}
+		input.Report.deleted(input.Region, "eks cluster", *clusterObj.Name)
 	}
 
 	return nil
 }
 
 func (a *action) markEKSClusterForFutureDeletion(ctx context.Context, clusterArn string, client *eks.EKS) error {
-	Log("Marking EKS cluster %s for future deletion", clusterArn)
+	a.logger.Info("Marking EKS cluster %s for future deletion", clusterArn)
 
-	_, err := client.TagResourceWithContext(ctx, &eks.TagResourceInput{ResourceArn: &clusterArn, Tags: map[string]*string{DeletionTag: aws.String("true")}})
+	_, err := client.TagResourceWithContext(ctx, &eks.TagResourceInput{ResourceArn: &clusterArn, Tags: aws.StringMap(a.markTags())})
 
 	return err
 }
 
+// deleteEKSCluster deletes the nodegroups and fargate profiles of the cluster, which
+// would otherwise prevent its deletion, then deletes it.
 func (a *action) deleteEKSCluster(ctx context.Context, clusterName string, client *eks.EKS) error {
-	Log("Deleting EKS cluster %s", clusterName)
+	a.logger.Info("Deleting EKS cluster %s", clusterName)
 
-	LogDebug("Deleting nodegroups for cluster %s", clusterName)
+	a.logger.Debug("Deleting nodegroups for cluster %s", clusterName)
 
-	listErr := client.ListNodegroupsPagesWithContext(ctx, &eks.ListNodegroupsInput{ClusterName: &clusterName}, func(page *eks.ListNodegroupsOutput, _ bool) bool {
-		for _, ngName := range page.Nodegroups {
-			Log("Deleting nodegroup %s in cluster %s", *ngName, clusterName)
-			if _, err := client.DeleteNodegroupWithContext(ctx, &eks.DeleteNodegroupInput{ClusterName: &clusterName, NodegroupName: ngName}); err != nil {
-				LogError("failed to deleted nodegroups %s for cluster %s: %s", *ngName, clusterName, err.Error())
-			}
+	nodegroups := []*string{}
+	if err := client.ListNodegroupsPagesWithContext(ctx, &eks.ListNodegroupsInput{ClusterName: &clusterName}, func(page *eks.ListNodegroupsOutput, _ bool) bool {
+		nodegroups = append(nodegroups, page.Nodegroups...)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list nodegroups for cluster %s: %w", clusterName, err)
+	}
 
-			if err := client.WaitUntilNodegroupDeletedWithContext(ctx, &eks.DescribeNodegroupInput{ClusterName: &clusterName, NodegroupName: ngName}); err != nil {
-				LogError("failed to wait for nodegroups %s in cluster %s to be deleted: %s", *ngName, clusterName, err.Error())
+	// nodegroups are deleted in parallel and waited for afterwards.
+	for _, ngName := range nodegroups {
+		a.logger.Info("Deleting nodegroup %s in cluster %s", *ngName, clusterName)
+		if _, err := client.DeleteNodegroupWithContext(ctx, &eks.DeleteNodegroupInput{ClusterName: &clusterName, NodegroupName: ngName}); err != nil && !isEKSNotFound(err) {
+			return fmt.Errorf("failed to delete nodegroup %s for cluster %s: %w", *ngName, clusterName, err)
+		}
+	}
+
+	for _, ngName := range nodegroups {
+		if err := waitUntil(ctx, 20*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+			_, err := client.DescribeNodegroupWithContext(ctx, &eks.DescribeNodegroupInput{ClusterName: &clusterName, NodegroupName: ngName})
+			if err != nil {
+				if isEKSNotFound(err) {
+					return true, nil
+				}
+				a.logger.Warn("error while waiting for nodegroup %s in cluster %s deletion: %s", *ngName, clusterName, err.Error())
 			}
+			return false, nil
+		}); err != nil {
+			return fmt.Errorf("failed waiting for nodegroup %s in cluster %s deletion: %w", *ngName, clusterName, err)
 		}
+	}
 
+	a.logger.Debug("Deleting fargate profiles for cluster %s", clusterName)
+
+	profiles := []*string{}
+	if err := client.ListFargateProfilesPagesWithContext(ctx, &eks.ListFargateProfilesInput{ClusterName: &clusterName}, func(page *eks.ListFargateProfilesOutput, _ bool) bool {
+		profiles = append(profiles, page.FargateProfileNames...)
 		return true
-	})
-	if listErr != nil {
-		return fmt.Errorf("failed to list nodegroups for cluster %s: %w", clusterName, listErr)
+	}); err != nil {
+		return fmt.Errorf("failed to list fargate profiles for cluster %s: %w", clusterName, err)
+	}
+
+	// a cluster can only have one fargate profile being deleted at a time.
+	for _, profileName := range profiles {
+		a.logger.Info("Deleting fargate profile %s in cluster %s", *profileName, clusterName)
+		if _, err := client.DeleteFargateProfileWithContext(ctx, &eks.DeleteFargateProfileInput{ClusterName: &clusterName, FargateProfileName: profileName}); err != nil && !isEKSNotFound(err) {
+			return fmt.Errorf("failed to delete fargate profile %s for cluster %s: %w", *profileName, clusterName, err)
+		}
+
+		if err := waitUntil(ctx, 10*time.Minute, 15*time.Second, func(ctx context.Context) (bool, error) {
+			_, err := client.DescribeFargateProfileWithContext(ctx, &eks.DescribeFargateProfileInput{ClusterName: &clusterName, FargateProfileName: profileName})
+			if err != nil {
+				if isEKSNotFound(err) {
+					return true, nil
+				}
+				a.logger.Warn("error while waiting for fargate profile %s in cluster %s deletion: %s", *profileName, clusterName, err.Error())
+			}
+			return false, nil
+		}); err != nil {
+			return fmt.Errorf("failed waiting for fargate profile %s in cluster %s deletion: %w", *profileName, clusterName, err)
+		}
 	}
 
 	if _, err := client.DeleteClusterWithContext(ctx, &eks.DeleteClusterInput{Name: &clusterName}); err != nil {
 		return fmt.Errorf("failed to delete cluster %s: %w", clusterName, err)
 	}
 
-	if err := client.WaitUntilClusterDeletedWithContext(ctx, &eks.DescribeClusterInput{Name: &clusterName}); err != nil {
-		return fmt.Errorf("failed to wait for cluster %s to be delete: %w", clusterName, err)
+	if err := waitUntil(ctx, 20*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		_, err := client.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: &clusterName})
+		if err != nil {
+			if isEKSNotFound(err) {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for cluster %s deletion: %s", clusterName, err.Error())
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for cluster %s deletion: %w", clusterName, err)
 	}
 
 	return nil
 }
+
+func isEKSNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == eks.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,283 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+)
+
+func (a *action) cleanElastiCacheReplicationGroups(ctx context.Context, input *CleanupScope) error {
+	client := elasticache.New(input.Session)
+
+	groupsToDelete := []*string{}
+	pageFunc := func(page *elasticache.DescribeReplicationGroupsOutput, _ bool) bool {
+		for _, group := range page.ReplicationGroups {
+			if aws.StringValue(group.Status) == "deleting" {
+				a.logger.Debug("elasticache replication group %s is already being deleted, skipping cleanup", *group.ReplicationGroupId)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{ResourceName: group.ARN})
+			if err != nil {
+				a.logger.Error("failed getting tags for elasticache replication group %s: %s", *group.ReplicationGroupId, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "elasticache replication group", ID: *group.ReplicationGroupId, ARN: aws.StringValue(group.ARN), Tags: elasticacheTags(tagOut.TagList), CreatedAt: aws.TimeValue(group.ReplicationGroupCreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "elasticache replication group", *group.ReplicationGroupId, func() error {
+					return a.markElastiCacheResourceForFutureDeletion(ctx, *group.ARN, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding elasticache replication group %s to delete list", *group.ReplicationGroupId)
+			groupsToDelete = append(groupsToDelete, group.ReplicationGroupId)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeReplicationGroupsPagesWithContext(ctx, &elasticache.DescribeReplicationGroupsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of elasticache replication groups: %w", err)
+	}
+
+	if len(groupsToDelete) == 0 {
+		a.logger.Info("no elasticache replication groups to delete")
+		return nil
+	}
+
+	for _, groupId := range groupsToDelete {
+		if !a.canDelete(input, "elasticache replication group", *groupId) {
+			continue
+		}
+
+		if err := a.deleteReplicationGroup(ctx, *groupId, client); err != nil {
+			a.logger.Error("failed to delete elasticache replication group %s: %s", *groupId, err.Error())
+			input.Report.failed(input.Region, "elasticache replication group", *groupId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "elasticache replication group", *groupId)
+	}
+
+	return nil
+}
+
+func (a *action) cleanElastiCacheClusters(ctx context.Context, input *CleanupScope) error {
+	client := elasticache.New(input.Session)
+
+	clustersToDelete := []*string{}
+	pageFunc := func(page *elasticache.DescribeCacheClustersOutput, _ bool) bool {
+		for _, cluster := range page.CacheClusters {
+			// clusters that are part of a replication group are deleted along with their group.
+			if cluster.ReplicationGroupId != nil {
+				a.logger.Debug("elasticache cluster %s is part of replication group %s, skipping cleanup", *cluster.CacheClusterId, *cluster.ReplicationGroupId)
+				continue
+			}
+
+			if aws.StringValue(cluster.CacheClusterStatus) == "deleting" {
+				a.logger.Debug("elasticache cluster %s is already being deleted, skipping cleanup", *cluster.CacheClusterId)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{ResourceName: cluster.ARN})
+			if err != nil {
+				a.logger.Error("failed getting tags for elasticache cluster %s: %s", *cluster.CacheClusterId, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "elasticache cluster", ID: *cluster.CacheClusterId, ARN: aws.StringValue(cluster.ARN), Tags: elasticacheTags(tagOut.TagList), CreatedAt: aws.TimeValue(cluster.CacheClusterCreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "elasticache cluster", *cluster.CacheClusterId, func() error {
+					return a.markElastiCacheResourceForFutureDeletion(ctx, *cluster.ARN, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding elasticache cluster %s to delete list", *cluster.CacheClusterId)
+			clustersToDelete = append(clustersToDelete, cluster.CacheClusterId)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeCacheClustersPagesWithContext(ctx, &elasticache.DescribeCacheClustersInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of elasticache clusters: %w", err)
+	}
+
+	if len(clustersToDelete) == 0 {
+		a.logger.Info("no elasticache clusters to delete")
+		return nil
+	}
+
+	for _, clusterId := range clustersToDelete {
+		if !a.canDelete(input, "elasticache cluster", *clusterId) {
+			continue
+		}
+
+		if err := a.deleteCacheCluster(ctx, *clusterId, client); err != nil {
+			a.logger.Error("failed to delete elasticache cluster %s: %s", *clusterId, err.Error())
+			input.Report.failed(input.Region, "elasticache cluster", *clusterId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "elasticache cluster", *clusterId)
+	}
+
+	return nil
+}
+
+func (a *action) cleanCacheSubnetGroups(ctx context.Context, input *CleanupScope) error {
+	client := elasticache.New(input.Session)
+
+	groupsInUse := map[string]string{}
+	clustersPageFunc := func(page *elasticache.DescribeCacheClustersOutput, _ bool) bool {
+		for _, cluster := range page.CacheClusters {
+			if cluster.CacheSubnetGroupName != nil {
+				groupsInUse[*cluster.CacheSubnetGroupName] = aws.StringValue(cluster.CacheClusterId)
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeCacheClustersPagesWithContext(ctx, &elasticache.DescribeCacheClustersInput{}, clustersPageFunc); err != nil {
+		return fmt.Errorf("failed to get elasticache clusters: %w", err)
+	}
+
+	groupsToDelete := []*string{}
+	pageFunc := func(page *elasticache.DescribeCacheSubnetGroupsOutput, _ bool) bool {
+		for _, group := range page.CacheSubnetGroups {
+			if aws.StringValue(group.CacheSubnetGroupName) == "default" {
+				a.logger.Debug("cache subnet group %s is the default one, skipping cleanup", *group.CacheSubnetGroupName)
+				continue
+			}
+
+			if clusterId, ok := groupsInUse[*group.CacheSubnetGroupName]; ok {
+				a.logger.Debug("cache subnet group %s is used by elasticache cluster %s, skipping cleanup", *group.CacheSubnetGroupName, clusterId)
+				input.Report.skipped(input.Region, "cache subnet group", *group.CacheSubnetGroupName, "used by elasticache cluster "+clusterId)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &elasticache.ListTagsForResourceInput{ResourceName: group.ARN})
+			if err != nil {
+				a.logger.Error("failed getting tags for cache subnet group %s: %s", *group.CacheSubnetGroupName, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "cache subnet group", ID: *group.CacheSubnetGroupName, ARN: aws.StringValue(group.ARN), Tags: elasticacheTags(tagOut.TagList), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "cache subnet group", *group.CacheSubnetGroupName, func(tags Tags) error {
+					return a.tagElastiCacheResource(ctx, *group.ARN, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "cache subnet group", *group.CacheSubnetGroupName, func() error {
+					return a.markElastiCacheResourceForFutureDeletion(ctx, *group.ARN, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding cache subnet group %s to delete list", *group.CacheSubnetGroupName)
+			groupsToDelete = append(groupsToDelete, group.CacheSubnetGroupName)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeCacheSubnetGroupsPagesWithContext(ctx, &elasticache.DescribeCacheSubnetGroupsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of cache subnet groups: %w", err)
+	}
+
+	if len(groupsToDelete) == 0 {
+		a.logger.Info("no unused cache subnet groups to delete")
+		return nil
+	}
+
+	for _, groupName := range groupsToDelete {
+		if !a.canDelete(input, "cache subnet group", *groupName) {
+			continue
+		}
+
+		a.logger.Info("Deleting Cache Subnet Group %s", *groupName)
+		if _, err := client.DeleteCacheSubnetGroupWithContext(ctx, &elasticache.DeleteCacheSubnetGroupInput{CacheSubnetGroupName: groupName}); err != nil {
+			a.logger.Error("failed to delete cache subnet group %s: %s", *groupName, err.Error())
+			input.Report.failed(input.Region, "cache subnet group", *groupName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "cache subnet group", *groupName)
+	}
+
+	return nil
+}
+
+func (a *action) markElastiCacheResourceForFutureDeletion(ctx context.Context, arn string, client *elasticache.ElastiCache) error {
+	a.logger.Info("Marking ElastiCache resource %s for future deletion", arn)
+
+	return a.tagElastiCacheResource(ctx, arn, a.markTags(), client)
+}
+
+func (a *action) tagElastiCacheResource(ctx context.Context, arn string, tags Tags, client *elasticache.ElastiCache) error {
+	_, err := client.AddTagsToResourceWithContext(ctx, &elasticache.AddTagsToResourceInput{
+		ResourceName: &arn,
+		Tags:         elasticacheTagList(tags),
+	})
+
+	return err
+}
+
+func (a *action) deleteReplicationGroup(ctx context.Context, groupId string, client *elasticache.ElastiCache) error {
+	a.logger.Info("Deleting ElastiCache replication group %s", groupId)
+
+	if _, err := client.DeleteReplicationGroupWithContext(ctx, &elasticache.DeleteReplicationGroupInput{
+		ReplicationGroupId:   &groupId,
+		RetainPrimaryCluster: aws.Bool(false),
+	}); err != nil {
+		return fmt.Errorf("failed to delete elasticache replication group %s: %w", groupId, err)
+	}
+
+	if err := waitUntil(ctx, 30*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		if _, err := client.DescribeReplicationGroupsWithContext(ctx, &elasticache.DescribeReplicationGroupsInput{ReplicationGroupId: &groupId}); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == elasticache.ErrCodeReplicationGroupNotFoundFault {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for elasticache replication group %s deletion: %s", groupId, err.Error())
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for elasticache replication group %s deletion: %w", groupId, err)
+	}
+
+	return nil
+}
+
+func (a *action) deleteCacheCluster(ctx context.Context, clusterId string, client *elasticache.ElastiCache) error {
+	a.logger.Info("Deleting ElastiCache cluster %s", clusterId)
+
+	if _, err := client.DeleteCacheClusterWithContext(ctx, &elasticache.DeleteCacheClusterInput{CacheClusterId: &clusterId}); err != nil {
+		return fmt.Errorf("failed to delete elasticache cluster %s: %w", clusterId, err)
+	}
+
+	if err := waitUntil(ctx, 30*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		if _, err := client.DescribeCacheClustersWithContext(ctx, &elasticache.DescribeCacheClustersInput{CacheClusterId: &clusterId}); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == elasticache.ErrCodeCacheClusterNotFoundFault {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for elasticache cluster %s deletion: %s", clusterId, err.Error())
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for elasticache cluster %s deletion: %w", clusterId, err)
+	}
+
+	return nil
+}
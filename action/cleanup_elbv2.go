@@ -2,12 +2,19 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 )
 
+// loadBalancerV2DeletionProtectionAttribute is the attribute preventing the deletion of a v2 load balancer.
+const loadBalancerV2DeletionProtectionAttribute = "deletion_protection.enabled"
+
 func (a *action) cleanLoadBalancersV2(ctx context.Context, input *CleanupScope) error {
 	client := elbv2.New(input.Session)
 
@@ -15,40 +22,25 @@ func (a *action) cleanLoadBalancersV2(ctx context.Context, input *CleanupScope)
 
 	pageFunc := func(page *elbv2.DescribeLoadBalancersOutput, _ bool) bool {
 		for _, lb := range page.LoadBalancers {
+			// the load balancers are reported by the arn they're deleted by, and can be excluded by name too.
+			arn := aws.StringValue(lb.LoadBalancerArn)
 			tagOut, err := client.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: []*string{lb.LoadBalancerArn}})
 			if err != nil {
-				LogError("failed getting tags for elbv2 %s: %s", aws.StringValue(lb.LoadBalancerName), err.Error())
+				a.logger.Error("failed getting tags for elbv2 %s: %s", arn, err.Error())
 				continue
 			}
 
-			var ignore, markedForDeletion bool
-			for _, desc := range tagOut.TagDescriptions {
-				for _, tag := range desc.Tags {
-					switch aws.StringValue(tag.Key) {
-					case input.IgnoreTag:
-						ignore = true
-					case DeletionTag:
-						markedForDeletion = true
-					}
-				}
-			}
-
-			if ignore {
-				LogDebug("elbv2 %s has ignore tag, skipping cleanup", aws.StringValue(lb.LoadBalancerName))
+			switch input.evaluate(resource{Type: "elbv2", ID: arn, ARN: arn, Name: aws.StringValue(lb.LoadBalancerName), Tags: elbv2Tags(tagOut.TagDescriptions), CreatedAt: aws.TimeValue(lb.CreatedTime)}) {
+			case verdictSkip:
 				continue
-			}
-
-			if !markedForDeletion {
-				if a.commit {
-					LogDebug("elbv2 %s does not have deletion tag, marking for future deletion and skipping cleanup", aws.StringValue(lb.LoadBalancerName))
-					if err := a.markLoadBalancerV2ForFutureDeletion(ctx, aws.StringValue(lb.LoadBalancerArn), client); err != nil {
-						LogError("failed to mark elbv2 %s for future deletion: %s", aws.StringValue(lb.LoadBalancerName), err.Error())
-					}
-				}
+			case verdictMark:
+				a.markForDeletion(input, "elbv2", arn, func() error {
+					return a.markLoadBalancerV2ForFutureDeletion(ctx, arn, client)
+				})
 				continue
 			}
 
-			LogDebug("adding elbv2 %s to delete list", aws.StringValue(lb.LoadBalancerName))
+			a.logger.Debug("adding elbv2 %s to delete list", arn)
 			lbsToDelete = append(lbsToDelete, lb.LoadBalancerArn)
 		}
 
@@ -60,55 +52,215 @@ func (a *action) cleanLoadBalancersV2(ctx context.Context, input *CleanupScope)
 	}
 
 	if len(lbsToDelete) == 0 {
-		Log("no elbv2 load balancers to delete")
+		a.logger.Info("no elbv2 load balancers to delete")
 		return nil
 	}
 
 	for _, arn := range lbsToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of elbv2 %s as running in dry-mode", aws.StringValue(arn))
+		if !a.canDelete(input, "elbv2", aws.StringValue(arn)) {
 			continue
 		}
 
-		if err := a.deleteLoadBalancerV2(ctx, aws.StringValue(arn), client); err != nil {
-			LogError("failed to delete elbv2 %s: %s", aws.StringValue(arn), err.Error())
+		// the protection is checked before deleting anything, so the listeners of a protected
+		// load balancer are left in place.
+		protected, err := a.isLoadBalancerV2DeletionProtected(ctx, aws.StringValue(arn), client)
+		if err != nil {
+			a.logger.Warn("failed to get deletion protection of elbv2 %s: %s", aws.StringValue(arn), err.Error())
 		}
+		if protected {
+			if !input.DisableELBv2Protection {
+				a.logger.Warn("elbv2 %s has deletion protection enabled, skipping deletion", aws.StringValue(arn))
+				input.Report.skipped(input.Region, "elbv2", aws.StringValue(arn), "deletion protection enabled")
+				continue
+			}
+			if err := a.disableLoadBalancerV2DeletionProtection(ctx, aws.StringValue(arn), client); err != nil {
+				a.logger.Error("failed to disable deletion protection of elbv2 %s: %s", aws.StringValue(arn), err.Error())
+				input.Report.failed(input.Region, "elbv2", aws.StringValue(arn), err.Error())
+				continue
+			}
+		}
+
+		err = a.deleteLoadBalancerV2(ctx, aws.StringValue(arn), input, client)
+		if errors.Is(err, errAlreadyDeleted) {
+			input.Report.skipped(input.Region, "elbv2", aws.StringValue(arn), "already deleted")
+			continue
+		}
+		if err != nil {
+			a.logger.Error("failed to delete elbv2 %s: %s", aws.StringValue(arn), err.Error())
+			input.Report.failed(input.Region, "elbv2", aws.StringValue(arn), err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "elbv2", aws.StringValue(arn))
 	}
 
 	return nil
 }
 
-func (a *action) deleteLoadBalancerV2(ctx context.Context, lbArn string, client *elbv2.ELBV2) error {
-	Log("Deleting ELBv2 %s and its target groups", lbArn)
+// deleteLoadBalancerV2 deletes the load balancer along with its listeners and target groups, except the
+// target groups with the ignore tag. When the acm certificates cleanup is enabled, the certificates of
+// the listeners are released to be cleaned up.
+func (a *action) deleteLoadBalancerV2(ctx context.Context, lbArn string, input *CleanupScope, client elbv2iface.ELBV2API) error {
+	a.logger.Info("Deleting ELBv2 %s with its listeners and target groups", lbArn)
 
-	tgsOut, err := client.DescribeTargetGroupsWithContext(ctx, &elbv2.DescribeTargetGroupsInput{LoadBalancerArn: aws.String(lbArn)})
-	if err != nil {
-		LogWarning("failed to list target groups for lb %s: %s", lbArn, err.Error())
+	// the target groups are listed before the load balancer is deleted, as they can't be
+	// looked up by load balancer afterwards.
+	targetGroups := []*elbv2.TargetGroup{}
+	if err := client.DescribeTargetGroupsPagesWithContext(ctx, &elbv2.DescribeTargetGroupsInput{LoadBalancerArn: aws.String(lbArn)}, func(page *elbv2.DescribeTargetGroupsOutput, _ bool) bool {
+		targetGroups = append(targetGroups, page.TargetGroups...)
+		return true
+	}); err != nil && !isNotFoundError(err) {
+		a.logger.Warn("failed to list target groups for lb %s: %s", lbArn, err.Error())
 	}
 
-	if _, err := client.DeleteLoadBalancerWithContext(ctx, &elbv2.DeleteLoadBalancerInput{LoadBalancerArn: aws.String(lbArn)}); err != nil {
+	certificateArns, err := a.deleteLoadBalancerV2Listeners(ctx, lbArn, client)
+	if err != nil && !isNotFoundError(err) {
+		a.logger.Warn("failed to delete listeners for elbv2 %s: %s", lbArn, err.Error())
+	}
+
+	if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+		_, err := client.DeleteLoadBalancerWithContext(ctx, &elbv2.DeleteLoadBalancerInput{LoadBalancerArn: aws.String(lbArn)})
+		return err
+	}); err != nil {
+		if isAlreadyDeleted(a.logger, err, "elbv2", lbArn) {
+			return errAlreadyDeleted
+		}
 		return fmt.Errorf("failed to delete elbv2 %s: %w", lbArn, err)
 	}
 
-	if err := client.WaitUntilLoadBalancersDeletedWithContext(ctx, &elbv2.DescribeLoadBalancersInput{LoadBalancerArns: []*string{aws.String(lbArn)}}); err != nil {
-		LogWarning("failed waiting for elbv2 %s deletion: %s", lbArn, err.Error())
+	if err := waitUntilState(ctx, 10*time.Minute, 15*time.Second, func(ctx context.Context) (bool, string, error) {
+		out, err := client.DescribeLoadBalancersWithContext(ctx, &elbv2.DescribeLoadBalancersInput{LoadBalancerArns: []*string{aws.String(lbArn)}})
+		if err != nil {
+			if isNotFoundError(err) {
+				return true, "", nil
+			}
+			return false, "last check failed: " + err.Error(), nil
+		}
+		if len(out.LoadBalancers) == 0 {
+			return true, "", nil
+		}
+		if state := out.LoadBalancers[0].State; state != nil {
+			return false, "still in state " + aws.StringValue(state.Code), nil
+		}
+		return false, "still described", nil
+	}); err != nil {
+		a.logger.Warn("failed waiting for elbv2 %s deletion: %s", lbArn, err.Error())
+	}
+
+	if input.DeleteACMCertificates && len(certificateArns) > 0 {
+		a.logger.Debug("releasing acm certificates %s of elbv2 %s", strings.Join(certificateArns, ", "), lbArn)
+		a.releasedCertificates.add(input.AccountID, input.Region, lbArn, certificateArns)
 	}
 
-	for _, tg := range tgsOut.TargetGroups {
-		Log("Deleting target group %s", aws.StringValue(tg.TargetGroupArn))
-		if _, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: tg.TargetGroupArn}); err != nil {
-			LogWarning("failed to delete target group %s: %s", aws.StringValue(tg.TargetGroupArn), err.Error())
+	for _, tg := range targetGroups {
+		// the load balancer is already gone, so a target group left in place doesn't block anything.
+		tagOut, err := client.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: []*string{tg.TargetGroupArn}})
+		if err != nil {
+			a.logger.Warn("failed getting tags for target group %s, leaving it in place: %s", aws.StringValue(tg.TargetGroupArn), err.Error())
+			continue
+		}
+		if input.isIgnored(elbv2Tags(tagOut.TagDescriptions)) {
+			a.logger.Info("target group %s of elbv2 %s has ignore tag, leaving it in place", aws.StringValue(tg.TargetGroupArn), lbArn)
+			input.Report.skipped(input.Region, "target group", aws.StringValue(tg.TargetGroupArn), "has ignore tag")
+			continue
+		}
+
+		a.logger.Info("Deleting target group %s", aws.StringValue(tg.TargetGroupArn))
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: tg.TargetGroupArn})
+			return err
+		}); err != nil && !isAlreadyDeleted(a.logger, err, "target group", aws.StringValue(tg.TargetGroupArn)) {
+			a.logger.Warn("failed to delete target group %s: %s", aws.StringValue(tg.TargetGroupArn), err.Error())
 		}
 	}
 
 	return nil
 }
 
+// isLoadBalancerV2DeletionProtected returns true if the load balancer has deletion protection enabled.
+func (a *action) isLoadBalancerV2DeletionProtected(ctx context.Context, lbArn string, client *elbv2.ELBV2) (bool, error) {
+	out, err := client.DescribeLoadBalancerAttributesWithContext(ctx, &elbv2.DescribeLoadBalancerAttributesInput{LoadBalancerArn: aws.String(lbArn)})
+	if err != nil {
+		return false, err
+	}
+
+	for _, attribute := range out.Attributes {
+		if aws.StringValue(attribute.Key) == loadBalancerV2DeletionProtectionAttribute {
+			return aws.StringValue(attribute.Value) == "true", nil
+		}
+	}
+
+	return false, nil
+}
+
+func (a *action) disableLoadBalancerV2DeletionProtection(ctx context.Context, lbArn string, client *elbv2.ELBV2) error {
+	a.logger.Warn("Disabling deletion protection of elbv2 %s, as it's marked for deletion", lbArn)
+
+	_, err := client.ModifyLoadBalancerAttributesWithContext(ctx, &elbv2.ModifyLoadBalancerAttributesInput{
+		LoadBalancerArn: aws.String(lbArn),
+		Attributes: []*elbv2.LoadBalancerAttribute{
+			{Key: aws.String(loadBalancerV2DeletionProtectionAttribute), Value: aws.String("false")},
+		},
+	})
+
+	return err
+}
+
+// deleteLoadBalancerV2Listeners deletes the listeners of the load balancer and returns the
+// arns of the certificates that were attached to them.
+func (a *action) deleteLoadBalancerV2Listeners(ctx context.Context, lbArn string, client elbv2iface.ELBV2API) ([]string, error) {
+	listenerArns := []*string{}
+	pageFunc := func(page *elbv2.DescribeListenersOutput, _ bool) bool {
+		for _, listener := range page.Listeners {
+			listenerArns = append(listenerArns, listener.ListenerArn)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeListenersPagesWithContext(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: aws.String(lbArn)}, pageFunc); err != nil {
+		return nil, fmt.Errorf("failed to list listeners: %w", err)
+	}
+
+	certificateArns := []string{}
+	for _, listenerArn := range listenerArns {
+		// the listeners only describe their default certificate, the others must be listed.
+		params := &elbv2.DescribeListenerCertificatesInput{ListenerArn: listenerArn}
+		for {
+			out, err := client.DescribeListenerCertificatesWithContext(ctx, params)
+			if err != nil {
+				a.logger.Warn("failed to list certificates of listener %s: %s", aws.StringValue(listenerArn), err.Error())
+				break
+			}
+			for _, cert := range out.Certificates {
+				a.logger.Debug("listener %s has certificate %s attached", aws.StringValue(listenerArn), aws.StringValue(cert.CertificateArn))
+				certificateArns = append(certificateArns, aws.StringValue(cert.CertificateArn))
+			}
+
+			if out.NextMarker == nil {
+				break
+			}
+			params.Marker = out.NextMarker
+		}
+	}
+
+	for _, listenerArn := range listenerArns {
+		a.logger.Info("Deleting listener %s", aws.StringValue(listenerArn))
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteListenerWithContext(ctx, &elbv2.DeleteListenerInput{ListenerArn: listenerArn})
+			return err
+		}); err != nil && !isAlreadyDeleted(a.logger, err, "listener", aws.StringValue(listenerArn)) {
+			a.logger.Warn("failed to delete listener %s: %s", aws.StringValue(listenerArn), err.Error())
+		}
+	}
+
+	return certificateArns, nil
+}
+
 func (a *action) markLoadBalancerV2ForFutureDeletion(ctx context.Context, lbArn string, client *elbv2.ELBV2) error {
-	Log("Marking ELBv2 %s for future deletion", lbArn)
+	a.logger.Info("Marking ELBv2 %s for future deletion", lbArn)
 	_, err := client.AddTagsWithContext(ctx, &elbv2.AddTagsInput{
 		ResourceArns: []*string{aws.String(lbArn)},
-		Tags:         []*elbv2.Tag{{Key: aws.String(DeletionTag), Value: aws.String("true")}},
+		Tags:         elbv2TagList(a.markTags()),
 	})
 	return err
 }
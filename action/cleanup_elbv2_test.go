@@ -0,0 +1,94 @@
+package action
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+)
+
+// fakeELBV2 is a load balancer whose target groups are described over several pages.
+type fakeELBV2 struct {
+	elbv2iface.ELBV2API
+	targetGroupPages [][]*elbv2.TargetGroup
+	// tags are the tags of the target groups, by arn.
+	tags                map[string]Tags
+	deletedTargetGroups []string
+	deletedLB           bool
+}
+
+func (f *fakeELBV2) DescribeTargetGroupsPagesWithContext(_ aws.Context, _ *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool, _ ...request.Option) error {
+	for i, page := range f.targetGroupPages {
+		if !fn(&elbv2.DescribeTargetGroupsOutput{TargetGroups: page}, i == len(f.targetGroupPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeELBV2) DescribeListenersPagesWithContext(_ aws.Context, _ *elbv2.DescribeListenersInput, fn func(*elbv2.DescribeListenersOutput, bool) bool, _ ...request.Option) error {
+	fn(&elbv2.DescribeListenersOutput{}, true)
+	return nil
+}
+
+func (f *fakeELBV2) DeleteLoadBalancerWithContext(_ aws.Context, _ *elbv2.DeleteLoadBalancerInput, _ ...request.Option) (*elbv2.DeleteLoadBalancerOutput, error) {
+	f.deletedLB = true
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+func (f *fakeELBV2) DescribeLoadBalancersWithContext(_ aws.Context, _ *elbv2.DescribeLoadBalancersInput, _ ...request.Option) (*elbv2.DescribeLoadBalancersOutput, error) {
+	return &elbv2.DescribeLoadBalancersOutput{}, nil
+}
+
+func (f *fakeELBV2) DescribeTagsWithContext(_ aws.Context, input *elbv2.DescribeTagsInput, _ ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	arn := aws.StringValue(input.ResourceArns[0])
+	return &elbv2.DescribeTagsOutput{TagDescriptions: []*elbv2.TagDescription{
+		{ResourceArn: aws.String(arn), Tags: elbv2TagList(f.tags[arn])},
+	}}, nil
+}
+
+func (f *fakeELBV2) DeleteTargetGroupWithContext(_ aws.Context, input *elbv2.DeleteTargetGroupInput, _ ...request.Option) (*elbv2.DeleteTargetGroupOutput, error) {
+	f.deletedTargetGroups = append(f.deletedTargetGroups, aws.StringValue(input.TargetGroupArn))
+	return &elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+func targetGroups(arns ...string) []*elbv2.TargetGroup {
+	groups := []*elbv2.TargetGroup{}
+	for _, arn := range arns {
+		groups = append(groups, &elbv2.TargetGroup{TargetGroupArn: aws.String(arn)})
+	}
+	return groups
+}
+
+func TestDeleteLoadBalancerV2DeletesTargetGroupsOfAllPages(t *testing.T) {
+	client := &fakeELBV2{
+		targetGroupPages: [][]*elbv2.TargetGroup{
+			targetGroups("tg-1", "tg-2"),
+			targetGroups("tg-3", "tg-4"),
+			targetGroups("tg-5"),
+		},
+		tags: map[string]Tags{"tg-4": {"janitor-ignore": "true"}},
+	}
+	a := newTestAction(t, true)
+	input := newTestScope(a, "us-east-1")
+	input.IgnoreTags = []IgnoreTag{{Key: "janitor-ignore"}}
+
+	if err := a.deleteLoadBalancerV2(context.Background(), "lb-1", input, client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !client.deletedLB {
+		t.Fatal("load balancer wasn't deleted")
+	}
+	// the target group with the ignore tag is left in place.
+	if want := []string{"tg-1", "tg-2", "tg-3", "tg-5"}; !slices.Equal(client.deletedTargetGroups, want) {
+		t.Fatalf("got deleted target groups %v, want %v", client.deletedTargetGroups, want)
+	}
+	if skipped := a.report.Resources["target group"].Skipped; len(skipped) != 1 || skipped[0].ID != "tg-4" {
+		t.Fatalf("got skipped target groups %v, want tg-4", skipped)
+	}
+}
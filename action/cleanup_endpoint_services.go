@@ -0,0 +1,128 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanVPCEndpointServices(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	servicesToDelete := []*ec2.ServiceConfiguration{}
+	pageFunc := func(page *ec2.DescribeVpcEndpointServiceConfigurationsOutput, _ bool) bool {
+		for _, service := range page.ServiceConfigurations {
+			if aws.StringValue(service.ServiceState) == ec2.ServiceStateDeleting {
+				a.logger.Debug("vpc endpoint service %s is already being deleted, skipping cleanup", *service.ServiceId)
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "vpc endpoint service", ID: *service.ServiceId, Tags: ec2Tags(service.Tags), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "vpc endpoint service", *service.ServiceId, func(tags Tags) error {
+					return a.tagEC2Resource(ctx, *service.ServiceId, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "vpc endpoint service", *service.ServiceId, func() error {
+					return a.markVPCEndpointServiceForFutureDeletion(ctx, *service.ServiceId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding vpc endpoint service %s to delete list", *service.ServiceId)
+			servicesToDelete = append(servicesToDelete, service)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeVpcEndpointServiceConfigurationsPagesWithContext(ctx, &ec2.DescribeVpcEndpointServiceConfigurationsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of vpc endpoint services: %w", err)
+	}
+
+	if len(servicesToDelete) == 0 {
+		a.logger.Info("no vpc endpoint services to delete")
+		return nil
+	}
+
+	for _, service := range servicesToDelete {
+		if !a.canDelete(input, "vpc endpoint service", *service.ServiceId) {
+			continue
+		}
+
+		if err := a.deleteVPCEndpointService(ctx, service, client); err != nil {
+			a.logger.Error("failed to delete vpc endpoint service %s: %s", *service.ServiceId, err.Error())
+			input.Report.failed(input.Region, "vpc endpoint service", *service.ServiceId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "vpc endpoint service", *service.ServiceId)
+	}
+
+	return nil
+}
+
+func (a *action) markVPCEndpointServiceForFutureDeletion(ctx context.Context, serviceId string, client *ec2.EC2) error {
+	a.logger.Info("Marking VPC Endpoint Service %s for future deletion", serviceId)
+
+	return a.tagEC2Resource(ctx, serviceId, a.markTags(), client)
+}
+
+// deleteVPCEndpointService rejects the endpoint connections to the service, which would
+// otherwise prevent its deletion, then deletes it.
+func (a *action) deleteVPCEndpointService(ctx context.Context, service *ec2.ServiceConfiguration, client *ec2.EC2) error {
+	a.logger.Info("Deleting VPC Endpoint Service %s (load balancers %v)", *service.ServiceId, aws.StringValueSlice(service.NetworkLoadBalancerArns))
+
+	endpointIds := []*string{}
+	pageFunc := func(page *ec2.DescribeVpcEndpointConnectionsOutput, _ bool) bool {
+		for _, connection := range page.VpcEndpointConnections {
+			switch aws.StringValue(connection.VpcEndpointState) {
+			case ec2.StateRejected, ec2.StateDeleted, ec2.StateDeleting, ec2.StateFailed:
+				continue
+			}
+			endpointIds = append(endpointIds, connection.VpcEndpointId)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeVpcEndpointConnectionsPagesWithContext(ctx, &ec2.DescribeVpcEndpointConnectionsInput{
+		Filters: []*ec2.Filter{{Name: aws.String("service-id"), Values: []*string{service.ServiceId}}},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed to describe vpc endpoint connections: %w", err)
+	}
+
+	if len(endpointIds) > 0 {
+		a.logger.Debug("Rejecting %d vpc endpoint connections to service %s", len(endpointIds), *service.ServiceId)
+		out, err := client.RejectVpcEndpointConnectionsWithContext(ctx, &ec2.RejectVpcEndpointConnectionsInput{
+			ServiceId:      service.ServiceId,
+			VpcEndpointIds: endpointIds,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reject vpc endpoint connections: %w", err)
+		}
+		for _, item := range out.Unsuccessful {
+			if item.Error != nil {
+				a.logger.Warn("failed to reject vpc endpoint connection %s: %s", aws.StringValue(item.ResourceId), aws.StringValue(item.Error.Message))
+			}
+		}
+	}
+
+	out, err := client.DeleteVpcEndpointServiceConfigurationsWithContext(ctx, &ec2.DeleteVpcEndpointServiceConfigurationsInput{
+		ServiceIds: []*string{service.ServiceId},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete vpc endpoint service configuration: %w", err)
+	}
+	for _, item := range out.Unsuccessful {
+		if item.Error != nil {
+			return fmt.Errorf("failed to delete vpc endpoint service configuration: %s", aws.StringValue(item.Error.Message))
+		}
+	}
+
+	return nil
+}
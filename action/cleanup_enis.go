@@ -2,67 +2,235 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 func (a *action) cleanNetworkInterfaces(ctx context.Context, input *CleanupScope) error {
-	client := ec2.New(input.Session)
-
-	out, err := client.DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
-		Filters: []*ec2.Filter{
-			{Name: aws.String("status"), Values: []*string{aws.String("available")}},
-		},
-	})
-	if err != nil {
+	return a.cleanNetworkInterfacesWithClient(ctx, input, ec2.New(input.Session))
+}
+
+// cleanNetworkInterfacesWithClient cleans up the network interfaces with client, which can be faked.
+func (a *action) cleanNetworkInterfacesWithClient(ctx context.Context, input *CleanupScope, client ec2iface.EC2API) error {
+	nisToDelete := []*ec2.NetworkInterface{}
+	pageFunc := func(page *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		for _, ni := range page.NetworkInterfaces {
+			if !a.isNetworkInterfaceDescriptionSelected(ni, input) {
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "network interface", ID: aws.StringValue(ni.NetworkInterfaceId), Tags: ec2Tags(ni.TagSet), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "network interface", aws.StringValue(ni.NetworkInterfaceId), func(tags Tags) error {
+					return a.tagEC2Resource(ctx, aws.StringValue(ni.NetworkInterfaceId), tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "network interface", aws.StringValue(ni.NetworkInterfaceId), func() error {
+					return a.markNetworkInterfaceForFutureDeletion(ctx, aws.StringValue(ni.NetworkInterfaceId), client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding network interface %s to delete list", aws.StringValue(ni.NetworkInterfaceId))
+			nisToDelete = append(nisToDelete, ni)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeNetworkInterfacesPagesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{
+		Filters: networkInterfaceFilters(input),
+	}, pageFunc); err != nil {
 		return fmt.Errorf("failed to describe network interfaces: %w", err)
 	}
 
-	if len(out.NetworkInterfaces) == 0 {
-		Log("no unattached network interfaces to delete")
+	if len(nisToDelete) == 0 {
+		a.logger.Info("no unattached network interfaces to delete")
 		return nil
 	}
 
-	for _, ni := range out.NetworkInterfaces {
-		var ignore, markedForDeletion bool
-		for _, tag := range ni.TagSet {
-			switch aws.StringValue(tag.Key) {
-			case input.IgnoreTag:
-				ignore = true
-			case DeletionTag:
-				markedForDeletion = true
-			}
+	for _, ni := range nisToDelete {
+		if !a.canDelete(input, "network interface", aws.StringValue(ni.NetworkInterfaceId)) {
+			continue
+		}
+
+		err := a.deleteNetworkInterface(ctx, ni, input, client)
+		if errors.Is(err, errAlreadyDeleted) {
+			input.Report.skipped(input.Region, "network interface", aws.StringValue(ni.NetworkInterfaceId), "already deleted")
+			continue
 		}
-		if ignore {
-			LogDebug("network interface %s has ignore tag, skipping cleanup", aws.StringValue(ni.NetworkInterfaceId))
+		if err != nil {
+			a.logger.Warn("failed to delete network interface %s: %s", aws.StringValue(ni.NetworkInterfaceId), err.Error())
+			input.Report.failed(input.Region, "network interface", aws.StringValue(ni.NetworkInterfaceId), err.Error())
 			continue
 		}
+		input.Report.deleted(input.Region, "network interface", aws.StringValue(ni.NetworkInterfaceId))
+	}
+
+	return nil
+}
+
+// networkInterfaceFilters returns the filters selecting the available network interfaces,
+// restricted to the vpcs, requesters and interface types of the scope when set.
+func networkInterfaceFilters(input *CleanupScope) []*ec2.Filter {
+	filters := []*ec2.Filter{
+		{Name: aws.String("status"), Values: []*string{aws.String("available")}},
+	}
+	if len(input.ENIVPCIDs) > 0 {
+		filters = append(filters, &ec2.Filter{Name: aws.String("vpc-id"), Values: aws.StringSlice(input.ENIVPCIDs)})
+	}
+	if len(input.ENIRequesterIDs) > 0 {
+		filters = append(filters, &ec2.Filter{Name: aws.String("requester-id"), Values: aws.StringSlice(input.ENIRequesterIDs)})
+	}
+	if len(input.ENIInterfaceTypes) > 0 {
+		filters = append(filters, &ec2.Filter{Name: aws.String("interface-type"), Values: aws.StringSlice(input.ENIInterfaceTypes)})
+	}
+
+	return filters
+}
 
-		if !markedForDeletion {
-			if a.commit {
-				LogDebug("network interface %s does not have deletion tag, marking for future deletion and skipping cleanup", aws.StringValue(ni.NetworkInterfaceId))
-				if _, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
-					Resources: []*string{ni.NetworkInterfaceId},
-					Tags:      []*ec2.Tag{{Key: aws.String(DeletionTag), Value: aws.String("true")}},
-				}); err != nil {
-					LogError("failed to mark network interface %s for future deletion: %s", aws.StringValue(ni.NetworkInterfaceId), err.Error())
-				}
+// descriptionPattern matches the descriptions holding its text, or matching its regular
+// expression when it's written between slashes, e.g. "/^ELB app\//".
+type descriptionPattern struct {
+	text string
+	re   *regexp.Regexp
+}
+
+func (p descriptionPattern) matches(description string) bool {
+	if p.re != nil {
+		return p.re.MatchString(description)
+	}
+	return strings.Contains(description, p.text)
+}
+
+func (p descriptionPattern) String() string {
+	return p.text
+}
+
+// parseDescriptionPatterns parses a list of description patterns, ignoring the empty ones.
+func parseDescriptionPatterns(values []string) ([]descriptionPattern, error) {
+	patterns := []descriptionPattern{}
+	for _, value := range splitList(values) {
+		pattern := descriptionPattern{text: value}
+		if len(value) > 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			re, err := regexp.Compile(value[1 : len(value)-1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %s", ErrInvalidDescriptionPattern, value, err.Error())
 			}
-			continue
+			pattern.re = re
 		}
+		patterns = append(patterns, pattern)
+	}
 
-		if !a.commit {
-			LogDebug("skipping deletion of network interface %s as running in dry-mode", aws.StringValue(ni.NetworkInterfaceId))
-			continue
+	return patterns, nil
+}
+
+// isNetworkInterfaceDescriptionSelected returns true if the description of the network interface
+// matches one of the include patterns, when set, and none of the exclude patterns.
+func (a *action) isNetworkInterfaceDescriptionSelected(ni *ec2.NetworkInterface, input *CleanupScope) bool {
+	niId, description := aws.StringValue(ni.NetworkInterfaceId), aws.StringValue(ni.Description)
+
+	if len(input.ENIIncludeDescriptions) > 0 {
+		included := false
+		for _, pattern := range input.ENIIncludeDescriptions {
+			if pattern.matches(description) {
+				a.logger.Debug("network interface %s description %q matches include pattern %s", niId, description, pattern)
+				included = true
+				break
+			}
+		}
+		if !included {
+			a.logger.Debug("network interface %s description %q doesn't match any include pattern, skipping cleanup", niId, description)
+			return false
 		}
+	}
 
-		Log("Deleting unattached network interface %s (subnet %s, desc=%s)", aws.StringValue(ni.NetworkInterfaceId), aws.StringValue(ni.SubnetId), aws.StringValue(ni.Description))
-		if _, err := client.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: ni.NetworkInterfaceId}); err != nil {
-			LogWarning("failed to delete network interface %s: %s", aws.StringValue(ni.NetworkInterfaceId), err.Error())
-			continue
+	for _, pattern := range input.ENIExcludeDescriptions {
+		if pattern.matches(description) {
+			a.logger.Debug("network interface %s description %q matches exclude pattern %s, skipping cleanup", niId, description, pattern)
+			input.Report.skipped(input.Region, "network interface", niId, "description excluded by "+pattern.String())
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a *action) markNetworkInterfaceForFutureDeletion(ctx context.Context, niId string, client ec2iface.EC2API) error {
+	a.logger.Info("Marking Network Interface %s for future deletion", niId)
+
+	return a.tagEC2Resource(ctx, niId, a.markTags(), client)
+}
+
+func (a *action) deleteNetworkInterface(ctx context.Context, ni *ec2.NetworkInterface, input *CleanupScope, client ec2iface.EC2API) error {
+	a.logger.Info("Deleting unattached network interface %s (subnet %s, desc=%s)", aws.StringValue(ni.NetworkInterfaceId), aws.StringValue(ni.SubnetId), aws.StringValue(ni.Description))
+
+	// interfaces can be left available with an attachment that was never cleaned up,
+	// e.g. when a lambda teardown failed, which prevents their deletion.
+	if ni.Attachment != nil && ni.Attachment.AttachmentId != nil && !aws.BoolValue(ni.Attachment.DeleteOnTermination) {
+		if !input.ForceDetachENIs {
+			a.logger.Debug("network interface %s has a lingering attachment %s, its deletion may fail", aws.StringValue(ni.NetworkInterfaceId), aws.StringValue(ni.Attachment.AttachmentId))
+		} else if err := a.forceDetachNetworkInterface(ctx, ni, client); err != nil {
+			return err
+		}
+	}
+
+	if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+		_, err := client.DeleteNetworkInterfaceWithContext(ctx, &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: ni.NetworkInterfaceId})
+		return err
+	}); err != nil {
+		if isAlreadyDeleted(a.logger, err, "network interface", aws.StringValue(ni.NetworkInterfaceId)) {
+			return errAlreadyDeleted
+		}
+		return fmt.Errorf("failed to delete network interface %s: %w", aws.StringValue(ni.NetworkInterfaceId), err)
+	}
+
+	return nil
+}
+
+// forceDetachNetworkInterface force-detaches the lingering attachment of the network interface,
+// then waits for it to be available again.
+func (a *action) forceDetachNetworkInterface(ctx context.Context, ni *ec2.NetworkInterface, client ec2iface.EC2API) error {
+	niId := aws.StringValue(ni.NetworkInterfaceId)
+	a.logger.Info("Force-detaching attachment %s of network interface %s", aws.StringValue(ni.Attachment.AttachmentId), niId)
+
+	if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+		_, err := client.DetachNetworkInterfaceWithContext(ctx, &ec2.DetachNetworkInterfaceInput{
+			AttachmentId: ni.Attachment.AttachmentId,
+			Force:        aws.Bool(true),
+		})
+		return err
+	}); err != nil && !isNotFoundError(err) {
+		return fmt.Errorf("failed to force-detach network interface %s: %w", niId, err)
+	}
+
+	if err := waitUntil(ctx, 5*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeNetworkInterfacesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: []*string{ni.NetworkInterfaceId}})
+		if err != nil {
+			if isNotFoundError(err) {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for network interface %s to be detached: %s", niId, err.Error())
+			return false, nil
+		}
+		for _, current := range out.NetworkInterfaces {
+			if aws.StringValue(current.Status) != ec2.NetworkInterfaceStatusAvailable || current.Attachment != nil && aws.StringValue(current.Attachment.Status) != ec2.AttachmentStatusDetached {
+				return false, nil
+			}
 		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for network interface %s to be detached: %w", niId, err)
 	}
 
 	return nil
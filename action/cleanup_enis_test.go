@@ -0,0 +1,92 @@
+package action
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// fakeEC2 describes the network interfaces over several pages.
+type fakeEC2 struct {
+	ec2iface.EC2API
+	networkInterfacePages [][]*ec2.NetworkInterface
+	tagged                []string
+	deleted               []string
+}
+
+func (f *fakeEC2) DescribeNetworkInterfacesPagesWithContext(_ aws.Context, _ *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool, _ ...request.Option) error {
+	for i, page := range f.networkInterfacePages {
+		if !fn(&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: page}, i == len(f.networkInterfacePages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEC2) CreateTagsWithContext(_ aws.Context, input *ec2.CreateTagsInput, _ ...request.Option) (*ec2.CreateTagsOutput, error) {
+	f.tagged = append(f.tagged, aws.StringValueSlice(input.Resources)...)
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteNetworkInterfaceWithContext(_ aws.Context, input *ec2.DeleteNetworkInterfaceInput, _ ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	f.deleted = append(f.deleted, aws.StringValue(input.NetworkInterfaceId))
+	return &ec2.DeleteNetworkInterfaceOutput{}, nil
+}
+
+// networkInterface returns an available network interface, marked for deletion if marked is set.
+func networkInterface(id string, marked bool) *ec2.NetworkInterface {
+	ni := &ec2.NetworkInterface{NetworkInterfaceId: aws.String(id), Status: aws.String(ec2.NetworkInterfaceStatusAvailable)}
+	if marked {
+		ni.TagSet = []*ec2.Tag{{Key: aws.String(DeletionTag), Value: aws.String("true")}}
+	}
+	return ni
+}
+
+func TestCleanNetworkInterfacesProcessesAllPages(t *testing.T) {
+	client := &fakeEC2{networkInterfacePages: [][]*ec2.NetworkInterface{
+		{networkInterface("eni-1", true), networkInterface("eni-2", false)},
+		{networkInterface("eni-3", false), networkInterface("eni-4", true)},
+		{networkInterface("eni-5", true)},
+	}}
+	a := newTestAction(t, true)
+
+	if err := a.cleanNetworkInterfacesWithClient(context.Background(), newTestScope(a, "us-east-1"), client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{"eni-2", "eni-3"}; !slices.Equal(client.tagged, want) {
+		t.Fatalf("got marked network interfaces %v, want %v", client.tagged, want)
+	}
+	if want := []string{"eni-1", "eni-4", "eni-5"}; !slices.Equal(client.deleted, want) {
+		t.Fatalf("got deleted network interfaces %v, want %v", client.deleted, want)
+	}
+}
+
+func TestCleanNetworkInterfacesDryRunProcessesAllPages(t *testing.T) {
+	client := &fakeEC2{networkInterfacePages: [][]*ec2.NetworkInterface{
+		{networkInterface("eni-1", true)},
+		{networkInterface("eni-2", false)},
+		{networkInterface("eni-3", true)},
+	}}
+	a := newTestAction(t, false)
+
+	if err := a.cleanNetworkInterfacesWithClient(context.Background(), newTestScope(a, "us-east-1"), client); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(client.tagged) > 0 || len(client.deleted) > 0 {
+		t.Fatalf("dry run marked %v and deleted %v", client.tagged, client.deleted)
+	}
+	report := a.report.Resources["network interface"]
+	if len(report.WouldMark) != 1 || report.WouldMark[0].ID != "eni-2" {
+		t.Fatalf("got would mark %v, want eni-2", report.WouldMark)
+	}
+	if len(report.WouldDelete) != 2 || report.WouldDelete[0].ID != "eni-1" || report.WouldDelete[1].ID != "eni-3" {
+		t.Fatalf("got would delete %v, want eni-1 and eni-3", report.WouldDelete)
+	}
+}
@@ -0,0 +1,191 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+)
+
+const (
+	// EventBridgeBusesDefault only cleans up the rules of the default event bus.
+	EventBridgeBusesDefault = "default"
+	// EventBridgeBusesCustom only cleans up the rules of the custom event buses.
+	EventBridgeBusesCustom = "custom"
+	// EventBridgeBusesAll cleans up the rules of all the event buses.
+	EventBridgeBusesAll = "all"
+
+	defaultEventBus = "default"
+)
+
+func (a *action) cleanEventBridgeRules(ctx context.Context, input *CleanupScope) error {
+	client := eventbridge.New(input.Session)
+
+	buses, err := a.getEventBuses(ctx, input.EventBridgeBuses, client)
+	if err != nil {
+		return fmt.Errorf("failed getting list of event buses: %w", err)
+	}
+
+	rulesToDelete := []*eventbridge.Rule{}
+	for _, bus := range buses {
+		rules, err := a.getEventBridgeRules(ctx, bus, client)
+		if err != nil {
+			a.logger.Error("failed getting rules of event bus %s: %s", bus, err.Error())
+			continue
+		}
+
+		for _, rule := range rules {
+			name := aws.StringValue(rule.Name)
+
+			// the rules managed by other services, e.g. for their integrations, are left to them.
+			if rule.ManagedBy != nil {
+				a.logger.Debug("eventbridge rule %s is managed by %s, skipping cleanup", name, aws.StringValue(rule.ManagedBy))
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &eventbridge.ListTagsForResourceInput{ResourceARN: rule.Arn})
+			if err != nil {
+				a.logger.Error("failed getting tags for eventbridge rule %s: %s", name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "eventbridge rule", ID: name, ARN: aws.StringValue(rule.Arn), Tags: eventbridgeTags(tagOut.Tags), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "eventbridge rule", name, func(tags Tags) error {
+					return a.tagEventBridgeRule(ctx, aws.StringValue(rule.Arn), tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "eventbridge rule", name, func() error {
+					return a.markEventBridgeRuleForFutureDeletion(ctx, aws.StringValue(rule.Arn), client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding eventbridge rule %s of event bus %s to delete list", name, bus)
+			rulesToDelete = append(rulesToDelete, rule)
+		}
+	}
+
+	if len(rulesToDelete) == 0 {
+		a.logger.Info("no eventbridge rules to delete")
+		return nil
+	}
+
+	for _, rule := range rulesToDelete {
+		name := aws.StringValue(rule.Name)
+		if !a.canDelete(input, "eventbridge rule", name) {
+			continue
+		}
+
+		if err := a.deleteEventBridgeRule(ctx, rule, client); err != nil {
+			a.logger.Error("failed to delete eventbridge rule %s: %s", name, err.Error())
+			input.Report.failed(input.Region, "eventbridge rule", name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "eventbridge rule", name)
+	}
+
+	return nil
+}
+
+// getEventBuses returns the names of the event buses whose rules are cleaned up, depending on scope.
+func (a *action) getEventBuses(ctx context.Context, scope string, client *eventbridge.EventBridge) ([]string, error) {
+	if scope == EventBridgeBusesDefault {
+		return []string{defaultEventBus}, nil
+	}
+
+	buses := []string{}
+	params := &eventbridge.ListEventBusesInput{}
+	for {
+		out, err := client.ListEventBusesWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for _, bus := range out.EventBuses {
+			if name := aws.StringValue(bus.Name); name != defaultEventBus || scope == EventBridgeBusesAll {
+				buses = append(buses, name)
+			}
+		}
+
+		if out.NextToken == nil {
+			return buses, nil
+		}
+		params.NextToken = out.NextToken
+	}
+}
+
+func (a *action) getEventBridgeRules(ctx context.Context, bus string, client *eventbridge.EventBridge) ([]*eventbridge.Rule, error) {
+	rules := []*eventbridge.Rule{}
+	params := &eventbridge.ListRulesInput{EventBusName: &bus}
+	for {
+		out, err := client.ListRulesWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, out.Rules...)
+
+		if out.NextToken == nil {
+			return rules, nil
+		}
+		params.NextToken = out.NextToken
+	}
+}
+
+func (a *action) markEventBridgeRuleForFutureDeletion(ctx context.Context, arn string, client *eventbridge.EventBridge) error {
+	a.logger.Info("Marking EventBridge rule %s for future deletion", arn)
+
+	return a.tagEventBridgeRule(ctx, arn, a.markTags(), client)
+}
+
+func (a *action) tagEventBridgeRule(ctx context.Context, arn string, tags Tags, client *eventbridge.EventBridge) error {
+	_, err := client.TagResourceWithContext(ctx, &eventbridge.TagResourceInput{
+		ResourceARN: &arn,
+		Tags:        eventbridgeTagList(tags),
+	})
+
+	return err
+}
+
+// deleteEventBridgeRule removes the targets of the rule, which can't be deleted while it has any, then deletes it.
+func (a *action) deleteEventBridgeRule(ctx context.Context, rule *eventbridge.Rule, client *eventbridge.EventBridge) error {
+	name := aws.StringValue(rule.Name)
+	a.logger.Info("Deleting EventBridge rule %s of event bus %s", name, aws.StringValue(rule.EventBusName))
+
+	params := &eventbridge.ListTargetsByRuleInput{Rule: rule.Name, EventBusName: rule.EventBusName}
+	for {
+		out, err := client.ListTargetsByRuleWithContext(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to list targets of eventbridge rule %s: %w", name, err)
+		}
+
+		ids := []*string{}
+		for _, target := range out.Targets {
+			ids = append(ids, target.Id)
+		}
+		if len(ids) > 0 {
+			a.logger.Debug("Removing %d targets of eventbridge rule %s", len(ids), name)
+			removeOut, err := client.RemoveTargetsWithContext(ctx, &eventbridge.RemoveTargetsInput{Rule: rule.Name, EventBusName: rule.EventBusName, Ids: ids})
+			if err != nil {
+				return fmt.Errorf("failed to remove targets of eventbridge rule %s: %w", name, err)
+			}
+			if aws.Int64Value(removeOut.FailedEntryCount) > 0 {
+				return fmt.Errorf("failed to remove %d targets of eventbridge rule %s", aws.Int64Value(removeOut.FailedEntryCount), name)
+			}
+		}
+
+		if out.NextToken == nil {
+			break
+		}
+		params.NextToken = out.NextToken
+	}
+
+	if _, err := client.DeleteRuleWithContext(ctx, &eventbridge.DeleteRuleInput{Name: rule.Name, EventBusName: rule.EventBusName}); err != nil {
+		return fmt.Errorf("failed to delete eventbridge rule %s: %w", name, err)
+	}
+
+	return nil
+}
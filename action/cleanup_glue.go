@@ -0,0 +1,168 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/glue"
+)
+
+// glueResource is a glue job, crawler or database. Glue has no common api for them but
+// they're all tagged by arn.
+type glueResource struct {
+	// Kind is "job", "crawler" or "database", as used in their arn and the logs.
+	Kind      string
+	Name      string
+	CreatedAt time.Time
+}
+
+func (r glueResource) resourceType() string {
+	return "glue " + r.Kind
+}
+
+// cleanGlueResources cleans up the glue jobs, crawlers and databases, in this order so the
+// crawlers are gone before the databases they write to.
+func (a *action) cleanGlueResources(ctx context.Context, input *CleanupScope) error {
+	client := glue.New(input.Session)
+
+	resources, err := a.getGlueResources(ctx, input, client)
+	if err != nil {
+		return err
+	}
+
+	resourcesToDelete := []glueResource{}
+	for _, res := range resources {
+		resourceType := res.resourceType()
+		resourceArn := regionalARN(input, glue.ServiceName, res.Kind+"/"+res.Name)
+
+		tagOut, err := client.GetTagsWithContext(ctx, &glue.GetTagsInput{ResourceArn: &resourceArn})
+		if err != nil {
+			a.logger.Error("failed getting tags for %s %s: %s", resourceType, res.Name, err.Error())
+			continue
+		}
+
+		switch input.evaluate(resource{Type: resourceType, ID: res.Name, ARN: resourceArn, Tags: glueTags(tagOut.Tags), CreatedAt: res.CreatedAt}) {
+		case verdictSkip:
+			continue
+		case verdictMark:
+			a.markForDeletion(input, resourceType, res.Name, func() error {
+				return a.markGlueResourceForFutureDeletion(ctx, resourceArn, client)
+			})
+			continue
+		}
+
+		a.logger.Debug("adding %s %s to delete list", resourceType, res.Name)
+		resourcesToDelete = append(resourcesToDelete, res)
+	}
+
+	if len(resourcesToDelete) == 0 {
+		a.logger.Info("no glue resources to delete")
+		return nil
+	}
+
+	for _, res := range resourcesToDelete {
+		resourceType := res.resourceType()
+		if !a.canDelete(input, resourceType, res.Name) {
+			continue
+		}
+
+		if err := a.deleteGlueResource(ctx, res, client); err != nil {
+			if isAlreadyDeleted(a.logger, err, resourceType, res.Name) {
+				input.Report.skipped(input.Region, resourceType, res.Name, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete %s %s: %s", resourceType, res.Name, err.Error())
+			input.Report.failed(input.Region, resourceType, res.Name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, resourceType, res.Name)
+	}
+
+	return nil
+}
+
+// getGlueResources returns the jobs, crawlers and databases whose name starts with the glue prefix.
+// The running crawlers and the default database are left out.
+func (a *action) getGlueResources(ctx context.Context, input *CleanupScope, client *glue.Glue) ([]glueResource, error) {
+	resources := []glueResource{}
+	add := func(res glueResource) {
+		if !strings.HasPrefix(res.Name, input.GluePrefix) {
+			a.logger.Debug("%s %s doesn't match prefix %s, skipping cleanup", res.resourceType(), res.Name, input.GluePrefix)
+			return
+		}
+		resources = append(resources, res)
+	}
+
+	if err := client.GetJobsPagesWithContext(ctx, &glue.GetJobsInput{}, func(page *glue.GetJobsOutput, _ bool) bool {
+		for _, job := range page.Jobs {
+			add(glueResource{Kind: "job", Name: aws.StringValue(job.Name), CreatedAt: aws.TimeValue(job.CreatedOn)})
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed getting list of glue jobs: %w", err)
+	}
+
+	if err := client.GetCrawlersPagesWithContext(ctx, &glue.GetCrawlersInput{}, func(page *glue.GetCrawlersOutput, _ bool) bool {
+		for _, crawler := range page.Crawlers {
+			name := aws.StringValue(crawler.Name)
+			if state := aws.StringValue(crawler.State); state != glue.CrawlerStateReady {
+				a.logger.Debug("glue crawler %s is %s, skipping cleanup", name, strings.ToLower(state))
+				input.Report.skipped(input.Region, "glue crawler", name, "crawler is "+strings.ToLower(state))
+				continue
+			}
+			add(glueResource{Kind: "crawler", Name: name, CreatedAt: aws.TimeValue(crawler.CreationTime)})
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed getting list of glue crawlers: %w", err)
+	}
+
+	if err := client.GetDatabasesPagesWithContext(ctx, &glue.GetDatabasesInput{}, func(page *glue.GetDatabasesOutput, _ bool) bool {
+		for _, database := range page.DatabaseList {
+			name := aws.StringValue(database.Name)
+			if name == "default" {
+				a.logger.Debug("glue database %s is the default one, skipping cleanup", name)
+				continue
+			}
+			add(glueResource{Kind: "database", Name: name, CreatedAt: aws.TimeValue(database.CreateTime)})
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed getting list of glue databases: %w", err)
+	}
+
+	return resources, nil
+}
+
+func (a *action) markGlueResourceForFutureDeletion(ctx context.Context, arn string, client *glue.Glue) error {
+	a.logger.Info("Marking Glue resource %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &glue.TagResourceInput{
+		ResourceArn: &arn,
+		TagsToAdd:   aws.StringMap(a.markTags()),
+	})
+
+	return err
+}
+
+func (a *action) deleteGlueResource(ctx context.Context, res glueResource, client *glue.Glue) error {
+	a.logger.Info("Deleting Glue %s %s", res.Kind, res.Name)
+
+	var err error
+	switch res.Kind {
+	case "job":
+		_, err = client.DeleteJobWithContext(ctx, &glue.DeleteJobInput{JobName: &res.Name})
+	case "crawler":
+		_, err = client.DeleteCrawlerWithContext(ctx, &glue.DeleteCrawlerInput{Name: &res.Name})
+	case "database":
+		// the tables and partitions of the database are deleted along with it.
+		_, err = client.DeleteDatabaseWithContext(ctx, &glue.DeleteDatabaseInput{Name: &res.Name})
+	default:
+		err = fmt.Errorf("unknown glue resource kind %s", res.Kind)
+	}
+
+	return err
+}
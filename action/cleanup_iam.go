@@ -0,0 +1,159 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+func (a *action) cleanIAMRoles(ctx context.Context, input *CleanupScope) error {
+	client := iam.New(input.Session)
+
+	rolesToDelete := []*string{}
+	pageFunc := func(page *iam.ListRolesOutput, _ bool) bool {
+		for _, role := range page.Roles {
+			// service-linked roles are managed by aws and can only be deleted by the service.
+			if strings.HasPrefix(aws.StringValue(role.Path), "/aws-service-role/") {
+				a.logger.Debug("iam role %s is a service-linked role, skipping cleanup", *role.RoleName)
+				continue
+			}
+
+			tags, err := a.getRoleTags(ctx, *role.RoleName, client)
+			if err != nil {
+				a.logger.Error("failed getting tags for iam role %s: %s", *role.RoleName, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "iam role", ID: *role.RoleName, ARN: aws.StringValue(role.Arn), Tags: tags, CreatedAt: aws.TimeValue(role.CreateDate)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "iam role", *role.RoleName, func() error {
+					return a.markRoleForFutureDeletion(ctx, *role.RoleName, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding iam role %s to delete list", *role.RoleName)
+			rolesToDelete = append(rolesToDelete, role.RoleName)
+		}
+
+		return true
+	}
+
+	if err := client.ListRolesPagesWithContext(ctx, &iam.ListRolesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of iam roles: %w", err)
+	}
+
+	if len(rolesToDelete) == 0 {
+		a.logger.Info("no iam roles to delete")
+		return nil
+	}
+
+	for _, roleName := range rolesToDelete {
+		if !a.canDelete(input, "iam role", *roleName) {
+			continue
+		}
+
+		if err := a.deleteRole(ctx, *roleName, client); err != nil {
+			a.logger.Error("failed to delete iam role %s: %s", *roleName, err.Error())
+			input.Report.failed(input.Region, "iam role", *roleName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "iam role", *roleName)
+	}
+
+	return nil
+}
+
+func (a *action) getRoleTags(ctx context.Context, roleName string, client *iam.IAM) (Tags, error) {
+	tags := Tags{}
+	pageFunc := func(page *iam.ListRoleTagsOutput, _ bool) bool {
+		for _, tag := range page.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+
+		return true
+	}
+
+	if err := client.ListRoleTagsPagesWithContext(ctx, &iam.ListRoleTagsInput{RoleName: &roleName}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (a *action) markRoleForFutureDeletion(ctx context.Context, roleName string, client *iam.IAM) error {
+	a.logger.Info("Marking IAM role %s for future deletion", roleName)
+
+	_, err := client.TagRoleWithContext(ctx, &iam.TagRoleInput{
+		RoleName: &roleName,
+		Tags:     iamTagList(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteRole removes everything attached to the role, which would otherwise make
+// the deletion fail, then deletes it.
+func (a *action) deleteRole(ctx context.Context, roleName string, client *iam.IAM) error {
+	a.logger.Info("Deleting IAM role %s", roleName)
+
+	policyArns := []*string{}
+	if err := client.ListAttachedRolePoliciesPagesWithContext(ctx, &iam.ListAttachedRolePoliciesInput{RoleName: &roleName}, func(page *iam.ListAttachedRolePoliciesOutput, _ bool) bool {
+		for _, policy := range page.AttachedPolicies {
+			policyArns = append(policyArns, policy.PolicyArn)
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list attached policies: %w", err)
+	}
+
+	for _, policyArn := range policyArns {
+		a.logger.Debug("Detaching policy %s from iam role %s", *policyArn, roleName)
+		if _, err := client.DetachRolePolicyWithContext(ctx, &iam.DetachRolePolicyInput{RoleName: &roleName, PolicyArn: policyArn}); err != nil {
+			return fmt.Errorf("failed to detach policy %s: %w", *policyArn, err)
+		}
+	}
+
+	policyNames := []*string{}
+	if err := client.ListRolePoliciesPagesWithContext(ctx, &iam.ListRolePoliciesInput{RoleName: &roleName}, func(page *iam.ListRolePoliciesOutput, _ bool) bool {
+		policyNames = append(policyNames, page.PolicyNames...)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list inline policies: %w", err)
+	}
+
+	for _, policyName := range policyNames {
+		a.logger.Debug("Deleting inline policy %s of iam role %s", *policyName, roleName)
+		if _, err := client.DeleteRolePolicyWithContext(ctx, &iam.DeleteRolePolicyInput{RoleName: &roleName, PolicyName: policyName}); err != nil {
+			return fmt.Errorf("failed to delete inline policy %s: %w", *policyName, err)
+		}
+	}
+
+	profileNames := []*string{}
+	if err := client.ListInstanceProfilesForRolePagesWithContext(ctx, &iam.ListInstanceProfilesForRoleInput{RoleName: &roleName}, func(page *iam.ListInstanceProfilesForRoleOutput, _ bool) bool {
+		for _, profile := range page.InstanceProfiles {
+			profileNames = append(profileNames, profile.InstanceProfileName)
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list instance profiles: %w", err)
+	}
+
+	for _, profileName := range profileNames {
+		a.logger.Debug("Removing iam role %s from instance profile %s", roleName, *profileName)
+		if _, err := client.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{RoleName: &roleName, InstanceProfileName: profileName}); err != nil {
+			return fmt.Errorf("failed to remove role from instance profile %s: %w", *profileName, err)
+		}
+	}
+
+	if _, err := client.DeleteRoleWithContext(ctx, &iam.DeleteRoleInput{RoleName: &roleName}); err != nil {
+		return fmt.Errorf("failed to delete iam role %s: %w", roleName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanImages(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	imagesInUse, err := a.getImagesInUse(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed getting images in use: %w", err)
+	}
+
+	imagesToDelete := []*ec2.Image{}
+	pageFunc := func(page *ec2.DescribeImagesOutput, _ bool) bool {
+		for _, image := range page.Images {
+			if usedBy, ok := imagesInUse[*image.ImageId]; ok {
+				a.logger.Debug("image %s (%s) is used by %s, skipping cleanup", *image.ImageId, aws.StringValue(image.Name), usedBy)
+				input.Report.skipped(input.Region, "image", *image.ImageId, "used by "+usedBy)
+				continue
+			}
+
+			// a creation date that can't be parsed is left as zero, falling back to the deletion tag.
+			createdAt, _ := time.Parse(time.RFC3339, aws.StringValue(image.CreationDate))
+			switch input.evaluate(resource{Type: "image", ID: *image.ImageId, Tags: ec2Tags(image.Tags), CreatedAt: createdAt}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "image", *image.ImageId, func() error {
+					return a.markImageForFutureDeletion(ctx, *image.ImageId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding image %s (%s) to delete list", *image.ImageId, aws.StringValue(image.Name))
+			imagesToDelete = append(imagesToDelete, image)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{Owners: []*string{aws.String("self")}}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of images: %w", err)
+	}
+
+	if len(imagesToDelete) == 0 {
+		a.logger.Info("no images to delete")
+		return nil
+	}
+
+	for _, image := range imagesToDelete {
+		if !a.canDelete(input, "image", *image.ImageId) {
+			continue
+		}
+
+		a.logger.Info("Deregistering Image %s (name %s, created %s)", *image.ImageId, aws.StringValue(image.Name), aws.StringValue(image.CreationDate))
+		if _, err := client.DeregisterImageWithContext(ctx, &ec2.DeregisterImageInput{ImageId: image.ImageId}); err != nil {
+			a.logger.Error("failed to deregister image %s: %s", *image.ImageId, err.Error())
+			input.Report.failed(input.Region, "image", *image.ImageId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "image", *image.ImageId)
+	}
+
+	return nil
+}
+
+// getImagesInUse returns the ids of the images referenced by existing instances or
+// by the default and latest versions of launch templates, mapped to who is using them.
+func (a *action) getImagesInUse(ctx context.Context, client *ec2.EC2) (map[string]string, error) {
+	images := map[string]string{}
+
+	instancesPageFunc := func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				images[aws.StringValue(instance.ImageId)] = fmt.Sprintf("instance %s", aws.StringValue(instance.InstanceId))
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{
+				ec2.InstanceStateNamePending,
+				ec2.InstanceStateNameRunning,
+				ec2.InstanceStateNameStopping,
+				ec2.InstanceStateNameStopped,
+			})},
+		},
+	}, instancesPageFunc); err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+
+	templatesPageFunc := func(page *ec2.DescribeLaunchTemplateVersionsOutput, _ bool) bool {
+		for _, version := range page.LaunchTemplateVersions {
+			if version.LaunchTemplateData == nil || version.LaunchTemplateData.ImageId == nil {
+				continue
+			}
+			images[*version.LaunchTemplateData.ImageId] = fmt.Sprintf("launch template %s", aws.StringValue(version.LaunchTemplateName))
+		}
+
+		return true
+	}
+
+	if err := client.DescribeLaunchTemplateVersionsPagesWithContext(ctx, &ec2.DescribeLaunchTemplateVersionsInput{
+		Versions: aws.StringSlice([]string{"$Latest", "$Default"}),
+	}, templatesPageFunc); err != nil {
+		return nil, fmt.Errorf("failed to describe launch template versions: %w", err)
+	}
+
+	return images, nil
+}
+
+func (a *action) markImageForFutureDeletion(ctx context.Context, imageId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Image %s for future deletion", imageId)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&imageId},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
@@ -0,0 +1,173 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// cleanInstanceProfiles cleans up the instance profiles, which are often left behind by the deletion
+// of their roles, unless an instance of the regions being cleaned up still uses them.
+func (a *action) cleanInstanceProfiles(ctx context.Context, input *CleanupScope) error {
+	client := iam.New(input.Session)
+
+	profilesInUse, err := a.getInstanceProfilesInUse(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to get instance profiles in use: %w", err)
+	}
+
+	profilesToDelete := []*iam.InstanceProfile{}
+	pageFunc := func(page *iam.ListInstanceProfilesOutput, _ bool) bool {
+		for _, profile := range page.InstanceProfiles {
+			name := aws.StringValue(profile.InstanceProfileName)
+
+			if instanceId, ok := profilesInUse[aws.StringValue(profile.Arn)]; ok {
+				a.logger.Debug("instance profile %s is used by instance %s, skipping cleanup", name, instanceId)
+				input.Report.skipped(input.Region, "instance profile", name, "used by instance "+instanceId)
+				continue
+			}
+
+			tags, err := a.getInstanceProfileTags(ctx, name, client)
+			if err != nil {
+				a.logger.Error("failed getting tags for instance profile %s: %s", name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "instance profile", ID: name, ARN: aws.StringValue(profile.Arn), Tags: tags, CreatedAt: aws.TimeValue(profile.CreateDate)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "instance profile", name, func() error {
+					return a.markInstanceProfileForFutureDeletion(ctx, name, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding instance profile %s to delete list", name)
+			profilesToDelete = append(profilesToDelete, profile)
+		}
+
+		return true
+	}
+
+	if err := client.ListInstanceProfilesPagesWithContext(ctx, &iam.ListInstanceProfilesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of instance profiles: %w", err)
+	}
+
+	if len(profilesToDelete) == 0 {
+		a.logger.Info("no instance profiles to delete")
+		return nil
+	}
+
+	for _, profile := range profilesToDelete {
+		name := aws.StringValue(profile.InstanceProfileName)
+		if !a.canDelete(input, "instance profile", name) {
+			continue
+		}
+
+		if err := a.deleteInstanceProfile(ctx, profile, client); err != nil {
+			if isAlreadyDeleted(a.logger, err, "instance profile", name) {
+				input.Report.skipped(input.Region, "instance profile", name, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete instance profile %s: %s", name, err.Error())
+			input.Report.failed(input.Region, "instance profile", name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "instance profile", name)
+	}
+
+	return nil
+}
+
+// getInstanceProfilesInUse returns the arns of the instance profiles used by the instances of all the
+// regions enabled in the account, along with the id of one of these instances.
+func (a *action) getInstanceProfilesInUse(ctx context.Context, input *CleanupScope) (map[string]string, error) {
+	profilesInUse := map[string]string{}
+	pageFunc := func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.IamInstanceProfile != nil {
+					profilesInUse[aws.StringValue(instance.IamInstanceProfile.Arn)] = aws.StringValue(instance.InstanceId)
+				}
+			}
+		}
+
+		return true
+	}
+
+	// instance profiles are global, while the instances using them are spread across the regions,
+	// including the ones that aren't cleaned up.
+	regionsOut, err := ec2.New(input.Session).DescribeRegionsWithContext(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabled regions: %w", err)
+	}
+
+	for _, enabledRegion := range regionsOut.Regions {
+		region := aws.StringValue(enabledRegion.RegionName)
+		client := ec2.New(input.Session.Copy(&aws.Config{Region: aws.String(region)}))
+		if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{
+					ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, ec2.InstanceStateNameShuttingDown,
+					ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped,
+				})},
+			},
+		}, pageFunc); err != nil {
+			return nil, fmt.Errorf("failed to get instances in region %s: %w", region, err)
+		}
+	}
+
+	return profilesInUse, nil
+}
+
+func (a *action) getInstanceProfileTags(ctx context.Context, profileName string, client *iam.IAM) (Tags, error) {
+	tags := Tags{}
+	pageFunc := func(page *iam.ListInstanceProfileTagsOutput, _ bool) bool {
+		for _, tag := range page.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+
+		return true
+	}
+
+	if err := client.ListInstanceProfileTagsPagesWithContext(ctx, &iam.ListInstanceProfileTagsInput{InstanceProfileName: &profileName}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (a *action) markInstanceProfileForFutureDeletion(ctx context.Context, profileName string, client *iam.IAM) error {
+	a.logger.Info("Marking instance profile %s for future deletion", profileName)
+
+	_, err := client.TagInstanceProfileWithContext(ctx, &iam.TagInstanceProfileInput{
+		InstanceProfileName: &profileName,
+		Tags:                iamTagList(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteInstanceProfile removes the roles from the instance profile, which can't be deleted
+// while it has any, then deletes it.
+func (a *action) deleteInstanceProfile(ctx context.Context, profile *iam.InstanceProfile, client *iam.IAM) error {
+	a.logger.Info("Deleting instance profile %s", aws.StringValue(profile.InstanceProfileName))
+
+	for _, role := range profile.Roles {
+		a.logger.Debug("Removing iam role %s from instance profile %s", aws.StringValue(role.RoleName), aws.StringValue(profile.InstanceProfileName))
+		if _, err := client.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{
+			InstanceProfileName: profile.InstanceProfileName,
+			RoleName:            role.RoleName,
+		}); err != nil && !isNotFoundError(err) {
+			return fmt.Errorf("failed to remove iam role %s from instance profile: %w", aws.StringValue(role.RoleName), err)
+		}
+	}
+
+	_, err := client.DeleteInstanceProfileWithContext(ctx, &iam.DeleteInstanceProfileInput{InstanceProfileName: profile.InstanceProfileName})
+
+	return err
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanInstances(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	instancesToDelete := []*string{}
+	pageFunc := func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				tags := ec2Tags(instance.Tags)
+				if isManagedByCloudFormation(tags) {
+					a.logger.Debug("instance %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", *instance.InstanceId)
+					input.Report.managedByStack(input.Region, "instance", *instance.InstanceId, cloudFormationStack(tags))
+					continue
+				}
+
+				switch input.evaluate(resource{Type: "instance", ID: *instance.InstanceId, Tags: tags, CreatedAt: aws.TimeValue(instance.LaunchTime)}) {
+				case verdictSkip:
+					continue
+				case verdictMark:
+					a.markForDeletion(input, "instance", *instance.InstanceId, func() error {
+						return a.markInstanceForFutureDeletion(ctx, *instance.InstanceId, client)
+					})
+					continue
+				}
+
+				a.logger.Debug("adding instance %s to delete list", *instance.InstanceId)
+				instancesToDelete = append(instancesToDelete, instance.InstanceId)
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("instance-state-name"), Values: []*string{aws.String(ec2.InstanceStateNameStopped)}},
+		},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of instances: %w", err)
+	}
+
+	if len(instancesToDelete) == 0 {
+		a.logger.Info("no stopped instances to delete")
+		return nil
+	}
+
+	terminatedIds := []*string{}
+	for _, instanceId := range instancesToDelete {
+		if !a.canDelete(input, "instance", *instanceId) {
+			continue
+		}
+
+		a.logger.Info("Terminating Instance %s", *instanceId)
+		if _, err := client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{InstanceIds: []*string{instanceId}}); err != nil {
+			a.logger.Error("failed to terminate instance %s: %s", *instanceId, err.Error())
+			input.Report.failed(input.Region, "instance", *instanceId, err.Error())
+			continue
+		}
+
+		input.Report.deleted(input.Region, "instance", *instanceId)
+		terminatedIds = append(terminatedIds, instanceId)
+	}
+
+	if len(terminatedIds) > 0 {
+		if err := client.WaitUntilInstanceTerminatedWithContext(ctx, &ec2.DescribeInstancesInput{InstanceIds: terminatedIds}); err != nil {
+			a.logger.Error("failed to wait for instances to be terminated: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) markInstanceForFutureDeletion(ctx context.Context, instanceId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Instance %s for future deletion", instanceId)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&instanceId},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
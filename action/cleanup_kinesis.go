@@ -0,0 +1,152 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+func (a *action) cleanKinesisStreams(ctx context.Context, input *CleanupScope) error {
+	client := kinesis.New(input.Session)
+
+	streamsToDelete := []*kinesis.StreamDescriptionSummary{}
+	pageFunc := func(page *kinesis.ListStreamsOutput, _ bool) bool {
+		for _, stream := range page.StreamSummaries {
+			name := aws.StringValue(stream.StreamName)
+
+			switch aws.StringValue(stream.StreamStatus) {
+			case kinesis.StreamStatusCreating:
+				a.logger.Debug("kinesis stream %s is being created, skipping cleanup", name)
+				input.Report.skipped(input.Region, "kinesis stream", name, "stream is "+aws.StringValue(stream.StreamStatus))
+				continue
+			case kinesis.StreamStatusDeleting:
+				a.logger.Debug("kinesis stream %s is already being deleted, skipping cleanup", name)
+				continue
+			}
+
+			tags, err := a.getStreamTags(ctx, stream.StreamARN, client)
+			if err != nil {
+				a.logger.Error("failed getting tags for kinesis stream %s: %s", name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "kinesis stream", ID: name, ARN: aws.StringValue(stream.StreamARN), Tags: tags, CreatedAt: aws.TimeValue(stream.StreamCreationTimestamp)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "kinesis stream", name, func() error {
+					return a.markStreamForFutureDeletion(ctx, stream.StreamARN, client)
+				})
+				continue
+			}
+
+			// the summary holds the shard and consumer counts, which give an idea of what the stream costs.
+			out, err := client.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{StreamARN: stream.StreamARN})
+			if err != nil {
+				a.logger.Warn("failed getting kinesis stream %s: %s", name, err.Error())
+				continue
+			}
+
+			a.logger.Debug("adding kinesis stream %s to delete list", name)
+			streamsToDelete = append(streamsToDelete, out.StreamDescriptionSummary)
+		}
+
+		return true
+	}
+
+	if err := client.ListStreamsPagesWithContext(ctx, &kinesis.ListStreamsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of kinesis streams: %w", err)
+	}
+
+	if len(streamsToDelete) == 0 {
+		a.logger.Info("no kinesis streams to delete")
+		return nil
+	}
+
+	for _, stream := range streamsToDelete {
+		name := aws.StringValue(stream.StreamName)
+		if !a.canDelete(input, "kinesis stream", name) {
+			continue
+		}
+
+		if err := a.deleteStream(ctx, stream, client); err != nil {
+			a.logger.Error("failed to delete kinesis stream %s: %s", name, err.Error())
+			input.Report.failed(input.Region, "kinesis stream", name, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "kinesis stream", name)
+	}
+
+	return nil
+}
+
+func (a *action) getStreamTags(ctx context.Context, arn *string, client *kinesis.Kinesis) (Tags, error) {
+	tags := Tags{}
+	params := &kinesis.ListTagsForStreamInput{StreamARN: arn}
+	for {
+		out, err := client.ListTagsForStreamWithContext(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range kinesisTags(out.Tags) {
+			tags[key] = value
+		}
+
+		if !aws.BoolValue(out.HasMoreTags) || len(out.Tags) == 0 {
+			return tags, nil
+		}
+		params.ExclusiveStartTagKey = out.Tags[len(out.Tags)-1].Key
+	}
+}
+
+func (a *action) markStreamForFutureDeletion(ctx context.Context, arn *string, client *kinesis.Kinesis) error {
+	a.logger.Info("Marking Kinesis stream %s for future deletion", aws.StringValue(arn))
+
+	_, err := client.AddTagsToStreamWithContext(ctx, &kinesis.AddTagsToStreamInput{
+		StreamARN: arn,
+		Tags:      aws.StringMap(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteStream deregisters the enhanced fan-out consumers of the stream and deletes it.
+func (a *action) deleteStream(ctx context.Context, stream *kinesis.StreamDescriptionSummary, client *kinesis.Kinesis) error {
+	name := aws.StringValue(stream.StreamName)
+	a.logger.Info("Deleting Kinesis stream %s (%d open shards, %d consumers)", name, aws.Int64Value(stream.OpenShardCount), aws.Int64Value(stream.ConsumerCount))
+
+	consumers := []*kinesis.Consumer{}
+	if err := client.ListStreamConsumersPagesWithContext(ctx, &kinesis.ListStreamConsumersInput{StreamARN: stream.StreamARN}, func(page *kinesis.ListStreamConsumersOutput, _ bool) bool {
+		consumers = append(consumers, page.Consumers...)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to list consumers of kinesis stream %s: %w", name, err)
+	}
+
+	for _, consumer := range consumers {
+		if aws.StringValue(consumer.ConsumerStatus) == kinesis.ConsumerStatusDeleting {
+			continue
+		}
+		a.logger.Debug("Deregistering consumer %s of kinesis stream %s", aws.StringValue(consumer.ConsumerName), name)
+		if _, err := client.DeregisterStreamConsumerWithContext(ctx, &kinesis.DeregisterStreamConsumerInput{ConsumerARN: consumer.ConsumerARN}); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kinesis.ErrCodeResourceNotFoundException {
+				continue
+			}
+			return fmt.Errorf("failed to deregister consumer %s of kinesis stream %s: %w", aws.StringValue(consumer.ConsumerName), name, err)
+		}
+	}
+
+	// the consumers are deregistered asynchronously, so the ones still being deregistered
+	// mustn't prevent the deletion.
+	if _, err := client.DeleteStreamWithContext(ctx, &kinesis.DeleteStreamInput{
+		StreamARN:               stream.StreamARN,
+		EnforceConsumerDeletion: aws.Bool(len(consumers) > 0),
+	}); err != nil {
+		return fmt.Errorf("failed to delete kinesis stream %s: %w", name, err)
+	}
+
+	return nil
+}
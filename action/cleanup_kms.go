@@ -0,0 +1,120 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+func (a *action) cleanKMSKeys(ctx context.Context, input *CleanupScope) error {
+	client := kms.New(input.Session)
+
+	keysToDelete := []*string{}
+	pageFunc := func(page *kms.ListKeysOutput, _ bool) bool {
+		for _, key := range page.Keys {
+			out, err := client.DescribeKeyWithContext(ctx, &kms.DescribeKeyInput{KeyId: key.KeyId})
+			if err != nil {
+				a.logger.Warn("failed getting kms key %s: %s", *key.KeyId, err.Error())
+				continue
+			}
+			metadata := out.KeyMetadata
+
+			// aws managed keys can't be deleted.
+			if aws.StringValue(metadata.KeyManager) == kms.KeyManagerTypeAws {
+				continue
+			}
+
+			switch aws.StringValue(metadata.KeyState) {
+			case kms.KeyStateEnabled, kms.KeyStateDisabled:
+			case kms.KeyStatePendingDeletion, kms.KeyStatePendingReplicaDeletion:
+				a.logger.Debug("kms key %s is already pending deletion, skipping cleanup", *key.KeyId)
+				continue
+			default:
+				a.logger.Debug("kms key %s is %s, skipping cleanup", *key.KeyId, aws.StringValue(metadata.KeyState))
+				input.Report.skipped(input.Region, "kms key", *key.KeyId, "key is "+aws.StringValue(metadata.KeyState))
+				continue
+			}
+
+			tags, err := a.getKeyTags(ctx, *key.KeyId, client)
+			if err != nil {
+				a.logger.Error("failed getting tags for kms key %s: %s", *key.KeyId, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "kms key", ID: *key.KeyId, ARN: aws.StringValue(key.KeyArn), Tags: tags, CreatedAt: aws.TimeValue(metadata.CreationDate)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "kms key", *key.KeyId, func() error {
+					return a.markKeyForFutureDeletion(ctx, *key.KeyId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding kms key %s to delete list", *key.KeyId)
+			keysToDelete = append(keysToDelete, key.KeyId)
+		}
+
+		return true
+	}
+
+	if err := client.ListKeysPagesWithContext(ctx, &kms.ListKeysInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of kms keys: %w", err)
+	}
+
+	if len(keysToDelete) == 0 {
+		a.logger.Info("no kms keys to delete")
+		return nil
+	}
+
+	for _, keyId := range keysToDelete {
+		if !a.canDelete(input, "kms key", *keyId) {
+			continue
+		}
+
+		// kms keys can't be deleted right away, they're deleted once the pending window is over.
+		a.logger.Info("Scheduling deletion of KMS key %s in %d days", *keyId, input.KMSPendingWindow)
+		out, err := client.ScheduleKeyDeletionWithContext(ctx, &kms.ScheduleKeyDeletionInput{
+			KeyId:               keyId,
+			PendingWindowInDays: aws.Int64(input.KMSPendingWindow),
+		})
+		if err != nil {
+			a.logger.Error("failed to schedule deletion of kms key %s: %s", *keyId, err.Error())
+			input.Report.failed(input.Region, "kms key", *keyId, err.Error())
+			continue
+		}
+		input.Report.scheduled(input.Region, "kms key", *keyId, fmt.Sprintf("deleted on %s", aws.TimeValue(out.DeletionDate).UTC().Format("2006-01-02")))
+	}
+
+	return nil
+}
+
+func (a *action) getKeyTags(ctx context.Context, keyId string, client *kms.KMS) (Tags, error) {
+	tags := Tags{}
+	pageFunc := func(page *kms.ListResourceTagsOutput, _ bool) bool {
+		for _, tag := range page.Tags {
+			tags[aws.StringValue(tag.TagKey)] = aws.StringValue(tag.TagValue)
+		}
+
+		return true
+	}
+
+	if err := client.ListResourceTagsPagesWithContext(ctx, &kms.ListResourceTagsInput{KeyId: &keyId}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (a *action) markKeyForFutureDeletion(ctx context.Context, keyId string, client *kms.KMS) error {
+	a.logger.Info("Marking KMS key %s for future deletion", keyId)
+
+	_, err := client.TagResourceWithContext(ctx, &kms.TagResourceInput{
+		KeyId: &keyId,
+		Tags:  kmsTagList(a.markTags()),
+	})
+
+	return err
+}
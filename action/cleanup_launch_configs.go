@@ -0,0 +1,79 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func (a *action) cleanLaunchConfigurations(ctx context.Context, input *CleanupScope) error {
+	client := autoscaling.New(input.Session)
+
+	configsInUse := map[string]string{}
+	asgPageFunc := func(page *autoscaling.DescribeAutoScalingGroupsOutput, _ bool) bool {
+		for _, asg := range page.AutoScalingGroups {
+			if asg.LaunchConfigurationName != nil {
+				configsInUse[*asg.LaunchConfigurationName] = aws.StringValue(asg.AutoScalingGroupName)
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeAutoScalingGroupsPagesWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{MaxRecords: aws.Int64(100)}, asgPageFunc); err != nil {
+		return fmt.Errorf("failed to get asgs: %w", err)
+	}
+
+	configsToDelete := []*string{}
+	pageFunc := func(page *autoscaling.DescribeLaunchConfigurationsOutput, _ bool) bool {
+		for _, config := range page.LaunchConfigurations {
+			if asgName, ok := configsInUse[*config.LaunchConfigurationName]; ok {
+				a.logger.Debug("launch configuration %s is used by asg %s, skipping cleanup", *config.LaunchConfigurationName, asgName)
+				input.Report.skipped(input.Region, "launch configuration", *config.LaunchConfigurationName, "used by asg "+asgName)
+				continue
+			}
+
+			// launch configurations can't be tagged, so unused ones are considered as
+			// marked for deletion since they were created. This way the grace period and the
+			// min age still apply, but ignore tags can't protect them.
+			createdAt := aws.TimeValue(config.CreatedTime)
+			tags := Tags{input.DeletionTag: createdAt.UTC().Format(time.RFC3339)}
+			if input.evaluate(resource{Type: "launch configuration", ID: *config.LaunchConfigurationName, Tags: tags, CreatedAt: createdAt}) != verdictDelete {
+				continue
+			}
+
+			a.logger.Debug("adding launch configuration %s to delete list", *config.LaunchConfigurationName)
+			configsToDelete = append(configsToDelete, config.LaunchConfigurationName)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeLaunchConfigurationsPagesWithContext(ctx, &autoscaling.DescribeLaunchConfigurationsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of launch configurations: %w", err)
+	}
+
+	if len(configsToDelete) == 0 {
+		a.logger.Info("no unused launch configurations to delete")
+		return nil
+	}
+
+	for _, configName := range configsToDelete {
+		if !a.canDelete(input, "launch configuration", *configName) {
+			continue
+		}
+
+		a.logger.Info("Deleting Launch Configuration %s", *configName)
+		if _, err := client.DeleteLaunchConfigurationWithContext(ctx, &autoscaling.DeleteLaunchConfigurationInput{LaunchConfigurationName: configName}); err != nil {
+			a.logger.Error("failed to delete launch configuration %s: %s", *configName, err.Error())
+			input.Report.failed(input.Region, "launch configuration", *configName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "launch configuration", *configName)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanLaunchTemplates(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	templatesInUse, err := a.getLaunchTemplatesInUse(ctx, client, autoscaling.New(input.Session))
+	if err != nil {
+		return fmt.Errorf("failed getting launch templates in use: %w", err)
+	}
+
+	templatesToDelete := []*ec2.LaunchTemplate{}
+	pageFunc := func(page *ec2.DescribeLaunchTemplatesOutput, _ bool) bool {
+		for _, template := range page.LaunchTemplates {
+			if usedBy, ok := templatesInUse[*template.LaunchTemplateId]; ok {
+				a.logger.Debug("launch template %s (%s) is used by %s, skipping cleanup", *template.LaunchTemplateId, aws.StringValue(template.LaunchTemplateName), usedBy)
+				input.Report.skipped(input.Region, "launch template", *template.LaunchTemplateId, "used by "+usedBy)
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "launch template", ID: *template.LaunchTemplateId, Tags: ec2Tags(template.Tags), CreatedAt: aws.TimeValue(template.CreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "launch template", *template.LaunchTemplateId, func() error {
+					return a.markLaunchTemplateForFutureDeletion(ctx, *template.LaunchTemplateId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding launch template %s to delete list", *template.LaunchTemplateId)
+			templatesToDelete = append(templatesToDelete, template)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeLaunchTemplatesPagesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of launch templates: %w", err)
+	}
+
+	if len(templatesToDelete) == 0 {
+		a.logger.Info("no launch templates to delete")
+		return nil
+	}
+
+	for _, template := range templatesToDelete {
+		if !a.canDelete(input, "launch template", *template.LaunchTemplateId) {
+			continue
+		}
+
+		a.logger.Info("Deleting Launch Template %s (%s)", *template.LaunchTemplateId, aws.StringValue(template.LaunchTemplateName))
+		if _, err := client.DeleteLaunchTemplateWithContext(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: template.LaunchTemplateId}); err != nil {
+			a.logger.Error("failed to delete launch template %s: %s", *template.LaunchTemplateId, err.Error())
+			input.Report.failed(input.Region, "launch template", *template.LaunchTemplateId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "launch template", *template.LaunchTemplateId)
+	}
+
+	return nil
+}
+
+// getLaunchTemplatesInUse returns the ids of the launch templates referenced by existing
+// autoscaling groups or instances, mapped to who is using them.
+func (a *action) getLaunchTemplatesInUse(ctx context.Context, client *ec2.EC2, asgClient *autoscaling.AutoScaling) (map[string]string, error) {
+	templates := map[string]string{}
+
+	asgPageFunc := func(page *autoscaling.DescribeAutoScalingGroupsOutput, _ bool) bool {
+		for _, asg := range page.AutoScalingGroups {
+			usedBy := fmt.Sprintf("asg %s", aws.StringValue(asg.AutoScalingGroupName))
+			if asg.LaunchTemplate != nil && asg.LaunchTemplate.LaunchTemplateId != nil {
+				templates[*asg.LaunchTemplate.LaunchTemplateId] = usedBy
+			}
+			if asg.MixedInstancesPolicy != nil && asg.MixedInstancesPolicy.LaunchTemplate != nil {
+				spec := asg.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+				if spec != nil && spec.LaunchTemplateId != nil {
+					templates[*spec.LaunchTemplateId] = usedBy
+				}
+			}
+		}
+
+		return true
+	}
+
+	if err := asgClient.DescribeAutoScalingGroupsPagesWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{MaxRecords: aws.Int64(100)}, asgPageFunc); err != nil {
+		return nil, fmt.Errorf("failed to describe asgs: %w", err)
+	}
+
+	// instances launched from a template carry the id of the template in an aws managed tag.
+	instancesPageFunc := func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if templateId, ok := ec2Tags(instance.Tags)["aws:ec2launchtemplate:id"]; ok {
+					templates[templateId] = fmt.Sprintf("instance %s", aws.StringValue(instance.InstanceId))
+				}
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{
+				ec2.InstanceStateNamePending,
+				ec2.InstanceStateNameRunning,
+				ec2.InstanceStateNameStopping,
+				ec2.InstanceStateNameStopped,
+			})},
+		},
+	}, instancesPageFunc); err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (a *action) markLaunchTemplateForFutureDeletion(ctx context.Context, templateId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Launch Template %s for future deletion", templateId)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&templateId},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
@@ -18,38 +18,21 @@ func (a *action) cleanLoadBalancers(ctx context.Context, input *CleanupScope) er
 		for _, lb := range page.LoadBalancerDescriptions {
 			tags, err := client.DescribeTagsWithContext(ctx, &elb.DescribeTagsInput{LoadBalancerNames: []*string{lb.LoadBalancerName}})
 			if err != nil {
-				LogError("failed getting tags for load balancer %s: %s", *lb.LoadBalancerName, err.Error())
-			}
-
-			var ignore, markedForDeletion bool
-			for _, tagDescription := range tags.TagDescriptions {
-				for _, tag := range tagDescription.Tags {
-					switch aws.StringValue(tag.Key) {
-					case input.IgnoreTag:
-						ignore = true
-					case DeletionTag:
-						markedForDeletion = true
-					}
-				}
-			}
-
-			if ignore {
-				LogDebug("load balancer %s has ignore tag, skipping cleanup", *lb.LoadBalancerName)
+				a.logger.Error("failed getting tags for load balancer %s: %s", *lb.LoadBalancerName, err.Error())
 				continue
 			}
 
-			if !markedForDeletion {
-				// NOTE: only mark for future deletion if we're not running in dry-mode
-				if a.commit {
-					LogDebug("load balancer %s does not have deletion tag, marking for future deletion and skipping cleanup", *lb.LoadBalancerName)
-					if err := a.markLoadBalancerForFutureDeletion(ctx, *lb.LoadBalancerName, client); err != nil {
-						LogError("failed to mark load balancer %s for future deletion: %s", *lb.LoadBalancerName, err.Error())
-					}
-				}
+			switch input.evaluate(resource{Type: "load balancer", ID: *lb.LoadBalancerName, Tags: elbTags(tags.TagDescriptions), CreatedAt: aws.TimeValue(lb.CreatedTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "load balancer", *lb.LoadBalancerName, func() error {
+					return a.markLoadBalancerForFutureDeletion(ctx, *lb.LoadBalancerName, client)
+				})
 				continue
 			}
 
-			LogDebug("adding load balancer %s to delete list", *lb.LoadBalancerName)
+			a.logger.Debug("adding load balancer %s to delete list", *lb.LoadBalancerName)
 			loadBalancersToDelete = append(loadBalancersToDelete, lb.LoadBalancerName)
 		}
 
@@ -61,59 +44,61 @@ func (a *action) cleanLoadBalancers(ctx context.Context, input *CleanupScope) er
 	}
 
 	if len(loadBalancersToDelete) == 0 {
-		Log("no load balancer to delete")
+		a.logger.Info("no load balancer to delete")
 		return nil
 	}
 
 	for _, lbName := range loadBalancersToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of load balancer %s as running in dry-mode", *lbName)
+		if !a.canDelete(input, "load balancer", *lbName) {
 			continue
 		}
 
 		if err := a.deleteLoadBalancer(ctx, *lbName, client); err != nil {
-			LogError("failed to delete load balancer %s: %s", *lbName, err.Error())
+			a.logger.Error("failed to delete load balancer %s: %s", *lbName, err.Error())
+			input.Report.failed(input.Region, "load balancer", *lbName, err.Error())
+			continue
 		}
+		input.Report.deleted(input.Region, "load balancer", *lbName)
 	}
 
 	return nil
 }
+
 func (a *action) markLoadBalancerForFutureDeletion(ctx context.Context, lbName string, client *elb.ELB) error {
-	Log("Marking Load Balancer %s for future deletion", lbName)
+	a.logger.Info("Marking Load Balancer %s for future deletion", lbName)
 
 	_, err := client.AddTagsWithContext(ctx, &elb.AddTagsInput{
 		LoadBalancerNames: []*string{&lbName},
-		Tags: []*elb.Tag{
-			{
-				Key:   aws.String(DeletionTag),
-				Value: aws.String("true")},
-		},
+		Tags:              elbTagList(a.markTags()),
 	})
 
 	return err
 }
 
 func (a *action) deleteLoadBalancer(ctx context.Context, lbName string, client *elb.ELB) error {
-	Log("Deleting Load Balancer %s", lbName)
+	a.logger.Info("Deleting Load Balancer %s", lbName)
 
 	if _, err := client.DeleteLoadBalancerWithContext(ctx, &elb.DeleteLoadBalancerInput{LoadBalancerName: &lbName}); err != nil {
 		return fmt.Errorf("failed to delete load balancer %s: %w", lbName, err)
 	}
 
-	if err := waitUntil(ctx, 5*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+	if err := waitUntilState(ctx, 5*time.Minute, 10*time.Second, func(ctx context.Context) (bool, string, error) {
 		out, err := client.DescribeLoadBalancersWithContext(ctx, &elb.DescribeLoadBalancersInput{LoadBalancerNames: []*string{&lbName}})
 		if err != nil {
 			if aerr, ok := err.(awserr.Error); ok {
 				if aerr.Code() == "AccessPointNotFound" {
-					return true, nil
+					return true, "", nil
 				}
 			}
-			LogWarning("error while waiting for ELB %s deletion: %s", lbName, err.Error())
-			return false, nil
+			a.logger.Warn("error while waiting for ELB %s deletion: %s", lbName, err.Error())
+			return false, "last check failed: " + err.Error(), nil
+		}
+		if len(out.LoadBalancerDescriptions) == 0 {
+			return true, "", nil
 		}
-		return len(out.LoadBalancerDescriptions) == 0, nil
+		return false, fmt.Sprintf("still described with %d instances registered", len(out.LoadBalancerDescriptions[0].Instances)), nil
 	}); err != nil {
-		return fmt.Errorf("timeout waiting for classic ELB %s to be deleted", lbName)
+		return fmt.Errorf("failed waiting for classic ELB %s to be deleted: %w", lbName, err)
 	}
 
 	return nil
@@ -0,0 +1,129 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// NAT gateways take minutes to be deleted, and the vpc cleaner may wait for several of
+// them at once, so their waits back off and are spread out.
+const (
+	natGatewayWaitBackoff     = 1.5
+	natGatewayWaitMaxInterval = time.Minute
+	natGatewayWaitJitter      = 0.2
+)
+
+// cleanNATGateways cleans up the NAT gateways on their own tags, whether their vpc is cleaned up
+// or not, e.g. because it's ignored or managed by cloudformation.
+func (a *action) cleanNATGateways(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	gatewaysToDelete := []*ec2.NatGateway{}
+	pageFunc := func(page *ec2.DescribeNatGatewaysOutput, _ bool) bool {
+		for _, natGw := range page.NatGateways {
+			id := aws.StringValue(natGw.NatGatewayId)
+
+			tags := ec2Tags(natGw.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("NAT gateway %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", id)
+				input.Report.managedByStack(input.Region, "NAT gateway", id, cloudFormationStack(tags))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "NAT gateway", ID: id, Tags: tags, CreatedAt: aws.TimeValue(natGw.CreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "NAT gateway", id, func() error {
+					return a.markNATGatewayForFutureDeletion(ctx, id, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding NAT gateway %s to delete list", id)
+			gatewaysToDelete = append(gatewaysToDelete, natGw)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeNatGatewaysPagesWithContext(ctx, &ec2.DescribeNatGatewaysInput{
+		Filter: []*ec2.Filter{
+			{Name: aws.String("state"), Values: []*string{aws.String(ec2.NatGatewayStateAvailable)}},
+		},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of NAT gateways: %w", err)
+	}
+
+	if len(gatewaysToDelete) == 0 {
+		a.logger.Info("no NAT gateways to delete")
+		return nil
+	}
+
+	deletedIds := []*string{}
+	// the elastic ips used by the NAT gateways aren't released along with them.
+	allocationIds := []*string{}
+	for _, natGw := range gatewaysToDelete {
+		id := aws.StringValue(natGw.NatGatewayId)
+		if !a.canDelete(input, "NAT gateway", id) {
+			continue
+		}
+
+		a.logger.Info("Deleting NAT Gateway %s of VPC %s", id, aws.StringValue(natGw.VpcId))
+		if _, err := client.DeleteNatGatewayWithContext(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: natGw.NatGatewayId}); err != nil {
+			if isAlreadyDeleted(a.logger, err, "NAT gateway", id) {
+				input.Report.skipped(input.Region, "NAT gateway", id, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete NAT gateway %s: %s", id, err.Error())
+			input.Report.failed(input.Region, "NAT gateway", id, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "NAT gateway", id)
+
+		deletedIds = append(deletedIds, natGw.NatGatewayId)
+		for _, address := range natGw.NatGatewayAddresses {
+			if address.AllocationId != nil {
+				allocationIds = append(allocationIds, address.AllocationId)
+			}
+		}
+	}
+
+	if len(allocationIds) == 0 {
+		return nil
+	}
+
+	// the elastic ips stay associated until the NAT gateways are deleted.
+	a.logger.Debug("Waiting up to %s for %d NAT gateways to be deleted", input.NATGatewayTimeout, len(deletedIds))
+	if err := waitUntilState(ctx, input.NATGatewayTimeout, 15*time.Second, func(ctx context.Context) (bool, string, error) {
+		out, err := client.DescribeNatGatewaysWithContext(ctx, &ec2.DescribeNatGatewaysInput{NatGatewayIds: deletedIds})
+		if err != nil {
+			return false, "", err
+		}
+		for _, natGw := range out.NatGateways {
+			if aws.StringValue(natGw.State) != ec2.NatGatewayStateDeleted {
+				return false, fmt.Sprintf("NAT gateway %s still in state %s", aws.StringValue(natGw.NatGatewayId), aws.StringValue(natGw.State)), nil
+			}
+		}
+		return true, "", nil
+	}, withBackoff(natGatewayWaitBackoff, natGatewayWaitMaxInterval), withJitter(natGatewayWaitJitter)); err != nil {
+		return fmt.Errorf("failed waiting for NAT gateways to be deleted, their elastic ips weren't released: %w", err)
+	}
+
+	return a.releaseNATGatewayAddresses(ctx, a.logger, allocationIds, input, client)
+}
+
+func (a *action) markNATGatewayForFutureDeletion(ctx context.Context, id string, client *ec2.EC2) error {
+	a.logger.Info("Marking NAT Gateway %s for future deletion", id)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&id},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
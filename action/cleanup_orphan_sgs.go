@@ -0,0 +1,179 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// cleanOrphanSecurityGroups cleans up the security groups that no network interface uses in the vpcs
+// the security group cleaner leaves alone, i.e. the default vpcs and the ones with the ignore tag.
+// A group referenced by the rules of a group that isn't deleted is skipped until the reference is removed.
+func (a *action) cleanOrphanSecurityGroups(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	keptVPCs := map[string]bool{}
+	vpcsPageFunc := func(page *ec2.DescribeVpcsOutput, _ bool) bool {
+		for _, vpc := range page.Vpcs {
+			if input.isIgnored(ec2Tags(vpc.Tags)) || aws.BoolValue(vpc.IsDefault) {
+				keptVPCs[aws.StringValue(vpc.VpcId)] = true
+			}
+		}
+		return true
+	}
+
+	if err := client.DescribeVpcsPagesWithContext(ctx, &ec2.DescribeVpcsInput{}, vpcsPageFunc); err != nil {
+		return fmt.Errorf("failed getting list of vpcs: %w", err)
+	}
+
+	if len(keptVPCs) == 0 {
+		a.logger.Info("no orphan security groups to delete")
+		return nil
+	}
+
+	groupsInUse, err := a.getSecurityGroupsInUse(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to get security groups in use: %w", err)
+	}
+
+	// all the groups are listed, as the groups of any vpc can reference the orphan ones.
+	groups := []*ec2.SecurityGroup{}
+	groupsPageFunc := func(page *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+		groups = append(groups, page.SecurityGroups...)
+		return true
+	}
+
+	if err := client.DescribeSecurityGroupsPagesWithContext(ctx, &ec2.DescribeSecurityGroupsInput{}, groupsPageFunc); err != nil {
+		return fmt.Errorf("failed getting list of security groups: %w", err)
+	}
+
+	sgsToDelete := map[string]*ec2.SecurityGroup{}
+	for _, sg := range groups {
+		sgId := aws.StringValue(sg.GroupId)
+		if !keptVPCs[aws.StringValue(sg.VpcId)] || aws.StringValue(sg.GroupName) == "default" {
+			continue
+		}
+
+		if eniId, ok := groupsInUse[sgId]; ok {
+			a.logger.Debug("security group %s is used by network interface %s, skipping cleanup", sgId, eniId)
+			input.Report.skipped(input.Region, "security group", sgId, "used by network interface "+eniId)
+			continue
+		}
+
+		switch input.evaluate(resource{Type: "security group", ID: sgId, Tags: ec2Tags(sg.Tags), Stampable: true}) {
+		case verdictSkip:
+			continue
+		case verdictStamp:
+			a.stampFirstSeen(input, "security group", sgId, func(tags Tags) error {
+				return a.tagEC2Resource(ctx, sgId, tags, client)
+			})
+			continue
+		case verdictMark:
+			a.markForDeletion(input, "security group", sgId, func() error {
+				return a.markSecurityGroupForFutureDeletion(ctx, sgId, client)
+			})
+			continue
+		}
+
+		sgsToDelete[sgId] = sg
+	}
+
+	// the references between the groups being deleted are revoked along with their rules, while
+	// the groups referenced by a kept group can't be deleted. Skipping a group can keep the groups it
+	// references in turn, so they're looked up until none is skipped.
+	for skipped := true; skipped; {
+		skipped = false
+		for _, sg := range groups {
+			if _, ok := sgsToDelete[aws.StringValue(sg.GroupId)]; ok {
+				continue
+			}
+			for _, referencedId := range referencedSecurityGroups(sg) {
+				if _, ok := sgsToDelete[referencedId]; !ok {
+					continue
+				}
+				a.logger.Debug("security group %s is referenced by security group %s, skipping cleanup", referencedId, aws.StringValue(sg.GroupId))
+				input.Report.skipped(input.Region, "security group", referencedId, "referenced by security group "+aws.StringValue(sg.GroupId))
+				delete(sgsToDelete, referencedId)
+				skipped = true
+			}
+		}
+	}
+
+	if len(sgsToDelete) == 0 {
+		a.logger.Info("no orphan security groups to delete")
+		return nil
+	}
+
+	confirmed := []*ec2.SecurityGroup{}
+	for _, sg := range groups {
+		sgId := aws.StringValue(sg.GroupId)
+		if _, ok := sgsToDelete[sgId]; !ok {
+			continue
+		}
+
+		if !a.canDelete(input, "security group", sgId) {
+			continue
+		}
+		confirmed = append(confirmed, sg)
+	}
+
+	// the rules of all the groups are revoked first, so the references between them don't
+	// block their deletion.
+	for _, sg := range confirmed {
+		if err := a.deleteSecurityGroupRules(ctx, aws.StringValue(sg.GroupId), sg.IpPermissions, sg.IpPermissionsEgress, client); err != nil {
+			a.logger.Error("failed to delete security group rules for %s: %s", aws.StringValue(sg.GroupId), err.Error())
+		}
+	}
+
+	for _, sg := range confirmed {
+		sgId := aws.StringValue(sg.GroupId)
+		if err := a.deleteSecurityGroup(ctx, sgId, client); err != nil {
+			if isAlreadyDeleted(a.logger, err, "security group", sgId) {
+				input.Report.skipped(input.Region, "security group", sgId, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete security group %s: %s", sgId, err.Error())
+			input.Report.failed(input.Region, "security group", sgId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "security group", sgId)
+	}
+
+	return nil
+}
+
+// getSecurityGroupsInUse returns the ids of the security groups used by a network interface, along with
+// the id of one of these interfaces.
+func (a *action) getSecurityGroupsInUse(ctx context.Context, client *ec2.EC2) (map[string]string, error) {
+	groupsInUse := map[string]string{}
+	pageFunc := func(page *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		for _, eni := range page.NetworkInterfaces {
+			for _, group := range eni.Groups {
+				groupsInUse[aws.StringValue(group.GroupId)] = aws.StringValue(eni.NetworkInterfaceId)
+			}
+		}
+		return true
+	}
+
+	if err := client.DescribeNetworkInterfacesPagesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return groupsInUse, nil
+}
+
+// referencedSecurityGroups returns the ids of the other security groups the rules of the group reference.
+func referencedSecurityGroups(sg *ec2.SecurityGroup) []string {
+	ids := []string{}
+	for _, permission := range append(append([]*ec2.IpPermission{}, sg.IpPermissions...), sg.IpPermissionsEgress...) {
+		for _, pair := range permission.UserIdGroupPairs {
+			if id := aws.StringValue(pair.GroupId); id != "" && id != aws.StringValue(sg.GroupId) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
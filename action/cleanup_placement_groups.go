@@ -0,0 +1,120 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// cleanPlacementGroups cleans up the placement groups that have no member instances left.
+func (a *action) cleanPlacementGroups(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	groupsInUse := map[string]string{}
+	instancesPageFunc := func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.Placement != nil && aws.StringValue(instance.Placement.GroupName) != "" {
+					groupsInUse[*instance.Placement.GroupName] = aws.StringValue(instance.InstanceId)
+				}
+			}
+		}
+
+		return true
+	}
+
+	// the instances being terminated are still members of their group, which can't be deleted until they're gone.
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{
+				ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, ec2.InstanceStateNameShuttingDown,
+				ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped,
+			})},
+		},
+	}, instancesPageFunc); err != nil {
+		return fmt.Errorf("failed to get instances: %w", err)
+	}
+
+	out, err := client.DescribePlacementGroupsWithContext(ctx, &ec2.DescribePlacementGroupsInput{})
+	if err != nil {
+		return fmt.Errorf("failed getting list of placement groups: %w", err)
+	}
+
+	groupsToDelete := []*string{}
+	for _, group := range out.PlacementGroups {
+		name := aws.StringValue(group.GroupName)
+		if aws.StringValue(group.State) != ec2.PlacementGroupStateAvailable {
+			continue
+		}
+
+		if instanceId, ok := groupsInUse[name]; ok {
+			a.logger.Debug("placement group %s is used by instance %s, skipping cleanup", name, instanceId)
+			input.Report.skipped(input.Region, "placement group", name, "used by instance "+instanceId)
+			continue
+		}
+
+		tags := ec2Tags(group.Tags)
+		// the groups created with older apis have no id and can't be tagged, they're only cleaned up
+		// when their name has the placement group prefix. They're considered as marked for deletion by an
+		// older version, so they're deleted straight away and ignore tags can't protect them.
+		if group.GroupId == nil {
+			if input.PlacementGroupPrefix == "" || !strings.HasPrefix(name, input.PlacementGroupPrefix) {
+				a.logger.Debug("placement group %s can't be tagged and doesn't match prefix %s, skipping cleanup", name, input.PlacementGroupPrefix)
+				continue
+			}
+			tags = Tags{input.DeletionTag: "true"}
+		}
+
+		switch input.evaluate(resource{Type: "placement group", ID: name, ARN: aws.StringValue(group.GroupArn), Tags: tags, Stampable: true}) {
+		case verdictSkip:
+			continue
+		case verdictStamp:
+			a.stampFirstSeen(input, "placement group", name, func(tags Tags) error {
+				return a.tagEC2Resource(ctx, aws.StringValue(group.GroupId), tags, client)
+			})
+			continue
+		case verdictMark:
+			a.markForDeletion(input, "placement group", name, func() error {
+				return a.markPlacementGroupForFutureDeletion(ctx, aws.StringValue(group.GroupId), client)
+			})
+			continue
+		}
+
+		a.logger.Debug("adding placement group %s to delete list", name)
+		groupsToDelete = append(groupsToDelete, group.GroupName)
+	}
+
+	if len(groupsToDelete) == 0 {
+		a.logger.Info("no empty placement groups to delete")
+		return nil
+	}
+
+	for _, groupName := range groupsToDelete {
+		if !a.canDelete(input, "placement group", *groupName) {
+			continue
+		}
+
+		a.logger.Info("Deleting Placement Group %s", *groupName)
+		if _, err := client.DeletePlacementGroupWithContext(ctx, &ec2.DeletePlacementGroupInput{GroupName: groupName}); err != nil {
+			if isAlreadyDeleted(a.logger, err, "placement group", *groupName) {
+				input.Report.skipped(input.Region, "placement group", *groupName, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete placement group %s: %s", *groupName, err.Error())
+			input.Report.failed(input.Region, "placement group", *groupName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "placement group", *groupName)
+	}
+
+	return nil
+}
+
+func (a *action) markPlacementGroupForFutureDeletion(ctx context.Context, groupId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Placement Group %s for future deletion", groupId)
+
+	return a.tagEC2Resource(ctx, groupId, a.markTags(), client)
+}
@@ -0,0 +1,355 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func (a *action) cleanRDSInstances(ctx context.Context, input *CleanupScope) error {
+	client := rds.New(input.Session)
+
+	instancesToDelete := []*rds.DBInstance{}
+	pageFunc := func(page *rds.DescribeDBInstancesOutput, _ bool) bool {
+		for _, instance := range page.DBInstances {
+			// instances that are part of a cluster are deleted along with their cluster.
+			if instance.DBClusterIdentifier != nil {
+				a.logger.Debug("rds instance %s is part of cluster %s, skipping cleanup", *instance.DBInstanceIdentifier, *instance.DBClusterIdentifier)
+				continue
+			}
+
+			if aws.StringValue(instance.DBInstanceStatus) == "deleting" {
+				a.logger.Debug("rds instance %s is already being deleted, skipping cleanup", *instance.DBInstanceIdentifier)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &rds.ListTagsForResourceInput{ResourceName: instance.DBInstanceArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for rds instance %s: %s", *instance.DBInstanceIdentifier, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "rds instance", ID: *instance.DBInstanceIdentifier, ARN: aws.StringValue(instance.DBInstanceArn), Tags: rdsTags(tagOut.TagList), CreatedAt: aws.TimeValue(instance.InstanceCreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "rds instance", *instance.DBInstanceIdentifier, func() error {
+					return a.markRDSResourceForFutureDeletion(ctx, *instance.DBInstanceArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding rds instance %s to delete list", *instance.DBInstanceIdentifier)
+			instancesToDelete = append(instancesToDelete, instance)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeDBInstancesPagesWithContext(ctx, &rds.DescribeDBInstancesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of rds instances: %w", err)
+	}
+
+	if len(instancesToDelete) == 0 {
+		a.logger.Info("no rds instances to delete")
+		return nil
+	}
+
+	for _, instance := range instancesToDelete {
+		if !a.canDelete(input, "rds instance", *instance.DBInstanceIdentifier) {
+			continue
+		}
+
+		if err := a.deleteRDSInstance(ctx, instance, client); err != nil {
+			a.logger.Error("failed to delete rds instance %s: %s", *instance.DBInstanceIdentifier, err.Error())
+			input.Report.failed(input.Region, "rds instance", *instance.DBInstanceIdentifier, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "rds instance", *instance.DBInstanceIdentifier)
+	}
+
+	return nil
+}
+
+func (a *action) cleanRDSClusters(ctx context.Context, input *CleanupScope) error {
+	client := rds.New(input.Session)
+
+	clustersToDelete := []*rds.DBCluster{}
+	pageFunc := func(page *rds.DescribeDBClustersOutput, _ bool) bool {
+		for _, cluster := range page.DBClusters {
+			if aws.StringValue(cluster.Status) == "deleting" {
+				a.logger.Debug("rds cluster %s is already being deleted, skipping cleanup", *cluster.DBClusterIdentifier)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &rds.ListTagsForResourceInput{ResourceName: cluster.DBClusterArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for rds cluster %s: %s", *cluster.DBClusterIdentifier, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "rds cluster", ID: *cluster.DBClusterIdentifier, ARN: aws.StringValue(cluster.DBClusterArn), Tags: rdsTags(tagOut.TagList), CreatedAt: aws.TimeValue(cluster.ClusterCreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "rds cluster", *cluster.DBClusterIdentifier, func() error {
+					return a.markRDSResourceForFutureDeletion(ctx, *cluster.DBClusterArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding rds cluster %s to delete list", *cluster.DBClusterIdentifier)
+			clustersToDelete = append(clustersToDelete, cluster)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeDBClustersPagesWithContext(ctx, &rds.DescribeDBClustersInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of rds clusters: %w", err)
+	}
+
+	if len(clustersToDelete) == 0 {
+		a.logger.Info("no rds clusters to delete")
+		return nil
+	}
+
+	for _, cluster := range clustersToDelete {
+		if !a.canDelete(input, "rds cluster", *cluster.DBClusterIdentifier) {
+			continue
+		}
+
+		if err := a.deleteRDSCluster(ctx, cluster, client); err != nil {
+			a.logger.Error("failed to delete rds cluster %s: %s", *cluster.DBClusterIdentifier, err.Error())
+			input.Report.failed(input.Region, "rds cluster", *cluster.DBClusterIdentifier, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "rds cluster", *cluster.DBClusterIdentifier)
+	}
+
+	return nil
+}
+
+// cleanDBSubnetGroups cleans up the db subnet groups that aren't used by any instance or cluster,
+// as they block the vpc deletion.
+func (a *action) cleanDBSubnetGroups(ctx context.Context, input *CleanupScope) error {
+	client := rds.New(input.Session)
+
+	groupsInUse, err := a.getDBSubnetGroupsInUse(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	groupsToDelete := []*string{}
+	pageFunc := func(page *rds.DescribeDBSubnetGroupsOutput, _ bool) bool {
+		for _, group := range page.DBSubnetGroups {
+			name := aws.StringValue(group.DBSubnetGroupName)
+			if name == "default" {
+				a.logger.Debug("db subnet group %s is the default one, skipping cleanup", name)
+				continue
+			}
+
+			if user, ok := groupsInUse[name]; ok {
+				a.logger.Debug("db subnet group %s is used by %s, skipping cleanup", name, user)
+				input.Report.skipped(input.Region, "db subnet group", name, "used by "+user)
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &rds.ListTagsForResourceInput{ResourceName: group.DBSubnetGroupArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for db subnet group %s: %s", name, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "db subnet group", ID: name, ARN: aws.StringValue(group.DBSubnetGroupArn), Tags: rdsTags(tagOut.TagList), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "db subnet group", name, func(tags Tags) error {
+					return a.tagRDSResource(ctx, *group.DBSubnetGroupArn, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "db subnet group", name, func() error {
+					return a.markRDSResourceForFutureDeletion(ctx, *group.DBSubnetGroupArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding db subnet group %s to delete list", name)
+			groupsToDelete = append(groupsToDelete, group.DBSubnetGroupName)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeDBSubnetGroupsPagesWithContext(ctx, &rds.DescribeDBSubnetGroupsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of db subnet groups: %w", err)
+	}
+
+	if len(groupsToDelete) == 0 {
+		a.logger.Info("no unused db subnet groups to delete")
+		return nil
+	}
+
+	for _, groupName := range groupsToDelete {
+		if !a.canDelete(input, "db subnet group", *groupName) {
+			continue
+		}
+
+		a.logger.Info("Deleting DB Subnet Group %s", *groupName)
+		if _, err := client.DeleteDBSubnetGroupWithContext(ctx, &rds.DeleteDBSubnetGroupInput{DBSubnetGroupName: groupName}); err != nil {
+			if isAlreadyDeleted(a.logger, err, "db subnet group", *groupName) {
+				input.Report.skipped(input.Region, "db subnet group", *groupName, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete db subnet group %s: %s", *groupName, err.Error())
+			input.Report.failed(input.Region, "db subnet group", *groupName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "db subnet group", *groupName)
+	}
+
+	return nil
+}
+
+// getDBSubnetGroupsInUse returns the names of the db subnet groups used by the instances and the
+// clusters, including the ones being deleted, along with one of them, e.g. "rds instance db-1".
+func (a *action) getDBSubnetGroupsInUse(ctx context.Context, client *rds.RDS) (map[string]string, error) {
+	groupsInUse := map[string]string{}
+
+	if err := client.DescribeDBInstancesPagesWithContext(ctx, &rds.DescribeDBInstancesInput{}, func(page *rds.DescribeDBInstancesOutput, _ bool) bool {
+		for _, instance := range page.DBInstances {
+			if instance.DBSubnetGroup != nil && instance.DBSubnetGroup.DBSubnetGroupName != nil {
+				groupsInUse[*instance.DBSubnetGroup.DBSubnetGroupName] = "rds instance " + aws.StringValue(instance.DBInstanceIdentifier)
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get rds instances: %w", err)
+	}
+
+	if err := client.DescribeDBClustersPagesWithContext(ctx, &rds.DescribeDBClustersInput{}, func(page *rds.DescribeDBClustersOutput, _ bool) bool {
+		for _, cluster := range page.DBClusters {
+			if aws.StringValue(cluster.DBSubnetGroup) != "" {
+				groupsInUse[*cluster.DBSubnetGroup] = "rds cluster " + aws.StringValue(cluster.DBClusterIdentifier)
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to get rds clusters: %w", err)
+	}
+
+	return groupsInUse, nil
+}
+
+func (a *action) markRDSResourceForFutureDeletion(ctx context.Context, arn string, client *rds.RDS) error {
+	a.logger.Info("Marking RDS resource %s for future deletion", arn)
+
+	return a.tagRDSResource(ctx, arn, a.markTags(), client)
+}
+
+func (a *action) tagRDSResource(ctx context.Context, arn string, tags Tags, client *rds.RDS) error {
+	_, err := client.AddTagsToResourceWithContext(ctx, &rds.AddTagsToResourceInput{
+		ResourceName: &arn,
+		Tags:         rdsTagList(tags),
+	})
+
+	return err
+}
+
+func (a *action) deleteRDSInstance(ctx context.Context, instance *rds.DBInstance, client *rds.RDS) error {
+	id := *instance.DBInstanceIdentifier
+	a.logger.Info("Deleting RDS instance %s (engine %s, class %s)", id, aws.StringValue(instance.Engine), aws.StringValue(instance.DBInstanceClass))
+
+	if aws.BoolValue(instance.DeletionProtection) {
+		a.logger.Debug("Disabling deletion protection for rds instance %s", id)
+		if _, err := client.ModifyDBInstanceWithContext(ctx, &rds.ModifyDBInstanceInput{
+			DBInstanceIdentifier: &id,
+			DeletionProtection:   aws.Bool(false),
+			ApplyImmediately:     aws.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("failed to disable deletion protection for rds instance %s: %w", id, err)
+		}
+	}
+
+	if _, err := client.DeleteDBInstanceWithContext(ctx, &rds.DeleteDBInstanceInput{
+		DBInstanceIdentifier:   &id,
+		SkipFinalSnapshot:      aws.Bool(true),
+		DeleteAutomatedBackups: aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("failed to delete rds instance %s: %w", id, err)
+	}
+
+	if err := waitUntil(ctx, 30*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: &id})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBInstanceNotFoundFault {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for rds instance %s deletion: %s", id, err.Error())
+			return false, nil
+		}
+		return len(out.DBInstances) == 0, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for rds instance %s deletion: %w", id, err)
+	}
+
+	return nil
+}
+
+func (a *action) deleteRDSCluster(ctx context.Context, cluster *rds.DBCluster, client *rds.RDS) error {
+	id := *cluster.DBClusterIdentifier
+	a.logger.Info("Deleting RDS cluster %s (engine %s) with its %d instances", id, aws.StringValue(cluster.Engine), len(cluster.DBClusterMembers))
+
+	if aws.BoolValue(cluster.DeletionProtection) {
+		a.logger.Debug("Disabling deletion protection for rds cluster %s", id)
+		if _, err := client.ModifyDBClusterWithContext(ctx, &rds.ModifyDBClusterInput{
+			DBClusterIdentifier: &id,
+			DeletionProtection:  aws.Bool(false),
+			ApplyImmediately:    aws.Bool(true),
+		}); err != nil {
+			return fmt.Errorf("failed to disable deletion protection for rds cluster %s: %w", id, err)
+		}
+	}
+
+	// a cluster can't be deleted while it still has instances.
+	for _, member := range cluster.DBClusterMembers {
+		out, err := client.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: member.DBInstanceIdentifier})
+		if err != nil {
+			return fmt.Errorf("failed to describe rds instance %s of cluster %s: %w", aws.StringValue(member.DBInstanceIdentifier), id, err)
+		}
+		for _, instance := range out.DBInstances {
+			if err := a.deleteRDSInstance(ctx, instance, client); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := client.DeleteDBClusterWithContext(ctx, &rds.DeleteDBClusterInput{
+		DBClusterIdentifier: &id,
+		SkipFinalSnapshot:   aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("failed to delete rds cluster %s: %w", id, err)
+	}
+
+	if err := waitUntil(ctx, 30*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeDBClustersWithContext(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: &id})
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBClusterNotFoundFault {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for rds cluster %s deletion: %s", id, err.Error())
+			return false, nil
+		}
+		return len(out.DBClusters) == 0, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for rds cluster %s deletion: %w", id, err)
+	}
+
+	return nil
+}
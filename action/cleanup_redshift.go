@@ -0,0 +1,198 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/redshift"
+)
+
+func (a *action) cleanRedshiftClusters(ctx context.Context, input *CleanupScope) error {
+	client := redshift.New(input.Session)
+
+	clustersToDelete := []*string{}
+	pageFunc := func(page *redshift.DescribeClustersOutput, _ bool) bool {
+		for _, cluster := range page.Clusters {
+			id := aws.StringValue(cluster.ClusterIdentifier)
+
+			if aws.StringValue(cluster.ClusterStatus) == "deleting" {
+				a.logger.Debug("redshift cluster %s is already being deleted, skipping cleanup", id)
+				continue
+			}
+
+			tags := redshiftTags(cluster.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("redshift cluster %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", id)
+				input.Report.managedByStack(input.Region, "redshift cluster", id, cloudFormationStack(tags))
+				continue
+			}
+
+			clusterArn := regionalARN(input, redshift.ServiceName, "cluster:"+id)
+			switch input.evaluate(resource{Type: "redshift cluster", ID: id, ARN: clusterArn, Tags: tags, CreatedAt: aws.TimeValue(cluster.ClusterCreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "redshift cluster", id, func() error {
+					return a.markRedshiftResourceForFutureDeletion(ctx, clusterArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding redshift cluster %s to delete list", id)
+			clustersToDelete = append(clustersToDelete, cluster.ClusterIdentifier)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeClustersPagesWithContext(ctx, &redshift.DescribeClustersInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of redshift clusters: %w", err)
+	}
+
+	if len(clustersToDelete) == 0 {
+		a.logger.Info("no redshift clusters to delete")
+		return nil
+	}
+
+	for _, clusterId := range clustersToDelete {
+		if !a.canDelete(input, "redshift cluster", *clusterId) {
+			continue
+		}
+
+		if err := a.deleteRedshiftCluster(ctx, *clusterId, client); err != nil {
+			a.logger.Error("failed to delete redshift cluster %s: %s", *clusterId, err.Error())
+			input.Report.failed(input.Region, "redshift cluster", *clusterId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "redshift cluster", *clusterId)
+	}
+
+	return nil
+}
+
+// cleanRedshiftSubnetGroups cleans up the cluster subnet groups that aren't used by any cluster,
+// as they block the vpc deletion.
+func (a *action) cleanRedshiftSubnetGroups(ctx context.Context, input *CleanupScope) error {
+	client := redshift.New(input.Session)
+
+	groupsInUse := map[string]string{}
+	clustersPageFunc := func(page *redshift.DescribeClustersOutput, _ bool) bool {
+		for _, cluster := range page.Clusters {
+			if cluster.ClusterSubnetGroupName != nil {
+				groupsInUse[*cluster.ClusterSubnetGroupName] = aws.StringValue(cluster.ClusterIdentifier)
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeClustersPagesWithContext(ctx, &redshift.DescribeClustersInput{}, clustersPageFunc); err != nil {
+		return fmt.Errorf("failed to get redshift clusters: %w", err)
+	}
+
+	groupsToDelete := []*string{}
+	pageFunc := func(page *redshift.DescribeClusterSubnetGroupsOutput, _ bool) bool {
+		for _, group := range page.ClusterSubnetGroups {
+			name := aws.StringValue(group.ClusterSubnetGroupName)
+			if name == "default" {
+				a.logger.Debug("redshift subnet group %s is the default one, skipping cleanup", name)
+				continue
+			}
+
+			if clusterId, ok := groupsInUse[name]; ok {
+				a.logger.Debug("redshift subnet group %s is used by redshift cluster %s, skipping cleanup", name, clusterId)
+				input.Report.skipped(input.Region, "redshift subnet group", name, "used by redshift cluster "+clusterId)
+				continue
+			}
+
+			groupArn := regionalARN(input, redshift.ServiceName, "subnetgroup:"+name)
+			switch input.evaluate(resource{Type: "redshift subnet group", ID: name, ARN: groupArn, Tags: redshiftTags(group.Tags), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "redshift subnet group", name, func(tags Tags) error {
+					return a.tagRedshiftResource(ctx, groupArn, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "redshift subnet group", name, func() error {
+					return a.markRedshiftResourceForFutureDeletion(ctx, groupArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding redshift subnet group %s to delete list", name)
+			groupsToDelete = append(groupsToDelete, group.ClusterSubnetGroupName)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeClusterSubnetGroupsPagesWithContext(ctx, &redshift.DescribeClusterSubnetGroupsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of redshift subnet groups: %w", err)
+	}
+
+	if len(groupsToDelete) == 0 {
+		a.logger.Info("no unused redshift subnet groups to delete")
+		return nil
+	}
+
+	for _, groupName := range groupsToDelete {
+		if !a.canDelete(input, "redshift subnet group", *groupName) {
+			continue
+		}
+
+		a.logger.Info("Deleting Redshift Subnet Group %s", *groupName)
+		if _, err := client.DeleteClusterSubnetGroupWithContext(ctx, &redshift.DeleteClusterSubnetGroupInput{ClusterSubnetGroupName: groupName}); err != nil {
+			a.logger.Error("failed to delete redshift subnet group %s: %s", *groupName, err.Error())
+			input.Report.failed(input.Region, "redshift subnet group", *groupName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "redshift subnet group", *groupName)
+	}
+
+	return nil
+}
+
+func (a *action) markRedshiftResourceForFutureDeletion(ctx context.Context, arn string, client *redshift.Redshift) error {
+	a.logger.Info("Marking Redshift resource %s for future deletion", arn)
+
+	return a.tagRedshiftResource(ctx, arn, a.markTags(), client)
+}
+
+func (a *action) tagRedshiftResource(ctx context.Context, arn string, tags Tags, client *redshift.Redshift) error {
+	_, err := client.CreateTagsWithContext(ctx, &redshift.CreateTagsInput{
+		ResourceName: &arn,
+		Tags:         redshiftTagList(tags),
+	})
+
+	return err
+}
+
+func (a *action) deleteRedshiftCluster(ctx context.Context, clusterId string, client *redshift.Redshift) error {
+	a.logger.Info("Deleting Redshift cluster %s", clusterId)
+
+	if _, err := client.DeleteClusterWithContext(ctx, &redshift.DeleteClusterInput{
+		ClusterIdentifier:        &clusterId,
+		SkipFinalClusterSnapshot: aws.Bool(true),
+	}); err != nil {
+		return fmt.Errorf("failed to delete redshift cluster %s: %w", clusterId, err)
+	}
+
+	if err := waitUntil(ctx, 30*time.Minute, 30*time.Second, func(ctx context.Context) (bool, error) {
+		if _, err := client.DescribeClustersWithContext(ctx, &redshift.DescribeClustersInput{ClusterIdentifier: &clusterId}); err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == redshift.ErrCodeClusterNotFoundFault {
+				return true, nil
+			}
+			a.logger.Warn("error while waiting for redshift cluster %s deletion: %s", clusterId, err.Error())
+		}
+		return false, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for redshift cluster %s deletion: %w", clusterId, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// route53ChangeBatchSize is the maximum number of changes in a single ChangeResourceRecordSets request.
+const route53ChangeBatchSize = 1000
+
+func (a *action) cleanRoute53Zones(ctx context.Context, input *CleanupScope) error {
+	client := route53.New(input.Session)
+
+	zonesToDelete := []*route53.HostedZone{}
+	pageFunc := func(page *route53.ListHostedZonesOutput, _ bool) bool {
+		for _, zone := range page.HostedZones {
+			zoneId := strings.TrimPrefix(*zone.Id, "/hostedzone/")
+
+			// zones created by other services (e.g. cloud map) must be deleted through them.
+			if zone.LinkedService != nil {
+				a.logger.Debug("hosted zone %s (%s) is managed by %s, skipping cleanup", zoneId, aws.StringValue(zone.Name), aws.StringValue(zone.LinkedService.ServicePrincipal))
+				input.Report.skipped(input.Region, "hosted zone", zoneId, "managed by "+aws.StringValue(zone.LinkedService.ServicePrincipal))
+				continue
+			}
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &route53.ListTagsForResourceInput{
+				ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+				ResourceId:   &zoneId,
+			})
+			if err != nil {
+				a.logger.Error("failed getting tags for hosted zone %s: %s", zoneId, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "hosted zone", ID: zoneId, Tags: route53Tags(tagOut.ResourceTagSet.Tags), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "hosted zone", zoneId, func(tags Tags) error {
+					return a.tagHostedZone(ctx, zoneId, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "hosted zone", zoneId, func() error {
+					return a.markHostedZoneForFutureDeletion(ctx, zoneId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding hosted zone %s to delete list", zoneId)
+			zonesToDelete = append(zonesToDelete, zone)
+		}
+
+		return true
+	}
+
+	if err := client.ListHostedZonesPagesWithContext(ctx, &route53.ListHostedZonesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of hosted zones: %w", err)
+	}
+
+	if len(zonesToDelete) == 0 {
+		a.logger.Info("no hosted zones to delete")
+		return nil
+	}
+
+	for _, zone := range zonesToDelete {
+		zoneId := strings.TrimPrefix(*zone.Id, "/hostedzone/")
+		if !a.canDelete(input, "hosted zone", zoneId) {
+			continue
+		}
+
+		if err := a.deleteHostedZone(ctx, zoneId, zone, client); err != nil {
+			a.logger.Error("failed to delete hosted zone %s: %s", zoneId, err.Error())
+			input.Report.failed(input.Region, "hosted zone", zoneId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "hosted zone", zoneId)
+	}
+
+	return nil
+}
+
+func (a *action) markHostedZoneForFutureDeletion(ctx context.Context, zoneId string, client *route53.Route53) error {
+	a.logger.Info("Marking Hosted Zone %s for future deletion", zoneId)
+
+	return a.tagHostedZone(ctx, zoneId, a.markTags(), client)
+}
+
+func (a *action) tagHostedZone(ctx context.Context, zoneId string, tags Tags, client *route53.Route53) error {
+	_, err := client.ChangeTagsForResourceWithContext(ctx, &route53.ChangeTagsForResourceInput{
+		ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+		ResourceId:   &zoneId,
+		AddTags:      route53TagList(tags),
+	})
+
+	return err
+}
+
+// deleteHostedZone deletes the record sets of the zone, except the NS and SOA records
+// created along with it, then deletes the zone.
+func (a *action) deleteHostedZone(ctx context.Context, zoneId string, zone *route53.HostedZone, client *route53.Route53) error {
+	a.logger.Info("Deleting Hosted Zone %s (%s)", zoneId, aws.StringValue(zone.Name))
+
+	if zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone) {
+		// the vpcs of a private zone may have been deleted already, which doesn't
+		// prevent the zone deletion, so failing to get them is only logged.
+		out, err := client.GetHostedZoneWithContext(ctx, &route53.GetHostedZoneInput{Id: zone.Id})
+		switch {
+		case err != nil:
+			a.logger.Warn("failed getting vpcs associated to private hosted zone %s: %s", zoneId, err.Error())
+		case len(out.VPCs) == 0:
+			a.logger.Debug("private hosted zone %s isn't associated to any vpc", zoneId)
+		default:
+			for _, vpc := range out.VPCs {
+				a.logger.Debug("private hosted zone %s is associated to vpc %s in %s", zoneId, aws.StringValue(vpc.VPCId), aws.StringValue(vpc.VPCRegion))
+			}
+		}
+	}
+
+	changes := []*route53.Change{}
+	pageFunc := func(page *route53.ListResourceRecordSetsOutput, _ bool) bool {
+		for _, record := range page.ResourceRecordSets {
+			recordType := aws.StringValue(record.Type)
+			if (recordType == route53.RRTypeNs || recordType == route53.RRTypeSoa) && aws.StringValue(record.Name) == aws.StringValue(zone.Name) {
+				continue
+			}
+			changes = append(changes, &route53.Change{Action: aws.String(route53.ChangeActionDelete), ResourceRecordSet: record})
+		}
+
+		return true
+	}
+
+	if err := client.ListResourceRecordSetsPagesWithContext(ctx, &route53.ListResourceRecordSetsInput{HostedZoneId: zone.Id}, pageFunc); err != nil {
+		return fmt.Errorf("failed to list record sets: %w", err)
+	}
+
+	for start := 0; start < len(changes); start += route53ChangeBatchSize {
+		end := start + route53ChangeBatchSize
+		if end > len(changes) {
+			end = len(changes)
+		}
+
+		a.logger.Debug("Deleting %d record sets of hosted zone %s", end-start, zoneId)
+		if _, err := client.ChangeResourceRecordSetsWithContext(ctx, &route53.ChangeResourceRecordSetsInput{
+			HostedZoneId: zone.Id,
+			ChangeBatch:  &route53.ChangeBatch{Changes: changes[start:end]},
+		}); err != nil {
+			return fmt.Errorf("failed to delete record sets: %w", err)
+		}
+	}
+
+	if _, err := client.DeleteHostedZoneWithContext(ctx, &route53.DeleteHostedZoneInput{Id: zone.Id}); err != nil {
+		return fmt.Errorf("failed to delete hosted zone %s: %w", zoneId, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const (
+	// s3DeleteBatchSize is the maximum number of keys DeleteObjects accepts.
+	s3DeleteBatchSize = 1000
+)
+
+func (a *action) cleanS3Buckets(ctx context.Context, input *CleanupScope) error {
+	client := s3.New(input.Session)
+
+	// buckets are listed globally, only the ones located in the region being cleaned are considered.
+	out, err := client.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return fmt.Errorf("failed getting list of buckets: %w", err)
+	}
+
+	bucketsToDelete := []*string{}
+	for _, bucket := range out.Buckets {
+		location, err := client.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{Bucket: bucket.Name})
+		if err != nil {
+			a.logger.Error("failed getting location of bucket %s: %s", *bucket.Name, err.Error())
+			continue
+		}
+		if s3.NormalizeBucketLocation(aws.StringValue(location.LocationConstraint)) != input.Region {
+			continue
+		}
+
+		tags, err := a.getBucketTags(ctx, *bucket.Name, client)
+		if err != nil {
+			a.logger.Error("failed getting tags for bucket %s: %s", *bucket.Name, err.Error())
+			continue
+		}
+
+		switch input.evaluate(resource{Type: "bucket", ID: *bucket.Name, Tags: tags, CreatedAt: aws.TimeValue(bucket.CreationDate)}) {
+		case verdictSkip:
+			continue
+		case verdictMark:
+			a.markForDeletion(input, "bucket", *bucket.Name, func() error {
+				return a.markBucketForFutureDeletion(ctx, *bucket.Name, tags, client)
+			})
+			continue
+		}
+
+		a.logger.Debug("adding bucket %s to delete list", *bucket.Name)
+		bucketsToDelete = append(bucketsToDelete, bucket.Name)
+	}
+
+	if len(bucketsToDelete) == 0 {
+		a.logger.Info("no buckets to delete")
+		return nil
+	}
+
+	for _, bucketName := range bucketsToDelete {
+		if !a.canDelete(input, "bucket", *bucketName) {
+			continue
+		}
+
+		if err := a.deleteBucket(ctx, *bucketName, client); err != nil {
+			a.logger.Error("failed to delete bucket %s: %s", *bucketName, err.Error())
+			input.Report.failed(input.Region, "bucket", *bucketName, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "bucket", *bucketName)
+	}
+
+	return nil
+}
+
+func (a *action) getBucketTags(ctx context.Context, bucketName string, client *s3.S3) (Tags, error) {
+	out, err := client.GetBucketTaggingWithContext(ctx, &s3.GetBucketTaggingInput{Bucket: &bucketName})
+	if err != nil {
+		// NOTE: buckets without tags return an error instead of an empty tag set.
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchTagSet" {
+			return Tags{}, nil
+		}
+		return nil, err
+	}
+
+	return s3Tags(out.TagSet), nil
+}
+
+func (a *action) markBucketForFutureDeletion(ctx context.Context, bucketName string, tags Tags, client *s3.S3) error {
+	a.logger.Info("Marking Bucket %s for future deletion", bucketName)
+
+	// NOTE: PutBucketTagging replaces the whole tag set, so the existing tags must be kept.
+	tagSet := Tags{}
+	for key, value := range tags {
+		tagSet[key] = value
+	}
+	for key, value := range a.markTags() {
+		tagSet[key] = value
+	}
+
+	_, err := client.PutBucketTaggingWithContext(ctx, &s3.PutBucketTaggingInput{
+		Bucket:  &bucketName,
+		Tagging: &s3.Tagging{TagSet: s3TagList(tagSet)},
+	})
+
+	return err
+}
+
+func (a *action) deleteBucket(ctx context.Context, bucketName string, client *s3.S3) error {
+	a.logger.Info("Deleting Bucket %s and its objects", bucketName)
+
+	if err := a.emptyBucket(ctx, bucketName, client); err != nil {
+		return fmt.Errorf("failed to empty bucket %s: %w", bucketName, err)
+	}
+
+	if _, err := client.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{Bucket: &bucketName}); err != nil {
+		return fmt.Errorf("failed to delete bucket %s: %w", bucketName, err)
+	}
+
+	return nil
+}
+
+// emptyBucket deletes all the object versions and delete markers of a bucket, so it
+// works for both versioned and unversioned buckets. Objects are deleted page by page
+// so large buckets don't have to be listed in memory first.
+func (a *action) emptyBucket(ctx context.Context, bucketName string, client *s3.S3) error {
+	var deleteErr error
+	deleted := 0
+	pageFunc := func(page *s3.ListObjectVersionsOutput, _ bool) bool {
+		objects := []*s3.ObjectIdentifier{}
+		for _, version := range page.Versions {
+			objects = append(objects, &s3.ObjectIdentifier{Key: version.Key, VersionId: version.VersionId})
+		}
+		for _, marker := range page.DeleteMarkers {
+			objects = append(objects, &s3.ObjectIdentifier{Key: marker.Key, VersionId: marker.VersionId})
+		}
+
+		for start := 0; start < len(objects); start += s3DeleteBatchSize {
+			end := start + s3DeleteBatchSize
+			if end > len(objects) {
+				end = len(objects)
+			}
+
+			if deleteErr = a.deleteObjects(ctx, bucketName, objects[start:end], client); deleteErr != nil {
+				return false
+			}
+			deleted += end - start
+		}
+
+		return true
+	}
+
+	if err := client.ListObjectVersionsPagesWithContext(ctx, &s3.ListObjectVersionsInput{Bucket: &bucketName}, pageFunc); err != nil {
+		return fmt.Errorf("failed to list object versions: %w", err)
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	a.logger.Debug("Deleted %d object versions from bucket %s", deleted, bucketName)
+
+	return nil
+}
+
+func (a *action) deleteObjects(ctx context.Context, bucketName string, objects []*s3.ObjectIdentifier, client *s3.S3) error {
+	out, err := client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+		Bucket: &bucketName,
+		Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete objects: %w", err)
+	}
+	if len(out.Errors) > 0 {
+		return fmt.Errorf("failed to delete %d objects, first error on %s: %s", len(out.Errors), aws.StringValue(out.Errors[0].Key), aws.StringValue(out.Errors[0].Message))
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func (a *action) cleanSecrets(ctx context.Context, input *CleanupScope) error {
+	client := secretsmanager.New(input.Session)
+
+	secretsToDelete := []*secretsmanager.SecretListEntry{}
+	pageFunc := func(page *secretsmanager.ListSecretsOutput, _ bool) bool {
+		for _, secret := range page.SecretList {
+			if secret.DeletedDate != nil {
+				a.logger.Debug("secret %s is already scheduled for deletion, skipping cleanup", *secret.Name)
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "secret", ID: *secret.Name, ARN: aws.StringValue(secret.ARN), Tags: secretsManagerTags(secret.Tags), CreatedAt: aws.TimeValue(secret.CreatedDate)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "secret", *secret.Name, func() error {
+					return a.markSecretForFutureDeletion(ctx, *secret.ARN, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding secret %s to delete list", *secret.Name)
+			secretsToDelete = append(secretsToDelete, secret)
+		}
+
+		return true
+	}
+
+	if err := client.ListSecretsPagesWithContext(ctx, &secretsmanager.ListSecretsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of secrets: %w", err)
+	}
+
+	if len(secretsToDelete) == 0 {
+		a.logger.Info("no secrets to delete")
+		return nil
+	}
+
+	for _, secret := range secretsToDelete {
+		if !a.canDelete(input, "secret", *secret.Name) {
+			continue
+		}
+
+		params := &secretsmanager.DeleteSecretInput{SecretId: secret.ARN}
+		if input.SecretsForceDelete {
+			a.logger.Info("Deleting secret %s without recovery", *secret.Name)
+			params.ForceDeleteWithoutRecovery = aws.Bool(true)
+		} else {
+			a.logger.Info("Deleting secret %s with a recovery window of %d days", *secret.Name, input.SecretsRecoveryWindow)
+			params.RecoveryWindowInDays = aws.Int64(input.SecretsRecoveryWindow)
+		}
+
+		if _, err := client.DeleteSecretWithContext(ctx, params); err != nil {
+			a.logger.Error("failed to delete secret %s: %s", *secret.Name, err.Error())
+			input.Report.failed(input.Region, "secret", *secret.Name, err.Error())
+			continue
+		}
+
+		if input.SecretsForceDelete {
+			input.Report.deleted(input.Region, "secret", *secret.Name)
+		} else {
+			input.Report.scheduled(input.Region, "secret", *secret.Name, fmt.Sprintf("recovery window of %d days", input.SecretsRecoveryWindow))
+		}
+	}
+
+	return nil
+}
+
+func (a *action) markSecretForFutureDeletion(ctx context.Context, arn string, client *secretsmanager.SecretsManager) error {
+	a.logger.Info("Marking secret %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &secretsmanager.TagResourceInput{
+		SecretId: &arn,
+		Tags:     secretsManagerTagList(a.markTags()),
+	})
+
+	return err
+}
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sfn"
+)
+
+func (a *action) cleanStateMachines(ctx context.Context, input *CleanupScope) error {
+	client := sfn.New(input.Session)
+
+	machinesToDelete := []*sfn.StateMachineListItem{}
+	pageFunc := func(page *sfn.ListStateMachinesOutput, _ bool) bool {
+		for _, machine := range page.StateMachines {
+			name := aws.StringValue(machine.Name)
+
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &sfn.ListTagsForResourceInput{ResourceArn: machine.StateMachineArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for state machine %s: %s", name, err.Error())
+				continue
+			}
+
+			tags := sfnTags(tagOut.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("state machine %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", name)
+				input.Report.managedByStack(input.Region, "state machine", name, cloudFormationStack(tags))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "state machine", ID: name, ARN: aws.StringValue(machine.StateMachineArn), Tags: tags, CreatedAt: aws.TimeValue(machine.CreationDate)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "state machine", name, func() error {
+					return a.markStateMachineForFutureDeletion(ctx, aws.StringValue(machine.StateMachineArn), client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding state machine %s to delete list", name)
+			machinesToDelete = append(machinesToDelete, machine)
+		}
+
+		return true
+	}
+
+	if err := client.ListStateMachinesPagesWithContext(ctx, &sfn.ListStateMachinesInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of state machines: %w", err)
+	}
+
+	if len(machinesToDelete) == 0 {
+		a.logger.Info("no state machines to delete")
+		return nil
+	}
+
+	deleted := 0
+	for _, machine := range machinesToDelete {
+		name := aws.StringValue(machine.Name)
+		if !a.canDelete(input, "state machine", name) {
+			continue
+		}
+
+		running, err := a.deleteStateMachine(ctx, machine, input, client)
+		if err != nil {
+			if isAlreadyDeleted(a.logger, err, "state machine", name) {
+				input.Report.skipped(input.Region, "state machine", name, "already deleted")
+				continue
+			}
+			a.logger.Error("failed to delete state machine %s: %s", name, err.Error())
+			input.Report.failed(input.Region, "state machine", name, err.Error())
+			continue
+		}
+
+		// a state machine with running executions is only deleted once they're done.
+		if running > 0 {
+			input.Report.scheduled(input.Region, "state machine", name, fmt.Sprintf("deleted once its %d running executions finish", running))
+			continue
+		}
+		input.Report.deleted(input.Region, "state machine", name)
+		deleted++
+	}
+
+	if a.commit {
+		a.logger.Info("Deleted %d state machines", deleted)
+	}
+
+	return nil
+}
+
+func (a *action) markStateMachineForFutureDeletion(ctx context.Context, arn string, client *sfn.SFN) error {
+	a.logger.Info("Marking State Machine %s for future deletion", arn)
+
+	_, err := client.TagResourceWithContext(ctx, &sfn.TagResourceInput{
+		ResourceArn: &arn,
+		Tags:        sfnTagList(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteStateMachine deletes the state machine, after stopping its running executions if
+// input.StopStateMachineExecutions is set. It returns how many executions are still running,
+// which the deletion waits for.
+func (a *action) deleteStateMachine(ctx context.Context, machine *sfn.StateMachineListItem, input *CleanupScope, client *sfn.SFN) (int, error) {
+	name := aws.StringValue(machine.Name)
+	a.logger.Info("Deleting State Machine %s", name)
+
+	// the executions of express state machines can't be listed, nor stopped.
+	executionArns := []*string{}
+	if aws.StringValue(machine.Type) == sfn.StateMachineTypeStandard {
+		if err := client.ListExecutionsPagesWithContext(ctx, &sfn.ListExecutionsInput{
+			StateMachineArn: machine.StateMachineArn,
+			StatusFilter:    aws.String(sfn.ExecutionStatusRunning),
+		}, func(page *sfn.ListExecutionsOutput, _ bool) bool {
+			for _, execution := range page.Executions {
+				executionArns = append(executionArns, execution.ExecutionArn)
+			}
+			return true
+		}); err != nil {
+			return 0, fmt.Errorf("failed to list running executions: %w", err)
+		}
+	}
+
+	if input.StopStateMachineExecutions {
+		for _, executionArn := range executionArns {
+			a.logger.Debug("Stopping execution %s of state machine %s", *executionArn, name)
+			if _, err := client.StopExecutionWithContext(ctx, &sfn.StopExecutionInput{
+				ExecutionArn: executionArn,
+				Cause:        aws.String("state machine deleted by aws-janitor"),
+			}); err != nil {
+				return 0, fmt.Errorf("failed to stop execution %s: %w", *executionArn, err)
+			}
+		}
+		executionArns = nil
+	}
+
+	if _, err := client.DeleteStateMachineWithContext(ctx, &sfn.DeleteStateMachineInput{StateMachineArn: machine.StateMachineArn}); err != nil {
+		return 0, err
+	}
+
+	return len(executionArns), nil
+}
@@ -18,39 +18,34 @@ func (a *action) cleanSecurityGroups(ctx context.Context, input *CleanupScope) e
 	pageFunc := func(page *ec2.DescribeVpcsOutput, _ bool) bool {
 		sgPageFunc := func(sgPage *ec2.GetSecurityGroupsForVpcOutput, _ bool) bool {
 			for _, sg := range sgPage.SecurityGroupForVpcs {
-				var ignore, markedForDeletion bool
-				for _, tag := range sg.Tags {
-					switch aws.StringValue(tag.Key) {
-					case input.IgnoreTag:
-						ignore = true
-					case DeletionTag:
-						markedForDeletion = true
-					}
-				}
-
-				if ignore || *sg.GroupName == "default" {
-					LogDebug("security group %s has ignore tag or is a default security group, skipping cleanup", *sg.GroupId)
+				if *sg.GroupName == "default" {
+					a.logger.Debug("security group %s is a default security group, skipping cleanup", *sg.GroupId)
+					input.Report.skipped(input.Region, "security group", *sg.GroupId, "default security group")
 					continue
 				}
 
-				if !markedForDeletion {
-					// NOTE: only mark for future deletion if we're not running in dry-mode
-					if a.commit {
-						LogDebug("security group %s does not have deletion tag, marking for future deletion and skipping cleanup", *sg.GroupId)
-						if err := a.markSecurityGroupForFutureDeletion(ctx, *sg.GroupId, client); err != nil {
-							LogError("failed to mark security group %s for future deletion: %s", *sg.GroupId, err.Error())
-						}
-					}
+				switch input.evaluate(resource{Type: "security group", ID: *sg.GroupId, Tags: ec2Tags(sg.Tags), Stampable: true}) {
+				case verdictSkip:
+					continue
+				case verdictStamp:
+					a.stampFirstSeen(input, "security group", *sg.GroupId, func(tags Tags) error {
+						return a.tagEC2Resource(ctx, *sg.GroupId, tags, client)
+					})
+					continue
+				case verdictMark:
+					a.markForDeletion(input, "security group", *sg.GroupId, func() error {
+						return a.markSecurityGroupForFutureDeletion(ctx, *sg.GroupId, client)
+					})
 					continue
 				}
 
 				securityGroups, err := client.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []*string{sg.GroupId}})
 				if err != nil || len(securityGroups.SecurityGroups) != 1 {
-					LogError("failed to describe security group %s: %s", *sg.GroupId, err.Error())
+					a.logger.Error("failed to describe security group %s: %s", *sg.GroupId, err.Error())
 					continue
 				}
 
-				LogDebug("adding security group %s to delete list", *sg.GroupId)
+				a.logger.Debug("adding security group %s to delete list", *sg.GroupId)
 				sgsToDelete = append(sgsToDelete, securityGroups.SecurityGroups[0])
 			}
 
@@ -58,21 +53,13 @@ func (a *action) cleanSecurityGroups(ctx context.Context, input *CleanupScope) e
 		}
 
 		for _, vpc := range page.Vpcs {
-			var ignore bool
-			for _, tag := range vpc.Tags {
-				switch aws.StringValue(tag.Key) {
-				case input.IgnoreTag:
-					ignore = true
-				}
-			}
-
-			if ignore || aws.BoolValue(vpc.IsDefault) {
-				LogDebug("vpc %s has ignore tag or is a default vpc, won't delete security groups associated with it", *vpc.VpcId)
+			if input.isIgnored(ec2Tags(vpc.Tags)) || aws.BoolValue(vpc.IsDefault) {
+				a.logger.Debug("vpc %s has ignore tag or is a default vpc, won't delete security groups associated with it", *vpc.VpcId)
 				continue
 			}
 
 			if err := client.GetSecurityGroupsForVpcPagesWithContext(ctx, &ec2.GetSecurityGroupsForVpcInput{VpcId: vpc.VpcId}, sgPageFunc); err != nil {
-				LogError("failed getting list of security groups for vpc %s: %s", *vpc.VpcId, err.Error())
+				a.logger.Error("failed getting list of security groups for vpc %s: %s", *vpc.VpcId, err.Error())
 				continue
 			}
 
@@ -86,7 +73,7 @@ func (a *action) cleanSecurityGroups(ctx context.Context, input *CleanupScope) e
 	}
 
 	if len(sgsToDelete) == 0 {
-		Log("no security groups to delete")
+		a.logger.Info("no security groups to delete")
 		return nil
 	}
 
@@ -95,25 +82,28 @@ func (a *action) cleanSecurityGroups(ctx context.Context, input *CleanupScope) e
 	// so we need to delete the rules first.
 	for _, securityGroup := range sgsToDelete {
 		if !a.commit {
-			LogDebug("skipping deletion of security group %s as running in dry-mode", *securityGroup.GroupId)
+			a.logger.Debug("skipping deletion of security group %s as running in dry-mode", *securityGroup.GroupId)
+			continue
+		}
+
+		if !a.isConfirmed(input.AccountID, input.Region, "security group", *securityGroup.GroupId) {
 			continue
 		}
 
 		if err := a.deleteSecurityGroupRules(ctx, *securityGroup.GroupId, securityGroup.IpPermissions, securityGroup.IpPermissionsEgress, client); err != nil {
-			LogError("failed to delete security group rules for %s: %s", *securityGroup.GroupId, err.Error())
+			a.logger.Error("failed to delete security group rules for %s: %s", *securityGroup.GroupId, err.Error())
 		}
 
 	}
 
 	for _, securityGroup := range sgsToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of security group %s as running in dry-mode", *securityGroup.GroupId)
+		if !a.canDelete(input, "security group", *securityGroup.GroupId) {
 			continue
 		}
 
-		_ = waitUntil(ctx, 2*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+		if err := waitUntil(ctx, 2*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
 			if err := a.deleteSecurityGroup(ctx, *securityGroup.GroupId, client); err != nil {
-				LogWarning("attempt to delete security group %s failed: %s", *securityGroup.GroupId, err.Error())
+				a.logger.Warn("attempt to delete security group %s failed: %s", *securityGroup.GroupId, err.Error())
 				// Refresh SG permissions in case rules changed between attempts
 				desc, dErr := client.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: []*string{securityGroup.GroupId}})
 				if dErr == nil && len(desc.SecurityGroups) == 1 {
@@ -122,26 +112,25 @@ func (a *action) cleanSecurityGroups(ctx context.Context, input *CleanupScope) e
 				return false, nil
 			}
 			return true, nil
-		})
+		}); err != nil {
+			a.logger.Error("failed to delete security group %s: %s", *securityGroup.GroupId, err.Error())
+			input.Report.failed(input.Region, "security group", *securityGroup.GroupId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "security group", *securityGroup.GroupId)
 	}
 
 	return nil
 }
 
 func (a *action) markSecurityGroupForFutureDeletion(ctx context.Context, sgId string, client *ec2.EC2) error {
-	Log("Marking Security Group %s for future deletion", sgId)
-
-	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
-		Resources: []*string{&sgId}, Tags: []*ec2.Tag{
-			{Key: aws.String(DeletionTag), Value: aws.String("true")},
-		},
-	})
+	a.logger.Info("Marking Security Group %s for future deletion", sgId)
 
-	return err
+	return a.tagEC2Resource(ctx, sgId, a.markTags(), client)
 }
 
 func (a *action) deleteSecurityGroupRules(ctx context.Context, sgId string, sgIngress, sgEgress []*ec2.IpPermission, client *ec2.EC2) error {
-	Log("Deleting Ingress/Egress Rules from security group %s", sgId)
+	a.logger.Info("Deleting Ingress/Egress Rules from security group %s", sgId)
 
 	if len(sgIngress) != 0 {
 		if _, err := client.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{GroupId: &sgId, IpPermissions: sgIngress}); err != nil {
@@ -159,7 +148,7 @@ func (a *action) deleteSecurityGroupRules(ctx context.Context, sgId string, sgIn
 }
 
 func (a *action) deleteSecurityGroup(ctx context.Context, sgId string, client *ec2.EC2) error {
-	Log("Deleting Security Group %s", sgId)
+	a.logger.Info("Deleting Security Group %s", sgId)
 
 	maxRetries := 5
 	retryDelay := 30 * time.Second
@@ -167,10 +156,10 @@ func (a *action) deleteSecurityGroup(ctx context.Context, sgId string, client *e
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if _, err := client.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{GroupId: &sgId}); err != nil {
 			if attempt < maxRetries && a.isDependencyViolation(err) {
-				LogDebug("Security group %s has dependencies, retrying in %v (attempt %d/%d)", sgId, retryDelay, attempt, maxRetries)
+				a.logger.Debug("Security group %s has dependencies, retrying in %v (attempt %d/%d)", sgId, retryDelay, attempt, maxRetries)
 
 				if err := a.handleSecurityGroupDependencies(ctx, sgId, client); err != nil {
-					LogDebug("Failed to handle dependencies for security group %s: %v", sgId, err)
+					a.logger.Debug("Failed to handle dependencies for security group %s: %v", sgId, err)
 				}
 
 				time.Sleep(retryDelay)
@@ -207,11 +196,11 @@ func (a *action) handleSecurityGroupDependencies(ctx context.Context, sgId strin
 	}
 
 	for _, eni := range eniResp.NetworkInterfaces {
-		LogDebug("Security group %s is used by network interface %s (status: %s)",
+		a.logger.Debug("Security group %s is used by network interface %s (status: %s)",
 			sgId, aws.StringValue(eni.NetworkInterfaceId), aws.StringValue(eni.Status))
 
 		if aws.StringValue(eni.Status) == "available" {
-			LogDebug("Network interface %s is available but not being deleted automatically for safety",
+			a.logger.Debug("Network interface %s is available but not being deleted automatically for safety",
 				aws.StringValue(eni.NetworkInterfaceId))
 		}
 	}
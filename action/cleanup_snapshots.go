@@ -0,0 +1,105 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanSnapshots(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	// snapshots backing a registered AMI can't be deleted until the AMI is deregistered.
+	imageSnapshots, err := a.getImageSnapshots(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed getting snapshots used by images: %w", err)
+	}
+
+	snapshotsToDelete := []*string{}
+	pageFunc := func(page *ec2.DescribeSnapshotsOutput, _ bool) bool {
+		for _, snapshot := range page.Snapshots {
+			if imageId, ok := imageSnapshots[*snapshot.SnapshotId]; ok {
+				a.logger.Warn("snapshot %s is used by image %s, skipping cleanup", *snapshot.SnapshotId, imageId)
+				input.Report.skipped(input.Region, "snapshot", *snapshot.SnapshotId, "used by image "+imageId)
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "snapshot", ID: *snapshot.SnapshotId, Tags: ec2Tags(snapshot.Tags), CreatedAt: aws.TimeValue(snapshot.StartTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "snapshot", *snapshot.SnapshotId, func() error {
+					return a.markSnapshotForFutureDeletion(ctx, *snapshot.SnapshotId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding snapshot %s to delete list", *snapshot.SnapshotId)
+			snapshotsToDelete = append(snapshotsToDelete, snapshot.SnapshotId)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeSnapshotsPagesWithContext(ctx, &ec2.DescribeSnapshotsInput{OwnerIds: []*string{aws.String("self")}}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of snapshots: %w", err)
+	}
+
+	if len(snapshotsToDelete) == 0 {
+		a.logger.Info("no snapshots to delete")
+		return nil
+	}
+
+	for _, snapshotId := range snapshotsToDelete {
+		if !a.canDelete(input, "snapshot", *snapshotId) {
+			continue
+		}
+
+		a.logger.Info("Deleting Snapshot %s", *snapshotId)
+		if _, err := client.DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{SnapshotId: snapshotId}); err != nil {
+			a.logger.Error("failed to delete snapshot %s: %s", *snapshotId, err.Error())
+			input.Report.failed(input.Region, "snapshot", *snapshotId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "snapshot", *snapshotId)
+	}
+
+	return nil
+}
+
+// getImageSnapshots returns the ids of the snapshots backing images owned by the account,
+// mapped to the id of the image using them.
+func (a *action) getImageSnapshots(ctx context.Context, client *ec2.EC2) (map[string]string, error) {
+	snapshots := map[string]string{}
+
+	pageFunc := func(page *ec2.DescribeImagesOutput, _ bool) bool {
+		for _, image := range page.Images {
+			for _, mapping := range image.BlockDeviceMappings {
+				if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+					snapshots[*mapping.Ebs.SnapshotId] = aws.StringValue(image.ImageId)
+				}
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{Owners: []*string{aws.String("self")}}, pageFunc); err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+func (a *action) markSnapshotForFutureDeletion(ctx context.Context, snapshotId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Snapshot %s for future deletion", snapshotId)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&snapshotId},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
@@ -0,0 +1,123 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// snsPendingConfirmation is the arn returned for the subscriptions that weren't confirmed yet.
+const snsPendingConfirmation = "PendingConfirmation"
+
+func (a *action) cleanSNSTopics(ctx context.Context, input *CleanupScope) error {
+	client := sns.New(input.Session)
+
+	topicsToDelete := []*string{}
+	pageFunc := func(page *sns.ListTopicsOutput, _ bool) bool {
+		for _, topic := range page.Topics {
+			tagOut, err := client.ListTagsForResourceWithContext(ctx, &sns.ListTagsForResourceInput{ResourceArn: topic.TopicArn})
+			if err != nil {
+				a.logger.Error("failed getting tags for sns topic %s: %s", *topic.TopicArn, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "sns topic", ID: *topic.TopicArn, Tags: snsTags(tagOut.Tags), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "sns topic", *topic.TopicArn, func(tags Tags) error {
+					return a.tagSNSTopic(ctx, *topic.TopicArn, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "sns topic", *topic.TopicArn, func() error {
+					return a.markSNSTopicForFutureDeletion(ctx, *topic.TopicArn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding sns topic %s to delete list", *topic.TopicArn)
+			topicsToDelete = append(topicsToDelete, topic.TopicArn)
+		}
+
+		return true
+	}
+
+	if err := client.ListTopicsPagesWithContext(ctx, &sns.ListTopicsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of sns topics: %w", err)
+	}
+
+	if len(topicsToDelete) == 0 {
+		a.logger.Info("no sns topics to delete")
+		return nil
+	}
+
+	for _, topicArn := range topicsToDelete {
+		if !a.canDelete(input, "sns topic", *topicArn) {
+			continue
+		}
+
+		if err := a.deleteSNSTopic(ctx, *topicArn, client); err != nil {
+			a.logger.Error("failed to delete sns topic %s: %s", *topicArn, err.Error())
+			input.Report.failed(input.Region, "sns topic", *topicArn, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "sns topic", *topicArn)
+	}
+
+	return nil
+}
+
+func (a *action) markSNSTopicForFutureDeletion(ctx context.Context, topicArn string, client *sns.SNS) error {
+	a.logger.Info("Marking SNS topic %s for future deletion", topicArn)
+
+	return a.tagSNSTopic(ctx, topicArn, a.markTags(), client)
+}
+
+func (a *action) tagSNSTopic(ctx context.Context, topicArn string, tags Tags, client *sns.SNS) error {
+	_, err := client.TagResourceWithContext(ctx, &sns.TagResourceInput{
+		ResourceArn: &topicArn,
+		Tags:        snsTagList(tags),
+	})
+
+	return err
+}
+
+// deleteSNSTopic unsubscribes the confirmed subscriptions of the topic, then deletes it.
+func (a *action) deleteSNSTopic(ctx context.Context, topicArn string, client *sns.SNS) error {
+	a.logger.Info("Deleting SNS topic %s", topicArn)
+
+	subscriptions := []*string{}
+	pageFunc := func(page *sns.ListSubscriptionsByTopicOutput, _ bool) bool {
+		for _, subscription := range page.Subscriptions {
+			// pending subscriptions have no arn to unsubscribe with, they expire on their own
+			// and are removed along with the topic.
+			if aws.StringValue(subscription.SubscriptionArn) == snsPendingConfirmation {
+				a.logger.Debug("subscription of %s to sns topic %s is pending confirmation, can't unsubscribe it", aws.StringValue(subscription.Endpoint), topicArn)
+				continue
+			}
+			subscriptions = append(subscriptions, subscription.SubscriptionArn)
+		}
+
+		return true
+	}
+
+	if err := client.ListSubscriptionsByTopicPagesWithContext(ctx, &sns.ListSubscriptionsByTopicInput{TopicArn: &topicArn}, pageFunc); err != nil {
+		return fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	for _, subscriptionArn := range subscriptions {
+		a.logger.Debug("Unsubscribing %s from sns topic %s", *subscriptionArn, topicArn)
+		if _, err := client.UnsubscribeWithContext(ctx, &sns.UnsubscribeInput{SubscriptionArn: subscriptionArn}); err != nil {
+			return fmt.Errorf("failed to unsubscribe %s: %w", *subscriptionArn, err)
+		}
+	}
+
+	if _, err := client.DeleteTopicWithContext(ctx, &sns.DeleteTopicInput{TopicArn: &topicArn}); err != nil {
+		return fmt.Errorf("failed to delete sns topic %s: %w", topicArn, err)
+	}
+
+	return nil
+}
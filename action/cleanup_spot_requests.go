@@ -0,0 +1,118 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// cleanSpotRequests cancels the open and active spot instance requests, before the instance cleaner
+// runs so the persistent ones don't launch the terminated instances again.
+func (a *action) cleanSpotRequests(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	requestsToDelete := []*ec2.SpotInstanceRequest{}
+	pageFunc := func(page *ec2.DescribeSpotInstanceRequestsOutput, _ bool) bool {
+		for _, request := range page.SpotInstanceRequests {
+			id := aws.StringValue(request.SpotInstanceRequestId)
+
+			switch input.evaluate(resource{Type: "spot request", ID: id, Tags: ec2Tags(request.Tags), CreatedAt: aws.TimeValue(request.CreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "spot request", id, func() error {
+					return a.markSpotRequestForFutureDeletion(ctx, id, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding spot request %s to delete list", id)
+			requestsToDelete = append(requestsToDelete, request)
+		}
+
+		return true
+	}
+
+	// the cancelled and closed requests don't launch instances anymore, so they're left alone.
+	if err := client.DescribeSpotInstanceRequestsPagesWithContext(ctx, &ec2.DescribeSpotInstanceRequestsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.SpotInstanceStateOpen, ec2.SpotInstanceStateActive})},
+		},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of spot requests: %w", err)
+	}
+
+	if len(requestsToDelete) == 0 {
+		a.logger.Info("no spot requests to delete")
+		return nil
+	}
+
+	for _, request := range requestsToDelete {
+		id := aws.StringValue(request.SpotInstanceRequestId)
+		if !a.canDelete(input, "spot request", id) {
+			continue
+		}
+
+		a.logger.Info("Cancelling Spot request %s", id)
+		if _, err := client.CancelSpotInstanceRequestsWithContext(ctx, &ec2.CancelSpotInstanceRequestsInput{SpotInstanceRequestIds: []*string{&id}}); err != nil {
+			a.logger.Error("failed to cancel spot request %s: %s", id, err.Error())
+			input.Report.failed(input.Region, "spot request", id, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "spot request", id)
+
+		if input.TerminateSpotInstances && request.InstanceId != nil {
+			a.terminateSpotInstance(ctx, aws.StringValue(request.InstanceId), input, client)
+		}
+	}
+
+	return nil
+}
+
+func (a *action) markSpotRequestForFutureDeletion(ctx context.Context, id string, client *ec2.EC2) error {
+	a.logger.Info("Marking Spot request %s for future deletion", id)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&id},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
+
+// terminateSpotInstance terminates the instance launched by a cancelled spot request, as cancelling
+// the request leaves it running, unless it's protected.
+func (a *action) terminateSpotInstance(ctx context.Context, instanceID string, input *CleanupScope, client *ec2.EC2) {
+	out, err := client.DescribeInstancesWithContext(ctx, &ec2.DescribeInstancesInput{InstanceIds: []*string{&instanceID}})
+	if err != nil {
+		if !isAlreadyDeleted(a.logger, err, "instance", instanceID) {
+			a.logger.Error("failed getting instance %s: %s", instanceID, err.Error())
+			input.Report.failed(input.Region, "instance", instanceID, err.Error())
+		}
+		return
+	}
+
+	for _, reservation := range out.Reservations {
+		for _, instance := range reservation.Instances {
+			switch aws.StringValue(instance.State.Name) {
+			case ec2.InstanceStateNameShuttingDown, ec2.InstanceStateNameTerminated:
+				return
+			}
+			if input.isIgnored(ec2Tags(instance.Tags)) || input.ExcludeIDs[instanceID] {
+				a.logger.Debug("instance %s of a cancelled spot request is protected, skipping termination", instanceID)
+				input.Report.skipped(input.Region, "instance", instanceID, "has ignore tag or is excluded")
+				return
+			}
+		}
+	}
+
+	a.logger.Info("Terminating Instance %s", instanceID)
+	if _, err := client.TerminateInstancesWithContext(ctx, &ec2.TerminateInstancesInput{InstanceIds: []*string{&instanceID}}); err != nil {
+		a.logger.Error("failed to terminate instance %s: %s", instanceID, err.Error())
+		input.Report.failed(input.Region, "instance", instanceID, err.Error())
+		return
+	}
+	input.Report.deleted(input.Region, "instance", instanceID)
+}
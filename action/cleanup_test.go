@@ -0,0 +1,57 @@
+package action
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateWhileApplyingPlan(t *testing.T) {
+	a := newTestAction(t, true)
+	a.mode = ModeDeleteOnly
+	createdAt := time.Now().Add(-time.Hour)
+	plan := map[string]bool{
+		approvalKey("", "us-east-1", "volume", "vol-planned"): true,
+	}
+
+	tests := []struct {
+		name      string
+		immediate bool
+		r         resource
+		want      verdict
+	}{
+		{
+			name:      "unmarked immediate resource in the plan",
+			immediate: true,
+			r:         resource{Type: "volume", ID: "vol-planned", CreatedAt: createdAt},
+			want:      verdictDelete,
+		},
+		{
+			name:      "unmarked immediate resource missing from the plan",
+			immediate: true,
+			r:         resource{Type: "volume", ID: "vol-other", CreatedAt: createdAt},
+			want:      verdictSkip,
+		},
+		{
+			name: "unmarked resource in the plan whose type is no longer deleted immediately",
+			r:    resource{Type: "volume", ID: "vol-planned", CreatedAt: createdAt},
+			want: verdictSkip,
+		},
+		{
+			name: "marked resource",
+			r:    resource{Type: "volume", ID: "vol-marked", Tags: Tags{DeletionTag: "2020-01-02T15:04:05Z"}, CreatedAt: createdAt},
+			want: verdictDelete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := newTestScope(a, "us-east-1")
+			input.Immediate = tt.immediate
+			input.Plan = plan
+
+			if got := input.evaluate(tt.r); got != tt.want {
+				t.Fatalf("got verdict %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func (a *action) cleanTargetGroups(ctx context.Context, input *CleanupScope) error {
+	client := elbv2.New(input.Session)
+
+	tgsToDelete := []*string{}
+
+	pageFunc := func(page *elbv2.DescribeTargetGroupsOutput, _ bool) bool {
+		for _, tg := range page.TargetGroups {
+			// the target groups are reported by the arn they're deleted by, like the v2 load balancers.
+			arn := aws.StringValue(tg.TargetGroupArn)
+			// target groups used by a listener or rule are always associated with its load balancer.
+			if len(tg.LoadBalancerArns) > 0 {
+				a.logger.Debug("target group %s is used by a load balancer, skipping cleanup", arn)
+				input.Report.skipped(input.Region, "target group", arn, "used by a load balancer")
+				continue
+			}
+
+			tagOut, err := client.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: []*string{tg.TargetGroupArn}})
+			if err != nil {
+				a.logger.Error("failed getting tags for target group %s: %s", arn, err.Error())
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "target group", ID: arn, ARN: arn, Name: aws.StringValue(tg.TargetGroupName), Tags: elbv2Tags(tagOut.TagDescriptions), Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "target group", arn, func(tags Tags) error {
+					return a.tagTargetGroup(ctx, arn, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "target group", arn, func() error {
+					return a.markTargetGroupForFutureDeletion(ctx, arn, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding target group %s to delete list", arn)
+			tgsToDelete = append(tgsToDelete, tg.TargetGroupArn)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeTargetGroupsPagesWithContext(ctx, &elbv2.DescribeTargetGroupsInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of target groups: %w", err)
+	}
+
+	if len(tgsToDelete) == 0 {
+		a.logger.Info("no unused target groups to delete")
+		return nil
+	}
+
+	for _, arn := range tgsToDelete {
+		if !a.canDelete(input, "target group", aws.StringValue(arn)) {
+			continue
+		}
+
+		a.logger.Info("Deleting target group %s", aws.StringValue(arn))
+		if _, err := client.DeleteTargetGroupWithContext(ctx, &elbv2.DeleteTargetGroupInput{TargetGroupArn: arn}); err != nil {
+			a.logger.Error("failed to delete target group %s: %s", aws.StringValue(arn), err.Error())
+			input.Report.failed(input.Region, "target group", aws.StringValue(arn), err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "target group", aws.StringValue(arn))
+	}
+
+	return nil
+}
+
+func (a *action) markTargetGroupForFutureDeletion(ctx context.Context, tgArn string, client *elbv2.ELBV2) error {
+	a.logger.Info("Marking target group %s for future deletion", tgArn)
+	return a.tagTargetGroup(ctx, tgArn, a.markTags(), client)
+}
+
+func (a *action) tagTargetGroup(ctx context.Context, tgArn string, tags Tags, client *elbv2.ELBV2) error {
+	_, err := client.AddTagsWithContext(ctx, &elbv2.AddTagsInput{
+		ResourceArns: []*string{aws.String(tgArn)},
+		Tags:         elbv2TagList(tags),
+	})
+	return err
+}
@@ -0,0 +1,273 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// transitGatewayTimeout is how long to wait for the attachments and route tables of a transit gateway to be deleted.
+const transitGatewayTimeout = 10 * time.Minute
+
+func (a *action) cleanTransitGateways(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	gatewaysToDelete := []*ec2.TransitGateway{}
+	pageFunc := func(page *ec2.DescribeTransitGatewaysOutput, _ bool) bool {
+		for _, tgw := range page.TransitGateways {
+			id := aws.StringValue(tgw.TransitGatewayId)
+
+			// the transit gateways shared by other accounts are listed too, they're left to their owner.
+			if input.AccountID != "" && aws.StringValue(tgw.OwnerId) != input.AccountID {
+				a.logger.Debug("transit gateway %s is owned by account %s, skipping cleanup", id, aws.StringValue(tgw.OwnerId))
+				continue
+			}
+
+			tags := ec2Tags(tgw.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("transit gateway %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", id)
+				input.Report.managedByStack(input.Region, "transit gateway", id, cloudFormationStack(tags))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "transit gateway", ID: id, ARN: aws.StringValue(tgw.TransitGatewayArn), Tags: tags, CreatedAt: aws.TimeValue(tgw.CreationTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "transit gateway", id, func() error {
+					return a.markTransitGatewayForFutureDeletion(ctx, id, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding transit gateway %s to delete list", id)
+			gatewaysToDelete = append(gatewaysToDelete, tgw)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeTransitGatewaysPagesWithContext(ctx, &ec2.DescribeTransitGatewaysInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("state"), Values: []*string{aws.String(ec2.TransitGatewayStateAvailable)}},
+		},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of transit gateways: %w", err)
+	}
+
+	if len(gatewaysToDelete) == 0 {
+		a.logger.Info("no transit gateways to delete")
+		return nil
+	}
+
+	for _, tgw := range gatewaysToDelete {
+		id := aws.StringValue(tgw.TransitGatewayId)
+		if !a.canDelete(input, "transit gateway", id) {
+			continue
+		}
+
+		err := a.deleteTransitGateway(ctx, id, input, client)
+		if errors.Is(err, errAlreadyDeleted) {
+			input.Report.skipped(input.Region, "transit gateway", id, "already deleted")
+			continue
+		}
+		if err != nil {
+			a.logger.Error("failed to delete transit gateway %s: %s", id, err.Error())
+			input.Report.failed(input.Region, "transit gateway", id, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "transit gateway", id)
+	}
+
+	return nil
+}
+
+func (a *action) markTransitGatewayForFutureDeletion(ctx context.Context, id string, client *ec2.EC2) error {
+	a.logger.Info("Marking Transit Gateway %s for future deletion", id)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&id},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
+
+// deleteTransitGateway deletes the vpc attachments of the transit gateway, then its route tables
+// and the gateway itself. Gateways with other kinds of attachments, e.g. vpn or peering, or with a
+// protected attachment are left alone.
+func (a *action) deleteTransitGateway(ctx context.Context, id string, input *CleanupScope, client *ec2.EC2) error {
+	a.logger.Info("Deleting Transit Gateway %s and its attachments", id)
+
+	attachments, err := a.getTransitGatewayAttachments(ctx, id, client)
+	if err != nil {
+		return fmt.Errorf("failed to describe transit gateway attachments: %w", err)
+	}
+
+	for _, attachment := range attachments {
+		attachmentId := aws.StringValue(attachment.TransitGatewayAttachmentId)
+		if resourceType := aws.StringValue(attachment.ResourceType); resourceType != ec2.TransitGatewayAttachmentResourceTypeVpc {
+			return fmt.Errorf("transit gateway has a %s attachment %s, which must be deleted first", resourceType, attachmentId)
+		}
+		if input.isIgnored(ec2Tags(attachment.Tags)) || input.ExcludeIDs[attachmentId] {
+			return fmt.Errorf("transit gateway attachment %s is protected", attachmentId)
+		}
+	}
+
+	for _, attachment := range attachments {
+		if aws.StringValue(attachment.State) == ec2.TransitGatewayAttachmentStateDeleting {
+			continue
+		}
+
+		a.logger.Debug("Deleting attachment %s of transit gateway %s to vpc %s", aws.StringValue(attachment.TransitGatewayAttachmentId), id, aws.StringValue(attachment.ResourceId))
+		if _, err := client.DeleteTransitGatewayVpcAttachmentWithContext(ctx, &ec2.DeleteTransitGatewayVpcAttachmentInput{
+			TransitGatewayAttachmentId: attachment.TransitGatewayAttachmentId,
+		}); err != nil && !isAlreadyDeleted(a.logger, err, "transit gateway attachment", aws.StringValue(attachment.TransitGatewayAttachmentId)) {
+			return fmt.Errorf("failed to delete transit gateway attachment %s: %w", aws.StringValue(attachment.TransitGatewayAttachmentId), err)
+		}
+	}
+
+	// the route tables can't be deleted while attachments are still associated with them.
+	if len(attachments) > 0 {
+		if err := waitUntil(ctx, transitGatewayTimeout, 15*time.Second, func(ctx context.Context) (bool, error) {
+			attachments, err := a.getTransitGatewayAttachments(ctx, id, client)
+			if err != nil {
+				return false, err
+			}
+			return len(attachments) == 0, nil
+		}); err != nil {
+			return fmt.Errorf("failed waiting for transit gateway attachments to be deleted: %w", err)
+		}
+	}
+
+	if err := a.deleteTransitGatewayRouteTables(ctx, id, client); err != nil {
+		return err
+	}
+
+	if _, err := client.DeleteTransitGatewayWithContext(ctx, &ec2.DeleteTransitGatewayInput{TransitGatewayId: &id}); err != nil {
+		if isAlreadyDeleted(a.logger, err, "transit gateway", id) {
+			return errAlreadyDeleted
+		}
+		return fmt.Errorf("failed to delete transit gateway %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// getTransitGatewayAttachments returns the attachments of the transit gateway that weren't deleted.
+func (a *action) getTransitGatewayAttachments(ctx context.Context, id string, client *ec2.EC2) ([]*ec2.TransitGatewayAttachment, error) {
+	attachments := []*ec2.TransitGatewayAttachment{}
+	if err := client.DescribeTransitGatewayAttachmentsPagesWithContext(ctx, &ec2.DescribeTransitGatewayAttachmentsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("transit-gateway-id"), Values: []*string{&id}},
+		},
+	}, func(page *ec2.DescribeTransitGatewayAttachmentsOutput, _ bool) bool {
+		for _, attachment := range page.TransitGatewayAttachments {
+			if isTransitGatewayAttachmentGone(aws.StringValue(attachment.State)) {
+				continue
+			}
+			attachments = append(attachments, attachment)
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// isTransitGatewayAttachmentGone returns true for the states of the attachments that don't hold anything anymore.
+func isTransitGatewayAttachmentGone(state string) bool {
+	switch state {
+	case ec2.TransitGatewayAttachmentStateDeleted, ec2.TransitGatewayAttachmentStateFailed, ec2.TransitGatewayAttachmentStateRejected:
+		return true
+	}
+	return false
+}
+
+// deleteTransitGatewayRouteTables deletes the route tables of the transit gateway, except the default
+// ones which are deleted along with the gateway, and waits for them to be deleted.
+func (a *action) deleteTransitGatewayRouteTables(ctx context.Context, id string, client *ec2.EC2) error {
+	routeTableIds := []*string{}
+	// the route tables already being deleted are only waited on.
+	deletingIds := map[string]bool{}
+	if err := client.DescribeTransitGatewayRouteTablesPagesWithContext(ctx, &ec2.DescribeTransitGatewayRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("transit-gateway-id"), Values: []*string{&id}},
+			{Name: aws.String("default-association-route-table"), Values: []*string{aws.String("false")}},
+		},
+	}, func(page *ec2.DescribeTransitGatewayRouteTablesOutput, _ bool) bool {
+		for _, routeTable := range page.TransitGatewayRouteTables {
+			switch aws.StringValue(routeTable.State) {
+			case ec2.TransitGatewayRouteTableStateDeleted:
+				continue
+			case ec2.TransitGatewayRouteTableStateDeleting:
+				deletingIds[aws.StringValue(routeTable.TransitGatewayRouteTableId)] = true
+			}
+			routeTableIds = append(routeTableIds, routeTable.TransitGatewayRouteTableId)
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to describe transit gateway route tables: %w", err)
+	}
+
+	if len(routeTableIds) == 0 {
+		return nil
+	}
+
+	for _, routeTableId := range routeTableIds {
+		if deletingIds[aws.StringValue(routeTableId)] {
+			continue
+		}
+
+		a.logger.Debug("Deleting route table %s of transit gateway %s", aws.StringValue(routeTableId), id)
+		if _, err := client.DeleteTransitGatewayRouteTableWithContext(ctx, &ec2.DeleteTransitGatewayRouteTableInput{
+			TransitGatewayRouteTableId: routeTableId,
+		}); err != nil && !isAlreadyDeleted(a.logger, err, "transit gateway route table", aws.StringValue(routeTableId)) {
+			return fmt.Errorf("failed to delete transit gateway route table %s: %w", aws.StringValue(routeTableId), err)
+		}
+	}
+
+	if err := waitUntil(ctx, transitGatewayTimeout, 15*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeTransitGatewayRouteTablesWithContext(ctx, &ec2.DescribeTransitGatewayRouteTablesInput{TransitGatewayRouteTableIds: routeTableIds})
+		if err != nil {
+			return false, err
+		}
+		for _, routeTable := range out.TransitGatewayRouteTables {
+			if aws.StringValue(routeTable.State) != ec2.TransitGatewayRouteTableStateDeleted {
+				return false, nil
+			}
+		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for transit gateway route tables to be deleted: %w", err)
+	}
+
+	return nil
+}
+
+// getTransitGatewayVPCAttachments returns the ids of the transit gateway attachments of the vpc that weren't deleted.
+func (a *action) getTransitGatewayVPCAttachments(ctx context.Context, vpcId string, client *ec2.EC2) ([]string, error) {
+	attachmentIds := []string{}
+	if err := client.DescribeTransitGatewayVpcAttachmentsPagesWithContext(ctx, &ec2.DescribeTransitGatewayVpcAttachmentsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeTransitGatewayVpcAttachmentsOutput, _ bool) bool {
+		for _, attachment := range page.TransitGatewayVpcAttachments {
+			if isTransitGatewayAttachmentGone(aws.StringValue(attachment.State)) {
+				continue
+			}
+			attachmentIds = append(attachmentIds, aws.StringValue(attachment.TransitGatewayAttachmentId))
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return attachmentIds, nil
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func (a *action) cleanVolumes(ctx context.Context, input *CleanupScope) error {
+	client := ec2.New(input.Session)
+
+	volumesToDelete := []*ec2.Volume{}
+	pageFunc := func(page *ec2.DescribeVolumesOutput, _ bool) bool {
+		for _, volume := range page.Volumes {
+			tags := ec2Tags(volume.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("volume %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", *volume.VolumeId)
+				input.Report.managedByStack(input.Region, "volume", *volume.VolumeId, cloudFormationStack(tags))
+				continue
+			}
+
+			switch input.evaluate(resource{Type: "volume", ID: *volume.VolumeId, Tags: tags, CreatedAt: aws.TimeValue(volume.CreateTime)}) {
+			case verdictSkip:
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "volume", *volume.VolumeId, func() error {
+					return a.markVolumeForFutureDeletion(ctx, *volume.VolumeId, client)
+				})
+				continue
+			}
+
+			a.logger.Debug("adding volume %s to delete list", *volume.VolumeId)
+			volumesToDelete = append(volumesToDelete, volume)
+		}
+
+		return true
+	}
+
+	if err := client.DescribeVolumesPagesWithContext(ctx, &ec2.DescribeVolumesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("status"), Values: []*string{aws.String(ec2.VolumeStateAvailable)}},
+		},
+	}, pageFunc); err != nil {
+		return fmt.Errorf("failed getting list of volumes: %w", err)
+	}
+
+	if len(volumesToDelete) == 0 {
+		a.logger.Info("no unattached volumes to delete")
+		return nil
+	}
+
+	for _, volume := range volumesToDelete {
+		input.Report.size(input.Region, "volume", *volume.VolumeId, aws.Int64Value(volume.Size))
+		if !a.canDelete(input, "volume", *volume.VolumeId) {
+			continue
+		}
+
+		if err := a.deleteVolume(ctx, volume, client); err != nil {
+			a.logger.Error("failed to delete volume %s: %s", *volume.VolumeId, err.Error())
+			input.Report.failed(input.Region, "volume", *volume.VolumeId, err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "volume", *volume.VolumeId)
+	}
+
+	return nil
+}
+
+func (a *action) markVolumeForFutureDeletion(ctx context.Context, volumeId string, client *ec2.EC2) error {
+	a.logger.Info("Marking Volume %s for future deletion", volumeId)
+
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&volumeId},
+		Tags:      ec2TagList(a.markTags()),
+	})
+
+	return err
+}
+
+func (a *action) deleteVolume(ctx context.Context, volume *ec2.Volume, client *ec2.EC2) error {
+	a.logger.Info("Deleting Volume %s (size %dGiB, created %s)", *volume.VolumeId, aws.Int64Value(volume.Size), aws.TimeValue(volume.CreateTime).UTC().Format(time.RFC3339))
+
+	if _, err := client.DeleteVolumeWithContext(ctx, &ec2.DeleteVolumeInput{VolumeId: volume.VolumeId}); err != nil {
+		return fmt.Errorf("failed to delete volume %s: %w", *volume.VolumeId, err)
+	}
+
+	return nil
+}
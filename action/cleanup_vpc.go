@@ -2,10 +2,15 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"go.uber.org/multierr"
 )
 
 func (a *action) cleanVPCs(ctx context.Context, input *CleanupScope) error {
@@ -14,40 +19,35 @@ func (a *action) cleanVPCs(ctx context.Context, input *CleanupScope) error {
 	vpcsToDelete := []*ec2.Vpc{}
 	pageFunc := func(page *ec2.DescribeVpcsOutput, _ bool) bool {
 		for _, vpc := range page.Vpcs {
-			var ignore, markedForDeletion, managedByCloudFormation bool
-			for _, tag := range vpc.Tags {
-				switch *tag.Key {
-				case input.IgnoreTag:
-					ignore = true
-				case DeletionTag:
-					markedForDeletion = true
-				case "aws:cloudformation:stack-name", "aws:cloudformation:stack-id":
-					managedByCloudFormation = true
-				}
-			}
-
-			if ignore || aws.BoolValue(vpc.IsDefault) {
-				LogDebug("vpc %s has ignore tag or is a default vpc, skipping cleanup", *vpc.VpcId)
+			if aws.BoolValue(vpc.IsDefault) {
+				a.logger.Debug("vpc %s is a default vpc, skipping cleanup", *vpc.VpcId)
+				input.Report.skipped(input.Region, "vpc", *vpc.VpcId, "default vpc")
 				continue
 			}
 
-			if managedByCloudFormation {
-				LogDebug("vpc %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", *vpc.VpcId)
+			tags := ec2Tags(vpc.Tags)
+			if isManagedByCloudFormation(tags) {
+				a.logger.Debug("vpc %s is managed by CloudFormation, should be cleaned by stack deletion, skipping", *vpc.VpcId)
+				input.Report.managedByStack(input.Region, "vpc", *vpc.VpcId, cloudFormationStack(tags))
 				continue
 			}
 
-			if !markedForDeletion {
-				// NOTE: only mark for future deletion if we're not running in dry-mode
-				if a.commit {
-					LogDebug("vpc %s does not have deletion tag, marking for future deletion and skipping cleanup", *vpc.VpcId)
-					if err := a.markVPCForFutureDeletion(ctx, *vpc.VpcId, client); err != nil {
-						LogError("failed to mark vpc %s for future deletion: %s", *vpc.VpcId, err.Error())
-					}
-				}
+			switch input.evaluate(resource{Type: "vpc", ID: *vpc.VpcId, Tags: tags, Stampable: true}) {
+			case verdictSkip:
+				continue
+			case verdictStamp:
+				a.stampFirstSeen(input, "vpc", *vpc.VpcId, func(tags Tags) error {
+					return a.tagEC2Resource(ctx, *vpc.VpcId, tags, client)
+				})
+				continue
+			case verdictMark:
+				a.markForDeletion(input, "vpc", *vpc.VpcId, func() error {
+					return a.markVPCForFutureDeletion(ctx, *vpc.VpcId, client)
+				})
 				continue
 			}
 
-			LogDebug("adding vpc %s to delete list", *vpc.VpcId)
+			a.logger.Debug("adding vpc %s to delete list", *vpc.VpcId)
 			vpcsToDelete = append(vpcsToDelete, vpc)
 		}
 
@@ -59,97 +59,353 @@ func (a *action) cleanVPCs(ctx context.Context, input *CleanupScope) error {
 	}
 
 	if len(vpcsToDelete) == 0 {
-		Log("no vpcs to delete")
+		a.logger.Info("no vpcs to delete")
 		return nil
 	}
 
+	// tearing down the dependencies of a vpc can take minutes, so several vpcs are deleted
+	// at once. The dependencies of each vpc are still deleted one after the other.
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, a.vpcWorkers)
+
 	for _, vpc := range vpcsToDelete {
-		if !a.commit {
-			LogDebug("skipping deletion of vpc %s as running in dry-mode", *vpc.VpcId)
+		if !a.canDelete(input, "vpc", *vpc.VpcId) {
 			continue
 		}
 
-		if err := a.deleteVPC(ctx, *vpc.VpcId, client); err != nil {
-			LogError("failed to delete vpc %s: %s", *vpc.VpcId, err.Error())
+		// a vpc attached to a transit gateway can't be deleted until the attachment is gone,
+		// which is up to the transit gateway cleaner or to the owner of the gateway.
+		attachmentIds, err := a.getTransitGatewayVPCAttachments(ctx, *vpc.VpcId, client)
+		if err != nil {
+			a.logger.Warn("failed to get transit gateway attachments of vpc %s: %s", *vpc.VpcId, err.Error())
+		}
+		if len(attachmentIds) > 0 {
+			a.logger.Debug("vpc %s is attached to a transit gateway, skipping deletion", *vpc.VpcId)
+			input.Report.skipped(input.Region, "vpc", *vpc.VpcId, "has transit gateway attachments "+strings.Join(attachmentIds, ", "))
+			continue
 		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(vpcId string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// the lines of the vpcs being deleted at once interleave, so they're prefixed with the vpc id.
+			logger := prefixedLogger{Logger: a.logger, prefix: "[" + vpcId + "] "}
+			err := a.deleteVPC(ctx, logger, vpcId, input, client)
+			if errors.Is(err, errAlreadyDeleted) {
+				input.Report.skipped(input.Region, "vpc", vpcId, "already deleted")
+				return
+			}
+			if err != nil {
+				logger.Error("failed to delete vpc %s: %s", vpcId, err.Error())
+				input.Report.failed(input.Region, "vpc", vpcId, err.Error())
+				return
+			}
+			input.Report.deleted(input.Region, "vpc", vpcId)
+		}(*vpc.VpcId)
 	}
+	wg.Wait()
 
 	return nil
 }
 
 func (a *action) markVPCForFutureDeletion(ctx context.Context, vpcId string, client *ec2.EC2) error {
-	Log("Marking VPC %s for future deletion", vpcId)
-
-	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
-		Resources: []*string{&vpcId}, Tags: []*ec2.Tag{
-			{Key: aws.String(DeletionTag), Value: aws.String("true")},
-		},
-	})
+	a.logger.Info("Marking VPC %s for future deletion", vpcId)
 
-	return err
+	return a.tagEC2Resource(ctx, vpcId, a.markTags(), client)
 }
 
-func (a *action) deleteVPC(ctx context.Context, vpcId string, client *ec2.EC2) error {
-	Log("Deleting VPC %s and its dependencies", vpcId)
+func (a *action) deleteVPC(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	logger.Info("Deleting VPC %s and its dependencies", vpcId)
 
-	if err := a.cleanVPCDependencies(ctx, vpcId, client); err != nil {
-		LogError("failed to clean VPC dependencies for %s: %s", vpcId, err.Error())
+	// the dhcp options set can only be deleted once no vpc is using it, so keep
+	// track of it before the vpc is disassociated from it.
+	dhcpOptionsId, err := a.getVPCDHCPOptionsId(ctx, logger, vpcId, client)
+	if err != nil {
+		logger.Warn("failed to get dhcp options for VPC %s: %s", vpcId, err.Error())
 	}
 
-	if _, err := client.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{VpcId: &vpcId}); err != nil {
+	if err := a.cleanVPCDependencies(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to clean VPC dependencies for %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+		_, err := client.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{VpcId: &vpcId})
+		return err
+	}); err != nil {
+		if isAlreadyDeleted(logger, err, "vpc", vpcId) {
+			return errAlreadyDeleted
+		}
+		// the dependency violation doesn't tell what's left in the vpc, so it's looked up to
+		// report what's blocking its deletion.
+		if a.isDependencyViolation(err) {
+			blocking, lookupErr := a.getVPCBlockingDependencies(ctx, vpcId, client)
+			if lookupErr != nil {
+				logger.Warn("failed to get the dependencies blocking the deletion of vpc %s: %s", vpcId, lookupErr.Error())
+			}
+			if len(blocking) > 0 {
+				logger.Error("vpc %s can't be deleted while it has %s", vpcId, strings.Join(blocking, ", "))
+				return fmt.Errorf("failed to delete vpc %s, blocked by %s: %w", vpcId, strings.Join(blocking, ", "), err)
+			}
+		}
 		return fmt.Errorf("failed to delete vpc %s: %w", vpcId, err)
 	}
 
-	Log("Successfully deleted VPC %s", vpcId)
+	logger.Info("Successfully deleted VPC %s", vpcId)
+
+	if dhcpOptionsId != "" {
+		if err := a.deleteDHCPOptions(ctx, logger, dhcpOptionsId, input, client); err != nil {
+			logger.Error("failed to delete dhcp options %s: %s", dhcpOptionsId, err.Error())
+		}
+	}
+
 	return nil
 }
 
-func (a *action) cleanVPCDependencies(ctx context.Context, vpcId string, client *ec2.EC2) error {
-	LogDebug("Cleaning VPC dependencies for %s", vpcId)
+// getVPCBlockingDependencies returns the common dependencies still in the vpc, which block its
+// deletion, e.g. "network interface eni-123 (amazon-elb)".
+func (a *action) getVPCBlockingDependencies(ctx context.Context, vpcId string, client *ec2.EC2) ([]string, error) {
+	vpcFilter := []*ec2.Filter{{Name: aws.String("vpc-id"), Values: []*string{&vpcId}}}
+	blocking := []string{}
+	var errs error
+
+	if err := client.DescribeNetworkInterfacesPagesWithContext(ctx, &ec2.DescribeNetworkInterfacesInput{Filters: vpcFilter}, func(page *ec2.DescribeNetworkInterfacesOutput, _ bool) bool {
+		for _, eni := range page.NetworkInterfaces {
+			owner := aws.StringValue(eni.RequesterId)
+			if owner == "" {
+				owner = aws.StringValue(eni.InterfaceType)
+			}
+			if owner == "" {
+				owner = aws.StringValue(eni.Description)
+			}
+			if owner != "" {
+				blocking = append(blocking, fmt.Sprintf("network interface %s (%s)", aws.StringValue(eni.NetworkInterfaceId), owner))
+			} else {
+				blocking = append(blocking, "network interface "+aws.StringValue(eni.NetworkInterfaceId))
+			}
+		}
+		return true
+	}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("failed to describe network interfaces: %w", err))
+	}
 
-	if err := a.deleteNATGateways(ctx, vpcId, client); err != nil {
-		LogError("failed to delete NAT gateways for VPC %s: %s", vpcId, err.Error())
+	if err := client.DescribeSecurityGroupsPagesWithContext(ctx, &ec2.DescribeSecurityGroupsInput{Filters: vpcFilter}, func(page *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+		for _, sg := range page.SecurityGroups {
+			// the default security group is deleted along with the vpc.
+			if aws.StringValue(sg.GroupName) != "default" {
+				blocking = append(blocking, "security group "+aws.StringValue(sg.GroupId))
+			}
+		}
+		return true
+	}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("failed to describe security groups: %w", err))
 	}
 
-	if err := a.deleteInternetGateways(ctx, vpcId, client); err != nil {
-		LogError("failed to delete internet gateways for VPC %s: %s", vpcId, err.Error())
+	if err := client.DescribeVpcEndpointsPagesWithContext(ctx, &ec2.DescribeVpcEndpointsInput{Filters: vpcFilter}, func(page *ec2.DescribeVpcEndpointsOutput, _ bool) bool {
+		for _, endpoint := range page.VpcEndpoints {
+			if state := strings.ToLower(aws.StringValue(endpoint.State)); state != "deleted" {
+				blocking = append(blocking, "vpc endpoint "+aws.StringValue(endpoint.VpcEndpointId))
+			}
+		}
+		return true
+	}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("failed to describe vpc endpoints: %w", err))
 	}
 
-	if err := a.deleteRouteTables(ctx, vpcId, client); err != nil {
-		LogError("failed to delete route tables for VPC %s: %s", vpcId, err.Error())
+	// the vpc can be either side of a peering connection.
+	for _, side := range []string{"requester-vpc-info.vpc-id", "accepter-vpc-info.vpc-id"} {
+		if err := client.DescribeVpcPeeringConnectionsPagesWithContext(ctx, &ec2.DescribeVpcPeeringConnectionsInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String(side), Values: []*string{&vpcId}},
+				{Name: aws.String("status-code"), Values: aws.StringSlice([]string{
+					ec2.VpcPeeringConnectionStateReasonCodeActive, ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance,
+					ec2.VpcPeeringConnectionStateReasonCodeProvisioning,
+				})},
+			},
+		}, func(page *ec2.DescribeVpcPeeringConnectionsOutput, _ bool) bool {
+			for _, peering := range page.VpcPeeringConnections {
+				blocking = append(blocking, "peering connection "+aws.StringValue(peering.VpcPeeringConnectionId))
+			}
+			return true
+		}); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to describe vpc peering connections: %w", err))
+		}
+	}
+
+	if err := client.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
+		Filters: append(vpcFilter, &ec2.Filter{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{
+			ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, ec2.InstanceStateNameShuttingDown,
+			ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped,
+		})}),
+	}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				blocking = append(blocking, "instance "+aws.StringValue(instance.InstanceId))
+			}
+		}
+		return true
+	}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("failed to describe instances: %w", err))
+	}
+
+	return blocking, errs
+}
+
+func (a *action) cleanVPCDependencies(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	logger.Debug("Cleaning VPC dependencies for %s", vpcId)
+
+	if err := a.disassociateDHCPOptions(ctx, logger, vpcId, client); err != nil {
+		logger.Error("failed to disassociate dhcp options from VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteNATGateways(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete NAT gateways for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteInternetGateways(ctx, logger, vpcId, client); err != nil {
+		logger.Error("failed to delete internet gateways for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteEgressOnlyInternetGateways(ctx, logger, vpcId, client); err != nil {
+		logger.Error("failed to delete egress-only internet gateways for VPC %s: %s", vpcId, err.Error())
 	}
 
-	if err := a.deleteSubnets(ctx, vpcId, client); err != nil {
-		LogError("failed to delete subnets for VPC %s: %s", vpcId, err.Error())
+	if err := a.deleteVPNGateways(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete vpn gateways for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteRouteTables(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete route tables for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteVPCEndpoints(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete vpc endpoints for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteSubnets(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete subnets for VPC %s: %s", vpcId, err.Error())
+	}
+
+	// network acls associated with subnets can't be deleted, so this needs to run
+	// after the subnets are deleted.
+	if err := a.deleteNetworkACLs(ctx, logger, vpcId, client); err != nil {
+		logger.Error("failed to delete network acls for VPC %s: %s", vpcId, err.Error())
+	}
+
+	if err := a.deleteSecurityGroups(ctx, logger, vpcId, input, client); err != nil {
+		logger.Error("failed to delete security groups for VPC %s: %s", vpcId, err.Error())
 	}
 
 	return nil
 }
 
-func (a *action) deleteNATGateways(ctx context.Context, vpcId string, client *ec2.EC2) error {
+func (a *action) deleteNATGateways(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
 	resp, err := client.DescribeNatGatewaysWithContext(ctx, &ec2.DescribeNatGatewaysInput{
 		Filter: []*ec2.Filter{
 			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
-			{Name: aws.String("state"), Values: []*string{aws.String("available")}},
+			{Name: aws.String("state"), Values: aws.StringSlice([]string{ec2.NatGatewayStateAvailable, ec2.NatGatewayStateDeleting})},
 		},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to describe NAT gateways: %w", err)
 	}
 
+	deletedIds := []*string{}
+	// the elastic ips used by the NAT gateways aren't released along with them.
+	allocationIds := []*string{}
 	for _, natGw := range resp.NatGateways {
-		LogDebug("Deleting NAT Gateway %s", *natGw.NatGatewayId)
-		if _, err := client.DeleteNatGatewayWithContext(ctx, &ec2.DeleteNatGatewayInput{
-			NatGatewayId: natGw.NatGatewayId,
-		}); err != nil {
-			LogError("failed to delete NAT gateway %s: %s", *natGw.NatGatewayId, err.Error())
+		// gateways already being deleted, e.g. by a previous run, still keep their subnet busy.
+		if aws.StringValue(natGw.State) == ec2.NatGatewayStateDeleting {
+			deletedIds = append(deletedIds, natGw.NatGatewayId)
+			continue
+		}
+
+		if input.isIgnored(ec2Tags(natGw.Tags)) {
+			logger.Warn("NAT gateway %s has ignore tag, skipping its deletion: vpc %s can't be deleted while it exists", *natGw.NatGatewayId, vpcId)
+			input.Report.skipped(input.Region, "NAT gateway", *natGw.NatGatewayId, "has ignore tag")
+			continue
 		}
+
+		logger.Debug("Deleting NAT Gateway %s", *natGw.NatGatewayId)
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteNatGatewayWithContext(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: natGw.NatGatewayId})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "NAT gateway", *natGw.NatGatewayId) {
+			logger.Error("failed to delete NAT gateway %s: %s", *natGw.NatGatewayId, err.Error())
+			continue
+		}
+
+		deletedIds = append(deletedIds, natGw.NatGatewayId)
+		for _, address := range natGw.NatGatewayAddresses {
+			if address.AllocationId != nil {
+				allocationIds = append(allocationIds, address.AllocationId)
+			}
+		}
+	}
+
+	if len(deletedIds) == 0 {
+		return nil
+	}
+
+	// the subnets can't be deleted until the network interfaces of the NAT gateways are gone.
+	logger.Debug("Waiting up to %s for %d NAT gateways to be deleted", input.NATGatewayTimeout, len(deletedIds))
+	if err := waitUntilState(ctx, input.NATGatewayTimeout, 15*time.Second, func(ctx context.Context) (bool, string, error) {
+		out, err := client.DescribeNatGatewaysWithContext(ctx, &ec2.DescribeNatGatewaysInput{NatGatewayIds: deletedIds})
+		if err != nil {
+			return false, "", err
+		}
+		for _, natGw := range out.NatGateways {
+			if aws.StringValue(natGw.State) != ec2.NatGatewayStateDeleted {
+				return false, fmt.Sprintf("NAT gateway %s still in state %s", aws.StringValue(natGw.NatGatewayId), aws.StringValue(natGw.State)), nil
+			}
+		}
+		return true, "", nil
+	}, withBackoff(natGatewayWaitBackoff, natGatewayWaitMaxInterval), withJitter(natGatewayWaitJitter)); err != nil {
+		return fmt.Errorf("failed waiting for NAT gateways to be deleted: %w", err)
+	}
+
+	if len(allocationIds) > 0 {
+		if err := a.releaseNATGatewayAddresses(ctx, logger, allocationIds, input, client); err != nil {
+			return fmt.Errorf("failed to release NAT gateway elastic ips: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// releaseNATGatewayAddresses releases the elastic ips that were used by deleted NAT gateways,
+// unless they have the ignore tag or were associated with something else in the meantime.
+func (a *action) releaseNATGatewayAddresses(ctx context.Context, logger Logger, allocationIds []*string, input *CleanupScope, client *ec2.EC2) error {
+	out, err := client.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{AllocationIds: allocationIds})
+	if err != nil {
+		return fmt.Errorf("failed to describe addresses: %w", err)
+	}
+
+	for _, address := range out.Addresses {
+		if input.isIgnored(ec2Tags(address.Tags)) {
+			logger.Debug("elastic ip %s has ignore tag, won't release it", aws.StringValue(address.PublicIp))
+			input.Report.skipped(input.Region, "elastic ip", aws.StringValue(address.PublicIp), "has ignore tag")
+			continue
+		}
+		if address.AssociationId != nil {
+			logger.Debug("elastic ip %s is associated with %s, won't release it", aws.StringValue(address.PublicIp), aws.StringValue(address.NetworkInterfaceId))
+			continue
+		}
+
+		if err := a.releaseElasticIP(ctx, address, client); err != nil {
+			logger.Error("failed to release elastic ip %s: %s", aws.StringValue(address.PublicIp), err.Error())
+			input.Report.failed(input.Region, "elastic ip", aws.StringValue(address.PublicIp), err.Error())
+			continue
+		}
+		input.Report.deleted(input.Region, "elastic ip", aws.StringValue(address.PublicIp))
 	}
 
 	return nil
 }
 
-func (a *action) deleteInternetGateways(ctx context.Context, vpcId string, client *ec2.EC2) error {
+func (a *action) deleteInternetGateways(ctx context.Context, logger Logger, vpcId string, client *ec2.EC2) error {
 	resp, err := client.DescribeInternetGatewaysWithContext(ctx, &ec2.DescribeInternetGatewaysInput{
 		Filters: []*ec2.Filter{
 			{Name: aws.String("attachment.vpc-id"), Values: []*string{&vpcId}},
@@ -160,37 +416,166 @@ func (a *action) deleteInternetGateways(ctx context.Context, vpcId string, clien
 	}
 
 	for _, igw := range resp.InternetGateways {
-		LogDebug("Detaching and deleting Internet Gateway %s", *igw.InternetGatewayId)
+		logger.Debug("Detaching and deleting Internet Gateway %s", *igw.InternetGatewayId)
+
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{
+				InternetGatewayId: igw.InternetGatewayId,
+				VpcId:             &vpcId,
+			})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "internet gateway", *igw.InternetGatewayId) {
+			logger.Error("failed to detach internet gateway %s: %s", *igw.InternetGatewayId, err.Error())
+			continue
+		}
 
-		if _, err := client.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{
-			InternetGatewayId: igw.InternetGatewayId,
-			VpcId:             &vpcId,
-		}); err != nil {
-			LogError("failed to detach internet gateway %s: %s", *igw.InternetGatewayId, err.Error())
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{InternetGatewayId: igw.InternetGatewayId})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "internet gateway", *igw.InternetGatewayId) {
+			logger.Error("failed to delete internet gateway %s: %s", *igw.InternetGatewayId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) deleteEgressOnlyInternetGateways(ctx context.Context, logger Logger, vpcId string, client *ec2.EC2) error {
+	eigwIds := []*string{}
+	pageFunc := func(page *ec2.DescribeEgressOnlyInternetGatewaysOutput, _ bool) bool {
+		for _, eigw := range page.EgressOnlyInternetGateways {
+			for _, attachment := range eigw.Attachments {
+				if aws.StringValue(attachment.VpcId) == vpcId {
+					eigwIds = append(eigwIds, eigw.EgressOnlyInternetGatewayId)
+					break
+				}
+			}
+		}
+
+		return true
+	}
+
+	if err := client.DescribeEgressOnlyInternetGatewaysPagesWithContext(ctx, &ec2.DescribeEgressOnlyInternetGatewaysInput{}, pageFunc); err != nil {
+		return fmt.Errorf("failed to describe egress-only internet gateways: %w", err)
+	}
+
+	for _, eigwId := range eigwIds {
+		logger.Debug("Deleting Egress-Only Internet Gateway %s", *eigwId)
+		if _, err := client.DeleteEgressOnlyInternetGatewayWithContext(ctx, &ec2.DeleteEgressOnlyInternetGatewayInput{
+			EgressOnlyInternetGatewayId: eigwId,
+		}); err != nil && !isAlreadyDeleted(logger, err, "egress-only internet gateway", *eigwId) {
+			logger.Error("failed to delete egress-only internet gateway %s: %s", *eigwId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) deleteVPNGateways(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	resp, err := client.DescribeVpnGatewaysWithContext(ctx, &ec2.DescribeVpnGatewaysInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("attachment.vpc-id"), Values: []*string{&vpcId}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe vpn gateways: %w", err)
+	}
+
+	for _, vgw := range resp.VpnGateways {
+		if input.isIgnored(ec2Tags(vgw.Tags)) {
+			logger.Debug("Skipping vpn gateway %s as it has ignore tag", *vgw.VpnGatewayId)
+			continue
+		}
+
+		if err := a.deleteVPNConnections(ctx, logger, *vgw.VpnGatewayId, client); err != nil {
+			logger.Error("failed to delete vpn connections for vpn gateway %s: %s", *vgw.VpnGatewayId, err.Error())
+		}
+
+		logger.Debug("Detaching and deleting VPN Gateway %s", *vgw.VpnGatewayId)
+		if _, err := client.DetachVpnGatewayWithContext(ctx, &ec2.DetachVpnGatewayInput{
+			VpnGatewayId: vgw.VpnGatewayId,
+			VpcId:        &vpcId,
+		}); err != nil && !isAlreadyDeleted(logger, err, "vpn gateway", *vgw.VpnGatewayId) {
+			logger.Error("failed to detach vpn gateway %s: %s", *vgw.VpnGatewayId, err.Error())
 			continue
 		}
 
-		if _, err := client.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{
-			InternetGatewayId: igw.InternetGatewayId,
+		if err := waitUntil(ctx, 5*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+			out, err := client.DescribeVpnGatewaysWithContext(ctx, &ec2.DescribeVpnGatewaysInput{VpnGatewayIds: []*string{vgw.VpnGatewayId}})
+			if err != nil {
+				logger.Warn("error while waiting for vpn gateway %s to detach: %s", *vgw.VpnGatewayId, err.Error())
+				return false, nil
+			}
+			for _, gw := range out.VpnGateways {
+				for _, attachment := range gw.VpcAttachments {
+					if aws.StringValue(attachment.VpcId) == vpcId && aws.StringValue(attachment.State) != ec2.AttachmentStatusDetached {
+						return false, nil
+					}
+				}
+			}
+			return true, nil
 		}); err != nil {
-			LogError("failed to delete internet gateway %s: %s", *igw.InternetGatewayId, err.Error())
+			logger.Error("failed waiting for vpn gateway %s to detach: %s", *vgw.VpnGatewayId, err.Error())
+			continue
+		}
+
+		if _, err := client.DeleteVpnGatewayWithContext(ctx, &ec2.DeleteVpnGatewayInput{
+			VpnGatewayId: vgw.VpnGatewayId,
+		}); err != nil && !isAlreadyDeleted(logger, err, "vpn gateway", *vgw.VpnGatewayId) {
+			logger.Error("failed to delete vpn gateway %s: %s", *vgw.VpnGatewayId, err.Error())
 		}
 	}
 
 	return nil
 }
 
-func (a *action) deleteRouteTables(ctx context.Context, vpcId string, client *ec2.EC2) error {
-	resp, err := client.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+func (a *action) deleteVPNConnections(ctx context.Context, logger Logger, vgwId string, client *ec2.EC2) error {
+	resp, err := client.DescribeVpnConnectionsWithContext(ctx, &ec2.DescribeVpnConnectionsInput{
 		Filters: []*ec2.Filter{
-			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+			{Name: aws.String("vpn-gateway-id"), Values: []*string{&vgwId}},
 		},
 	})
 	if err != nil {
+		return fmt.Errorf("failed to describe vpn connections: %w", err)
+	}
+
+	for _, conn := range resp.VpnConnections {
+		state := aws.StringValue(conn.State)
+		if state == ec2.VpnStateDeleted || state == ec2.VpnStateDeleting {
+			continue
+		}
+
+		logger.Debug("Deleting VPN Connection %s", *conn.VpnConnectionId)
+		if _, err := client.DeleteVpnConnectionWithContext(ctx, &ec2.DeleteVpnConnectionInput{
+			VpnConnectionId: conn.VpnConnectionId,
+		}); err != nil && !isAlreadyDeleted(logger, err, "vpn connection", *conn.VpnConnectionId) {
+			logger.Error("failed to delete vpn connection %s: %s", *conn.VpnConnectionId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) deleteRouteTables(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	routeTables := []*ec2.RouteTable{}
+	if err := client.DescribeRouteTablesPagesWithContext(ctx, &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeRouteTablesOutput, _ bool) bool {
+		routeTables = append(routeTables, page.RouteTables...)
+		return true
+	}); err != nil {
 		return fmt.Errorf("failed to describe route tables: %w", err)
 	}
 
-	for _, rt := range resp.RouteTables {
+	// disassociating a route table from a protected subnet would change how it's routed.
+	protectedSubnets, err := a.getProtectedSubnets(ctx, vpcId, input, client)
+	if err != nil {
+		return err
+	}
+
+	for _, rt := range routeTables {
 		isMain := false
 		for _, assoc := range rt.Associations {
 			if aws.BoolValue(assoc.Main) {
@@ -199,39 +584,334 @@ func (a *action) deleteRouteTables(ctx context.Context, vpcId string, client *ec
 			}
 		}
 		if isMain {
-			LogDebug("Skipping main route table %s", *rt.RouteTableId)
+			logger.Debug("Skipping main route table %s", *rt.RouteTableId)
 			continue
 		}
 
-		LogDebug("Deleting route table %s", *rt.RouteTableId)
-		if _, err := client.DeleteRouteTableWithContext(ctx, &ec2.DeleteRouteTableInput{
-			RouteTableId: rt.RouteTableId,
-		}); err != nil {
-			LogError("failed to delete route table %s: %s", *rt.RouteTableId, err.Error())
+		if input.isIgnored(ec2Tags(rt.Tags)) {
+			logger.Warn("route table %s has ignore tag, skipping its deletion: vpc %s can't be deleted while it exists", *rt.RouteTableId, vpcId)
+			input.Report.skipped(input.Region, "route table", *rt.RouteTableId, "has ignore tag")
+			continue
+		}
+
+		if subnetId := protectedSubnetAssociation(rt, protectedSubnets); subnetId != "" {
+			logger.Warn("route table %s is associated with subnet %s, which has ignore tag, skipping its deletion", *rt.RouteTableId, subnetId)
+			input.Report.skipped(input.Region, "route table", *rt.RouteTableId, "associated with protected subnet "+subnetId)
+			continue
+		}
+
+		// route tables with explicit subnet or gateway associations can't be deleted.
+		for _, assoc := range rt.Associations {
+			logger.Debug("Disassociating route table %s from %s", *rt.RouteTableId, routeTableAssociationTarget(assoc))
+			if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+				_, err := client.DisassociateRouteTableWithContext(ctx, &ec2.DisassociateRouteTableInput{AssociationId: assoc.RouteTableAssociationId})
+				return err
+			}); err != nil && !isAlreadyDeleted(logger, err, "route table association", aws.StringValue(assoc.RouteTableAssociationId)) {
+				logger.Error("failed to disassociate route table association %s: %s", aws.StringValue(assoc.RouteTableAssociationId), err.Error())
+			}
+		}
+
+		logger.Debug("Deleting route table %s", *rt.RouteTableId)
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteRouteTableWithContext(ctx, &ec2.DeleteRouteTableInput{RouteTableId: rt.RouteTableId})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "route table", *rt.RouteTableId) {
+			logger.Error("failed to delete route table %s: %s", *rt.RouteTableId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// getProtectedSubnets returns the ids of the subnets of the vpc that have the ignore tag.
+func (a *action) getProtectedSubnets(ctx context.Context, vpcId string, input *CleanupScope, client *ec2.EC2) (map[string]bool, error) {
+	protected := map[string]bool{}
+	if err := client.DescribeSubnetsPagesWithContext(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeSubnetsOutput, _ bool) bool {
+		for _, subnet := range page.Subnets {
+			if input.isIgnored(ec2Tags(subnet.Tags)) {
+				protected[*subnet.SubnetId] = true
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("failed to describe subnets: %w", err)
+	}
+
+	return protected, nil
+}
+
+// protectedSubnetAssociation returns the id of a protected subnet the route table is associated with, if any.
+func protectedSubnetAssociation(rt *ec2.RouteTable, protectedSubnets map[string]bool) string {
+	for _, assoc := range rt.Associations {
+		if subnetId := aws.StringValue(assoc.SubnetId); protectedSubnets[subnetId] {
+			return subnetId
+		}
+	}
+	return ""
+}
+
+func routeTableAssociationTarget(assoc *ec2.RouteTableAssociation) string {
+	if assoc.SubnetId != nil {
+		return fmt.Sprintf("subnet %s", *assoc.SubnetId)
+	}
+	return fmt.Sprintf("gateway %s", aws.StringValue(assoc.GatewayId))
+}
+
+func (a *action) deleteVPCEndpoints(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	endpoints := []*ec2.VpcEndpoint{}
+	if err := client.DescribeVpcEndpointsPagesWithContext(ctx, &ec2.DescribeVpcEndpointsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeVpcEndpointsOutput, _ bool) bool {
+		endpoints = append(endpoints, page.VpcEndpoints...)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to describe vpc endpoints: %w", err)
+	}
+
+	endpointIds := []*string{}
+	interfaceEndpointIds := []*string{}
+	for _, endpoint := range endpoints {
+		if strings.EqualFold(aws.StringValue(endpoint.State), ec2.StateDeleted) {
+			continue
+		}
+
+		if input.isIgnored(ec2Tags(endpoint.Tags)) {
+			logger.Debug("Skipping vpc endpoint %s as it has ignore tag", *endpoint.VpcEndpointId)
+			continue
+		}
+
+		logger.Debug("Deleting vpc endpoint %s (%s)", *endpoint.VpcEndpointId, aws.StringValue(endpoint.VpcEndpointType))
+		endpointIds = append(endpointIds, endpoint.VpcEndpointId)
+		if aws.StringValue(endpoint.VpcEndpointType) != ec2.VpcEndpointTypeGateway {
+			interfaceEndpointIds = append(interfaceEndpointIds, endpoint.VpcEndpointId)
+		}
+	}
+
+	if len(endpointIds) == 0 {
+		return nil
+	}
+
+	var out *ec2.DeleteVpcEndpointsOutput
+	if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+		var err error
+		out, err = client.DeleteVpcEndpointsWithContext(ctx, &ec2.DeleteVpcEndpointsInput{VpcEndpointIds: endpointIds})
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to delete vpc endpoints: %w", err)
+	}
+	for _, item := range out.Unsuccessful {
+		if item.Error != nil && isNotFoundCode(aws.StringValue(item.Error.Code)) {
+			logger.Debug("vpc endpoint %s was already deleted", aws.StringValue(item.ResourceId))
+			continue
+		}
+		if item.Error != nil {
+			logger.Error("failed to delete vpc endpoint %s: %s", aws.StringValue(item.ResourceId), aws.StringValue(item.Error.Message))
+		}
+	}
+
+	if len(interfaceEndpointIds) == 0 {
+		return nil
+	}
+
+	// interface endpoints own network interfaces in the vpc subnets, wait for them
+	// to be gone so the subnets can be deleted.
+	if err := waitUntil(ctx, 5*time.Minute, 10*time.Second, func(ctx context.Context) (bool, error) {
+		out, err := client.DescribeVpcEndpointsWithContext(ctx, &ec2.DescribeVpcEndpointsInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String("vpc-endpoint-id"), Values: interfaceEndpointIds},
+			},
+		})
+		if err != nil {
+			logger.Warn("error while waiting for vpc endpoints deletion in VPC %s: %s", vpcId, err.Error())
+			return false, nil
+		}
+		for _, endpoint := range out.VpcEndpoints {
+			if !strings.EqualFold(aws.StringValue(endpoint.State), ec2.StateDeleted) {
+				return false, nil
+			}
 		}
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("failed waiting for vpc endpoints to be deleted: %w", err)
 	}
 
 	return nil
 }
 
-func (a *action) deleteSubnets(ctx context.Context, vpcId string, client *ec2.EC2) error {
-	resp, err := client.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{
+func (a *action) deleteSubnets(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	subnets := []*ec2.Subnet{}
+	if err := client.DescribeSubnetsPagesWithContext(ctx, &ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeSubnetsOutput, _ bool) bool {
+		subnets = append(subnets, page.Subnets...)
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to describe subnets: %w", err)
+	}
+
+	for _, subnet := range subnets {
+		if input.isIgnored(ec2Tags(subnet.Tags)) {
+			logger.Warn("subnet %s has ignore tag, skipping its deletion: vpc %s can't be deleted while it exists", *subnet.SubnetId, vpcId)
+			input.Report.skipped(input.Region, "subnet", *subnet.SubnetId, "has ignore tag")
+			continue
+		}
+
+		logger.Debug("Deleting subnet %s", *subnet.SubnetId)
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.SubnetId})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "subnet", *subnet.SubnetId) {
+			logger.Error("failed to delete subnet %s: %s", *subnet.SubnetId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) deleteNetworkACLs(ctx context.Context, logger Logger, vpcId string, client *ec2.EC2) error {
+	resp, err := client.DescribeNetworkAclsWithContext(ctx, &ec2.DescribeNetworkAclsInput{
 		Filters: []*ec2.Filter{
 			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to describe subnets: %w", err)
+		return fmt.Errorf("failed to describe network acls: %w", err)
 	}
 
-	for _, subnet := range resp.Subnets {
-		LogDebug("Deleting subnet %s", *subnet.SubnetId)
-		if _, err := client.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{
-			SubnetId: subnet.SubnetId,
-		}); err != nil {
-			LogError("failed to delete subnet %s: %s", *subnet.SubnetId, err.Error())
+	for _, acl := range resp.NetworkAcls {
+		if aws.BoolValue(acl.IsDefault) {
+			logger.Debug("Skipping default network acl %s", *acl.NetworkAclId)
+			continue
+		}
+
+		if len(acl.Associations) > 0 {
+			logger.Debug("Network acl %s is still associated with %d subnets", *acl.NetworkAclId, len(acl.Associations))
+		}
+
+		logger.Debug("Deleting network acl %s", *acl.NetworkAclId)
+		if _, err := client.DeleteNetworkAclWithContext(ctx, &ec2.DeleteNetworkAclInput{
+			NetworkAclId: acl.NetworkAclId,
+		}); err != nil && !isAlreadyDeleted(logger, err, "network acl", *acl.NetworkAclId) {
+			logger.Error("failed to delete network acl %s: %s", *acl.NetworkAclId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (a *action) deleteSecurityGroups(ctx context.Context, logger Logger, vpcId string, input *CleanupScope, client *ec2.EC2) error {
+	securityGroups := []*ec2.SecurityGroup{}
+	if err := client.DescribeSecurityGroupsPagesWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("vpc-id"), Values: []*string{&vpcId}},
+		},
+	}, func(page *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+		for _, sg := range page.SecurityGroups {
+			if aws.StringValue(sg.GroupName) == "default" {
+				logger.Debug("Skipping default security group %s", *sg.GroupId)
+				continue
+			}
+			if input.isIgnored(ec2Tags(sg.Tags)) {
+				logger.Warn("security group %s has ignore tag, skipping its deletion: vpc %s can't be deleted while it exists", *sg.GroupId, vpcId)
+				input.Report.skipped(input.Region, "security group", *sg.GroupId, "has ignore tag")
+				continue
+			}
+			securityGroups = append(securityGroups, sg)
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to describe security groups: %w", err)
+	}
+
+	// security groups may reference each other in their rules, so all the
+	// rules are revoked before any of the groups is deleted.
+	for _, sg := range securityGroups {
+		logger.Debug("Revoking rules of security group %s", *sg.GroupId)
+		if err := a.deleteSecurityGroupRules(ctx, *sg.GroupId, sg.IpPermissions, sg.IpPermissionsEgress, client); err != nil {
+			logger.Error("failed to revoke rules of security group %s: %s", *sg.GroupId, err.Error())
+		}
+	}
+
+	for _, sg := range securityGroups {
+		logger.Debug("Deleting security group %s", *sg.GroupId)
+		if err := a.retryOnThrottling(ctx, func(ctx context.Context) error {
+			_, err := client.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{GroupId: sg.GroupId})
+			return err
+		}); err != nil && !isAlreadyDeleted(logger, err, "security group", *sg.GroupId) {
+			logger.Error("failed to delete security group %s: %s", *sg.GroupId, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// getVPCDHCPOptionsId returns the id of the custom dhcp options set associated with the vpc,
+// or an empty string if the vpc uses the default options.
+func (a *action) getVPCDHCPOptionsId(ctx context.Context, logger Logger, vpcId string, client *ec2.EC2) (string, error) {
+	resp, err := client.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{VpcIds: []*string{&vpcId}})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe vpc: %w", err)
+	}
+
+	if len(resp.Vpcs) != 1 || aws.StringValue(resp.Vpcs[0].DhcpOptionsId) == "default" {
+		return "", nil
+	}
+
+	return aws.StringValue(resp.Vpcs[0].DhcpOptionsId), nil
+}
+
+func (a *action) disassociateDHCPOptions(ctx context.Context, logger Logger, vpcId string, client *ec2.EC2) error {
+	logger.Debug("Disassociating dhcp options from VPC %s", vpcId)
+
+	if _, err := client.AssociateDhcpOptionsWithContext(ctx, &ec2.AssociateDhcpOptionsInput{
+		DhcpOptionsId: aws.String("default"),
+		VpcId:         &vpcId,
+	}); err != nil && !isAlreadyDeleted(logger, err, "vpc", vpcId) {
+		return fmt.Errorf("failed to associate default dhcp options: %w", err)
+	}
+
+	return nil
+}
+
+func (a *action) deleteDHCPOptions(ctx context.Context, logger Logger, dhcpOptionsId string, input *CleanupScope, client *ec2.EC2) error {
+	resp, err := client.DescribeDhcpOptionsWithContext(ctx, &ec2.DescribeDhcpOptionsInput{DhcpOptionsIds: []*string{&dhcpOptionsId}})
+	if err != nil {
+		return fmt.Errorf("failed to describe dhcp options: %w", err)
+	}
+
+	for _, options := range resp.DhcpOptions {
+		if input.isIgnored(ec2Tags(options.Tags)) {
+			logger.Debug("Skipping dhcp options %s as it has ignore tag", dhcpOptionsId)
+			return nil
 		}
 	}
 
+	vpcs, err := client.DescribeVpcsWithContext(ctx, &ec2.DescribeVpcsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("dhcp-options-id"), Values: []*string{&dhcpOptionsId}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe vpcs using dhcp options: %w", err)
+	}
+
+	if len(vpcs.Vpcs) > 0 {
+		logger.Debug("Skipping dhcp options %s as it's still used by %d VPCs", dhcpOptionsId, len(vpcs.Vpcs))
+		return nil
+	}
+
+	logger.Debug("Deleting dhcp options %s", dhcpOptionsId)
+	if _, err := client.DeleteDhcpOptionsWithContext(ctx, &ec2.DeleteDhcpOptionsInput{DhcpOptionsId: &dhcpOptionsId}); err != nil && !isAlreadyDeleted(logger, err, "dhcp options", dhcpOptionsId) {
+		return fmt.Errorf("failed to delete dhcp options: %w", err)
+	}
+
 	return nil
 }
@@ -1,36 +1,112 @@
 package action
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 )
 
 const (
 	failedExitCode = 1
 )
 
+const (
+	// LogFormatText writes log entries as github actions workflow commands.
+	LogFormatText = "text"
+	// LogFormatJSON writes log entries as json objects, one per line.
+	LogFormatJSON = "json"
+)
+
+const (
+	levelDebug   = "debug"
+	levelInfo    = "info"
+	levelWarning = "warning"
+	levelError   = "error"
+)
+
+var (
+	// logMu serializes writes to stdout so lines from concurrent cleaners don't interleave.
+	logMu     sync.Mutex
+	logFormat = LogFormatText
+)
+
+// SetLogFormat sets the format of the log entries, either LogFormatText or LogFormatJSON.
+func SetLogFormat(format string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	logFormat = format
+}
+
+// logEntry is a log entry as written when using LogFormatJSON.
+type logEntry struct {
+	Time         string `json:"time"`
+	Level        string `json:"level"`
+	Message      string `json:"message"`
+	Action       string `json:"action,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+	ResourceID   string `json:"resource_id,omitempty"`
+	Region       string `json:"region,omitempty"`
+}
+
+func writeLog(entry logEntry) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if logFormat == LogFormatJSON {
+		entry.Time = time.Now().UTC().Format(time.RFC3339)
+		data, err := json.Marshal(entry)
+		if err != nil {
+			data = []byte(fmt.Sprintf(`{"level":"error","message":"failed to marshal log entry: %s"}`, err.Error()))
+		}
+		fmt.Println(string(data)) //nolint: forbidigo
+		return
+	}
+
+	switch entry.Level {
+	case levelDebug, levelWarning, levelError:
+		fmt.Printf("::%s::%s\n", entry.Level, entry.Message) //nolint: forbidigo
+	default:
+		fmt.Println(entry.Message) //nolint: forbidigo
+	}
+}
+
+// logResourceEvent writes what was done with a resource. The text format already has
+// a free-form line for it, so it's only written when using LogFormatJSON.
+func logResourceEvent(action, region, resourceType, id, reason string) {
+	logMu.Lock()
+	format := logFormat
+	logMu.Unlock()
+	if format != LogFormatJSON {
+		return
+	}
+
+	message := fmt.Sprintf("%s %s %s", resourceType, id, action)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	writeLog(logEntry{Level: levelInfo, Message: message, Action: action, ResourceType: resourceType, ResourceID: id, Region: region})
+}
+
 // Log will write a log entry to stdout.
 func Log(msg string, a ...interface{}) {
-	message := fmt.Sprintf(msg, a...)
-	fmt.Println(message) //nolint: forbidigo
+	writeLog(logEntry{Level: levelInfo, Message: fmt.Sprintf(msg, a...)})
 }
 
 // LogDebug will write a debug message command to stdout.
 func LogDebug(msg string, a ...interface{}) {
-	message := fmt.Sprintf(msg, a...)
-	Log("::debug::%s", message)
+	writeLog(logEntry{Level: levelDebug, Message: fmt.Sprintf(msg, a...)})
 }
 
 // LogWarning will write a warning message command to stdout.
 func LogWarning(msg string, a ...interface{}) {
-	message := fmt.Sprintf(msg, a...)
-	Log("::warning::%s", message)
+	writeLog(logEntry{Level: levelWarning, Message: fmt.Sprintf(msg, a...)})
 }
 
 // LogError will write a error message command to stdout.
 func LogError(msg string, a ...interface{}) {
-	message := fmt.Sprintf(msg, a...)
-	Log("::error::%s", message)
+	writeLog(logEntry{Level: levelError, Message: fmt.Sprintf(msg, a...)})
 }
 
 // LogErrorAndExit will write a error message command to stdout and exit
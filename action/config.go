@@ -0,0 +1,83 @@
+package action
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings that can be read from a yaml config file, so the per-account
+// configuration can be kept under version control. The inputs take precedence over it.
+type Config struct {
+	IgnoreTags    []string          `yaml:"ignore-tags"`
+	RequiredTags  map[string]string `yaml:"required-tags"`
+	TagSelector   string            `yaml:"tag-selector"`
+	ResourceTypes []string          `yaml:"resource-types"`
+	// ImmediateResourceTypes are the resource types deleted on the first run, without being marked first.
+	ImmediateResourceTypes []string `yaml:"immediate-resource-types"`
+	Regions                []string `yaml:"regions"`
+	// MinAge and GracePeriod are pointers so an explicit 0s can be told apart from an unset value.
+	MinAge      *time.Duration `yaml:"min-age"`
+	GracePeriod *time.Duration `yaml:"grace-period"`
+	// CostPrices overrides the default hourly prices of the cost estimate, keyed by resource type.
+	CostPrices map[string]float64 `yaml:"cost-prices"`
+}
+
+// LoadConfig reads the yaml config file at path. Unknown keys are rejected so a typo
+// doesn't silently leave a setting out.
+func LoadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// apply sets the settings of the config on the input, except the ones whose input was set.
+func (c *Config) apply(input *Input) {
+	if len(c.IgnoreTags) > 0 && !isInputSet("INPUT_IGNORE-TAG") {
+		input.IgnoreTag = strings.Join(c.IgnoreTags, ",")
+	}
+	if len(c.RequiredTags) > 0 && !isInputSet("INPUT_REQUIRED-TAGS") {
+		input.RequiredTags = c.RequiredTags
+	}
+	if c.TagSelector != "" && !isInputSet("INPUT_TAG-SELECTOR") {
+		input.TagSelector = c.TagSelector
+	}
+	if len(c.ResourceTypes) > 0 && !isInputSet("INPUT_RESOURCE-TYPES") {
+		input.ResourceTypes = c.ResourceTypes
+	}
+	if len(c.ImmediateResourceTypes) > 0 && !isInputSet("INPUT_IMMEDIATE-RESOURCE-TYPES") {
+		input.ImmediateResourceTypes = c.ImmediateResourceTypes
+	}
+	if len(c.Regions) > 0 && !isInputSet("INPUT_REGIONS") {
+		input.Regions = strings.Join(c.Regions, ",")
+	}
+	if c.MinAge != nil && !isInputSet("INPUT_MIN-AGE") {
+		input.MinAge = *c.MinAge
+	}
+	if c.GracePeriod != nil && !isInputSet("INPUT_GRACE-PERIOD") {
+		input.GracePeriod = *c.GracePeriod
+	}
+	if len(c.CostPrices) > 0 && !isInputSet("INPUT_COST-PRICES") {
+		input.CostPrices = c.CostPrices
+	}
+}
+
+// isInputSet returns true if the input's environment variable holds a value. Github sets
+// the variables of all the inputs, to an empty string for the ones left out.
+func isInputSet(key string) bool {
+	return os.Getenv(key) != ""
+}
@@ -0,0 +1,112 @@
+package action
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// confirmationWord must be typed, or set as the confirmation token, to confirm the deletions.
+const confirmationWord = "delete"
+
+const (
+	// MaxDeletionsActionAbort aborts the run, before anything is marked or deleted, when too many
+	// resources would be deleted.
+	MaxDeletionsActionAbort = "abort"
+	// MaxDeletionsActionMarkOnly switches to the mark-only mode when too many resources would be deleted.
+	MaxDeletionsActionMarkOnly = "mark-only"
+)
+
+// planDeletions computes what would be deleted by running all the cleaners in dry-mode, then
+// checks it against the maximum number of deletions and, if required, waits for the deletions to
+// be confirmed. Only the approved resources are deleted afterwards, while marking resources doesn't
+// need any approval. ErrTooManyDeletions is returned when the maximum is exceeded.
+func (a *action) planDeletions(ctx context.Context, input *Input, accounts []*account, inputRegions []string) error {
+	// nothing is deleted until the deletions are approved.
+	a.approved = map[string]bool{}
+
+	planner := a.dryRunCopy()
+	// the resource types were already validated.
+	stages, _ := filterStages(planner.stages(), input.ResourceTypes)
+
+	a.logger.Info("Looking for the resources to delete before deleting any of them")
+	toDelete, err := planner.runStagesByAccount(ctx, input, stages, accounts, inputRegions)
+	if err != nil {
+		return fmt.Errorf("failed looking for the resources to delete, no resource will be deleted: %w", err)
+	}
+
+	if len(toDelete) == 0 {
+		a.logger.Info("No resources to delete")
+		return nil
+	}
+
+	// a misconfigured selection, e.g. a wrong required tag, could match a whole account.
+	if a.maxDeletions > 0 && len(toDelete) > a.maxDeletions {
+		err := fmt.Errorf("%w: %d resources would be deleted, the maximum is %d", ErrTooManyDeletions, len(toDelete), a.maxDeletions)
+		if a.maxDeletionsAction == MaxDeletionsActionMarkOnly {
+			a.logger.Error("%s, switching to mark-only mode", err.Error())
+			a.mode = ModeMarkOnly
+		} else {
+			a.logger.Error("%s, aborting", err.Error())
+		}
+		return err
+	}
+
+	a.logger.Info("The following %d resources will be deleted:", len(toDelete))
+	for _, entry := range toDelete {
+		a.logger.Info("  - %s %s (%s)", entry.Type, entry.ID, entry.Region)
+	}
+
+	if a.confirm && !a.isConfirmationGiven() {
+		a.logger.Warn("Deletions weren't confirmed, resources will only be marked for deletion")
+		return nil
+	}
+
+	for _, entry := range toDelete {
+		a.approved[approvalKey(entry.Account, entry.Region, entry.Type, entry.ID)] = true
+	}
+
+	return nil
+}
+
+// isConfirmationGiven returns true if the confirmation token is set to the confirmation
+// word or, when it isn't set, if the confirmation word is typed.
+func (a *action) isConfirmationGiven() bool {
+	if a.confirmToken != "" {
+		return a.confirmToken == confirmationWord
+	}
+
+	a.logger.Info("Type %q to confirm the deletions:", confirmationWord)
+	line, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && line == "" {
+		a.logger.Warn("failed reading the confirmation: %s", err.Error())
+		return false
+	}
+
+	return strings.TrimSpace(line) == confirmationWord
+}
+
+// dryRunCopy returns a copy of the action that doesn't commit and has a report of its own, so the
+// resources the action would delete can be looked up with the same settings.
+func (a *action) dryRunCopy() *action {
+	planner := *a
+	planner.commit = false
+	planner.report = NewReport()
+	planner.approved = nil
+
+	return &planner
+}
+
+// isConfirmed returns true if the resource can be deleted, which is always the case
+// unless the deletions must be confirmed.
+func (a *action) isConfirmed(accountID, region, resourceType, id string) bool {
+	if a.approved == nil {
+		return true
+	}
+	return a.approved[approvalKey(accountID, region, resourceType, id)]
+}
+
+func approvalKey(accountID, region, resourceType, id string) string {
+	return accountID + "/" + region + "/" + resourceType + "/" + id
+}
@@ -0,0 +1,71 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// hoursPerMonth is the average number of hours in a month, as used by the aws pricing.
+const hoursPerMonth = 730
+
+// DefaultHourlyPrices are rough on-demand prices, in USD per hour, of the resource types whose
+// deletion saves money. The volumes are priced per GiB, the other types per resource.
+var DefaultHourlyPrices = map[string]float64{
+	"nat-gateway":  0.045,
+	"eip":          0.005,
+	"volume":       0.08 / hoursPerMonth,
+	"elb":          0.025,
+	"elbv2":        0.0225,
+	"rds-instance": 0.068,
+}
+
+// pricedReportTypes are the types the resources of the priced resource types are reported as.
+var pricedReportTypes = map[string]string{
+	"nat-gateway":  "NAT gateway",
+	"eip":          "elastic ip",
+	"volume":       "volume",
+	"elb":          "load balancer",
+	"elbv2":        "elbv2",
+	"rds-instance": "rds instance",
+}
+
+// CostEstimate is a ballpark of the monthly savings brought by the deleted resources, or by
+// the ones that would be deleted when not committing.
+type CostEstimate struct {
+	MonthlySavings float64 `json:"monthly_savings"`
+	// ByType holds the monthly savings per resource type, for the types that have a price.
+	ByType map[string]float64 `json:"by_type"`
+}
+
+// costPrices returns the default hourly prices, overridden by the given ones.
+func costPrices(overrides map[string]float64) map[string]float64 {
+	prices := make(map[string]float64, len(DefaultHourlyPrices)+len(overrides))
+	for resourceType, price := range DefaultHourlyPrices {
+		prices[resourceType] = price
+	}
+	for resourceType, price := range overrides {
+		prices[resourceType] = price
+	}
+
+	return prices
+}
+
+// String formats the estimate as its total followed by the savings of each resource type.
+func (c *CostEstimate) String() string {
+	resourceTypes := make([]string, 0, len(c.ByType))
+	for resourceType := range c.ByType {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	details := make([]string, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		details = append(details, fmt.Sprintf("%s: $%.2f", resourceType, c.ByType[resourceType]))
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("$%.2f", c.MonthlySavings)
+	}
+
+	return fmt.Sprintf("$%.2f (%s)", c.MonthlySavings, strings.Join(details, ", "))
+}
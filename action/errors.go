@@ -3,6 +3,43 @@ package action
 import "errors"
 
 var (
-	ErrAllRegionsNotAllowed = errors.New("all regions is not allowed")
-	ErrRegionsRequired      = errors.New("regions is required")
+	ErrAllRegionsNotAllowed         = errors.New("all regions is not allowed")
+	ErrRegionsRequired              = errors.New("regions is required")
+	ErrInvalidWorkers               = errors.New("workers must be at least 1")
+	ErrInvalidRegionWorkers         = errors.New("region workers must be at least 1")
+	ErrInvalidVPCWorkers            = errors.New("vpc workers must be at least 1")
+	ErrInvalidMaxRetries            = errors.New("max retries can't be negative")
+	ErrInvalidRateLimit             = errors.New("rate limit must be greater than 0")
+	ErrInvalidMinAge                = errors.New("min age can't be negative")
+	ErrInvalidGracePeriod           = errors.New("grace period can't be negative")
+	ErrPreviewWithCommit            = errors.New("preview can't be used with commit")
+	ErrPlanWithCommit               = errors.New("plan file can't be used with commit")
+	ErrPlanWithApplyPlan            = errors.New("plan file can't be used with apply plan")
+	ErrApplyPlanWithoutCommit       = errors.New("apply plan requires commit")
+	ErrInvalidPlan                  = errors.New("invalid plan")
+	ErrInvalidIgnoreTag             = errors.New("ignore tag must have a key")
+	ErrInvalidDeletionTag           = errors.New("deletion tag must have a key")
+	ErrInvalidTagSelector           = errors.New("tag selector is not a valid tag expression")
+	ErrInvalidNATGatewayTimeout     = errors.New("nat gateway timeout must be greater than 0")
+	ErrInvalidCloudFrontTimeout     = errors.New("cloudfront timeout can't be negative")
+	ErrInvalidTimeout               = errors.New("timeout can't be negative")
+	ErrInvalidCleanerTimeout        = errors.New("cleaner timeout can't be negative")
+	ErrUnknownResourceType          = errors.New("unknown resource type")
+	ErrInvalidLogFormat             = errors.New("log format must be text or json")
+	ErrInvalidMode                  = errors.New("mode must be mark-and-delete, mark-only or delete-only")
+	ErrInvalidKMSPendingWindow      = errors.New("kms pending window must be between 7 and 30 days")
+	ErrInvalidTaskDefinitionKeep    = errors.New("task definition keep must be 0 or more")
+	ErrInvalidSecretsRecoveryWindow = errors.New("secrets recovery window must be between 7 and 30 days")
+	ErrInvalidWebhookFormat         = errors.New("webhook format must be json or slack")
+	ErrInvalidRoleARN               = errors.New("role arn is not valid")
+	ErrUnexpectedAccount            = errors.New("account is not one of the expected accounts")
+	ErrNoRegionLeft                 = errors.New("no region left once the allowed and denied regions are applied")
+	ErrResourceFailed               = errors.New("failed to clean up resource")
+	ErrTooManyDeletions             = errors.New("too many resources would be deleted")
+	ErrInvalidMaxDeletions          = errors.New("max deletions can't be negative")
+	ErrInvalidMaxDeletionsAction    = errors.New("max deletions action must be abort or mark-only")
+	ErrInvalidEventBridgeBuses      = errors.New("eventbridge buses must be default, custom or all")
+	ErrInvalidCostPrice             = errors.New("cost prices can't be negative")
+	ErrUnknownCostPriceType         = errors.New("cost prices can only be set for nat-gateway, eip, volume, elb, elbv2 and rds-instance")
+	ErrInvalidDescriptionPattern    = errors.New("description pattern is not a valid regular expression")
 )
@@ -0,0 +1,23 @@
+package action
+
+import "testing"
+
+// testLogger writes the logs of the action to the logs of the test.
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Debug(msg string, a ...interface{}) { l.t.Logf("DEBUG "+msg, a...) }
+func (l testLogger) Info(msg string, a ...interface{})  { l.t.Logf("INFO "+msg, a...) }
+func (l testLogger) Warn(msg string, a ...interface{})  { l.t.Logf("WARN "+msg, a...) }
+func (l testLogger) Error(msg string, a ...interface{}) { l.t.Logf("ERROR "+msg, a...) }
+
+// newTestAction returns an action logging to the test, committing or not.
+func newTestAction(t *testing.T, commit bool) *action {
+	return &action{commit: commit, mode: ModeMarkAndDelete, deletionTag: DeletionTag, report: NewReport(), releasedCertificates: &releasedCertificates{}, logger: testLogger{t}}
+}
+
+// newTestScope returns the scope of a cleaner of the test action in region.
+func newTestScope(a *action, region string) *CleanupScope {
+	return &CleanupScope{Region: region, Mode: a.mode, DeletionTag: a.deletionTag, Report: a.report, Logger: a.logger}
+}
@@ -2,25 +2,99 @@ package action
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/caarlos0/env/v9"
 	"go.uber.org/multierr"
 )
 
 type Input struct {
-	Regions        string `env:"INPUT_REGIONS"`
-	AllowAllRegion bool   `env:"INPUT_ALLOW-ALL-REGIONS"`
-	Commit         bool   `env:"INPUT_COMMIT"`
-	IgnoreTag      string `env:"INPUT_IGNORE-TAG"`
+	Regions                    string            `env:"INPUT_REGIONS"`
+	AllowAllRegion             bool              `env:"INPUT_ALLOW-ALL-REGIONS"`
+	AllowedRegions             []string          `env:"INPUT_ALLOWED-REGIONS" envSeparator:","`
+	DeniedRegions              []string          `env:"INPUT_DENIED-REGIONS" envSeparator:","`
+	RoleARNs                   []string          `env:"INPUT_ROLE-ARNS" envSeparator:","`
+	RoleExternalID             string            `env:"INPUT_ROLE-EXTERNAL-ID"`
+	ExpectedAccountIDs         []string          `env:"INPUT_EXPECTED-ACCOUNT-IDS" envSeparator:","`
+	Commit                     bool              `env:"INPUT_COMMIT"`
+	Mode                       string            `env:"INPUT_MODE" envDefault:"mark-and-delete"`
+	Confirm                    bool              `env:"INPUT_CONFIRM"`
+	ConfirmToken               string            `env:"INPUT_CONFIRM-TOKEN"`
+	MaxDeletions               int               `env:"INPUT_MAX-DELETIONS" envDefault:"0"`
+	MaxDeletionsAction         string            `env:"INPUT_MAX-DELETIONS-ACTION" envDefault:"abort"`
+	IgnoreTag                  string            `env:"INPUT_IGNORE-TAG" envDefault:"janitor-ignore"`
+	DeletionTag                string            `env:"INPUT_DELETION-TAG" envDefault:"aws-janitor/marked-for-deletion"`
+	ExcludeIDs                 []string          `env:"INPUT_EXCLUDE-IDS" envSeparator:","`
+	Workers                    int               `env:"INPUT_WORKERS" envDefault:"1"`
+	RegionWorkers              int               `env:"INPUT_REGION-WORKERS" envDefault:"1"`
+	VPCWorkers                 int               `env:"INPUT_VPC-WORKERS" envDefault:"1"`
+	MaxRetries                 int               `env:"INPUT_MAX-RETRIES" envDefault:"5"`
+	RateLimit                  float64           `env:"INPUT_RATE-LIMIT" envDefault:"5"`
+	MinAge                     time.Duration     `env:"INPUT_MIN-AGE" envDefault:"0s"`
+	GracePeriod                time.Duration     `env:"INPUT_GRACE-PERIOD" envDefault:"0s"`
+	TrackFirstSeen             bool              `env:"INPUT_TRACK-FIRST-SEEN"`
+	MarkReason                 string            `env:"INPUT_MARK-REASON"`
+	Report                     string            `env:"INPUT_REPORT"`
+	Preview                    bool              `env:"INPUT_PREVIEW"`
+	PlanFile                   string            `env:"INPUT_PLAN-FILE"`
+	ApplyPlan                  string            `env:"INPUT_APPLY-PLAN"`
+	CostEstimate               bool              `env:"INPUT_COST-ESTIMATE"`
+	ReportCloudFormationStacks bool              `env:"INPUT_REPORT-CLOUDFORMATION-STACKS"`
+	RequiredTags               map[string]string `env:"INPUT_REQUIRED-TAGS"`
+	TagSelector                string            `env:"INPUT_TAG-SELECTOR"`
+	NATGatewayTimeout          time.Duration     `env:"INPUT_NAT-GATEWAY-TIMEOUT" envDefault:"10m"`
+	CloudFrontTimeout          time.Duration     `env:"INPUT_CLOUDFRONT-TIMEOUT" envDefault:"5m"`
+	Timeout                    time.Duration     `env:"INPUT_TIMEOUT" envDefault:"0s"`
+	CleanerTimeout             time.Duration     `env:"INPUT_CLEANER-TIMEOUT" envDefault:"0s"`
+	ResourceTypes              []string          `env:"INPUT_RESOURCE-TYPES" envSeparator:","`
+	ImmediateResourceTypes     []string          `env:"INPUT_IMMEDIATE-RESOURCE-TYPES" envSeparator:","`
+	LogFormat                  string            `env:"INPUT_LOG-FORMAT" envDefault:"text"`
+	ConfigFile                 string            `env:"INPUT_CONFIG-FILE"`
+	MetricsAddress             string            `env:"INPUT_METRICS-ADDRESS"`
+	MetricsPushgateway         string            `env:"INPUT_METRICS-PUSHGATEWAY"`
+	WebhookURL                 string            `env:"INPUT_WEBHOOK-URL"`
+	WebhookFormat              string            `env:"INPUT_WEBHOOK-FORMAT" envDefault:"json"`
+	ECRRepositoryPrefix        string            `env:"INPUT_ECR-REPOSITORY-PREFIX"`
+	GluePrefix                 string            `env:"INPUT_GLUE-PREFIX"`
+	TaskDefinitionPrefix       string            `env:"INPUT_TASK-DEFINITION-PREFIX"`
+	TaskDefinitionKeep         int               `env:"INPUT_TASK-DEFINITION-KEEP" envDefault:"0"`
+	PlacementGroupPrefix       string            `env:"INPUT_PLACEMENT-GROUP-PREFIX"`
+	KMSPendingWindow           int64             `env:"INPUT_KMS-PENDING-WINDOW" envDefault:"30"`
+	SecretsRecoveryWindow      int64             `env:"INPUT_SECRETS-RECOVERY-WINDOW" envDefault:"30"`
+	SecretsForceDelete         bool              `env:"INPUT_SECRETS-FORCE-DELETE"`
+	DeleteACMCertificates      bool              `env:"INPUT_DELETE-ACM-CERTIFICATES"`
+	ForceDetachENIs            bool              `env:"INPUT_FORCE-DETACH-ENIS"`
+	DisableELBv2Protection     bool              `env:"INPUT_ELBV2-DISABLE-PROTECTION"`
+	TerminateSpotInstances     bool              `env:"INPUT_TERMINATE-SPOT-INSTANCES"`
+	StopStateMachineExecutions bool              `env:"INPUT_STOP-SFN-EXECUTIONS"`
+	EventBridgeBuses           string            `env:"INPUT_EVENTBRIDGE-BUSES" envDefault:"default"`
+	ENIVPCIDs                  []string          `env:"INPUT_ENI-VPC-IDS" envSeparator:","`
+	ENIRequesterIDs            []string          `env:"INPUT_ENI-REQUESTER-IDS" envSeparator:","`
+	ENIInterfaceTypes          []string          `env:"INPUT_ENI-INTERFACE-TYPES" envSeparator:","`
+	ENIIncludeDescriptions     []string          `env:"INPUT_ENI-INCLUDE-DESCRIPTIONS" envSeparator:","`
+	ENIExcludeDescriptions     []string          `env:"INPUT_ENI-EXCLUDE-DESCRIPTIONS" envSeparator:","`
+	// CostPrices overrides the default hourly prices of the cost estimate, keyed by resource type.
+	CostPrices map[string]float64 `env:"INPUT_COST-PRICES"`
 }
 
-// NewInput creates a new input from the environment variables.
+// NewInput creates a new input from the environment variables, completed with the
+// config file if one is set.
 func NewInput() (*Input, error) {
 	input := &Input{}
 	if err := env.Parse(input); err != nil {
 		return nil, fmt.Errorf("parsing environment variables: %w", err)
 	}
 
+	if input.ConfigFile != "" {
+		config, err := LoadConfig(input.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		config.apply(input)
+	}
+
 	return input, nil
 }
 
@@ -35,5 +109,182 @@ func (i *Input) Validate() error {
 		err = multierr.Append(err, ErrAllRegionsNotAllowed)
 	}
 
+	for _, roleARN := range i.RoleARNs {
+		if roleARN = strings.TrimSpace(roleARN); roleARN != "" && !arn.IsARN(roleARN) {
+			err = multierr.Append(err, fmt.Errorf("%w: %q", ErrInvalidRoleARN, roleARN))
+		}
+	}
+
+	if i.Workers < 1 {
+		err = multierr.Append(err, ErrInvalidWorkers)
+	}
+
+	if i.RegionWorkers < 1 {
+		err = multierr.Append(err, ErrInvalidRegionWorkers)
+	}
+
+	if i.VPCWorkers < 1 {
+		err = multierr.Append(err, ErrInvalidVPCWorkers)
+	}
+
+	if i.MaxRetries < 0 {
+		err = multierr.Append(err, ErrInvalidMaxRetries)
+	}
+
+	if i.RateLimit <= 0 {
+		err = multierr.Append(err, ErrInvalidRateLimit)
+	}
+
+	if i.MinAge < 0 {
+		err = multierr.Append(err, ErrInvalidMinAge)
+	}
+
+	if i.GracePeriod < 0 {
+		err = multierr.Append(err, ErrInvalidGracePeriod)
+	}
+
+	if _, ignoreErr := parseIgnoreTags(i.IgnoreTag); ignoreErr != nil {
+		err = multierr.Append(err, ignoreErr)
+	}
+
+	if strings.TrimSpace(i.DeletionTag) == "" {
+		err = multierr.Append(err, ErrInvalidDeletionTag)
+	}
+
+	if _, selectorErr := parseTagExpression(i.TagSelector); selectorErr != nil {
+		err = multierr.Append(err, selectorErr)
+	}
+
+	if i.NATGatewayTimeout <= 0 {
+		err = multierr.Append(err, ErrInvalidNATGatewayTimeout)
+	}
+
+	if i.CloudFrontTimeout < 0 {
+		err = multierr.Append(err, ErrInvalidCloudFrontTimeout)
+	}
+
+	if i.Timeout < 0 {
+		err = multierr.Append(err, ErrInvalidTimeout)
+	}
+
+	if i.CleanerTimeout < 0 {
+		err = multierr.Append(err, ErrInvalidCleanerTimeout)
+	}
+
+	if i.TaskDefinitionKeep < 0 {
+		err = multierr.Append(err, ErrInvalidTaskDefinitionKeep)
+	}
+
+	if i.KMSPendingWindow < 7 || i.KMSPendingWindow > 30 {
+		err = multierr.Append(err, ErrInvalidKMSPendingWindow)
+	}
+
+	if i.SecretsRecoveryWindow < 7 || i.SecretsRecoveryWindow > 30 {
+		err = multierr.Append(err, ErrInvalidSecretsRecoveryWindow)
+	}
+
+	if i.LogFormat != LogFormatText && i.LogFormat != LogFormatJSON {
+		err = multierr.Append(err, ErrInvalidLogFormat)
+	}
+
+	if i.WebhookFormat != WebhookFormatJSON && i.WebhookFormat != WebhookFormatSlack {
+		err = multierr.Append(err, ErrInvalidWebhookFormat)
+	}
+
+	if i.Mode != ModeMarkAndDelete && i.Mode != ModeMarkOnly && i.Mode != ModeDeleteOnly {
+		err = multierr.Append(err, ErrInvalidMode)
+	}
+
+	if i.MaxDeletions < 0 {
+		err = multierr.Append(err, ErrInvalidMaxDeletions)
+	}
+
+	if i.MaxDeletionsAction != MaxDeletionsActionAbort && i.MaxDeletionsAction != MaxDeletionsActionMarkOnly {
+		err = multierr.Append(err, ErrInvalidMaxDeletionsAction)
+	}
+
+	if i.EventBridgeBuses != EventBridgeBusesDefault && i.EventBridgeBuses != EventBridgeBusesCustom && i.EventBridgeBuses != EventBridgeBusesAll {
+		err = multierr.Append(err, ErrInvalidEventBridgeBuses)
+	}
+
+	if _, patternErr := parseDescriptionPatterns(i.ENIIncludeDescriptions); patternErr != nil {
+		err = multierr.Append(err, patternErr)
+	}
+
+	if _, patternErr := parseDescriptionPatterns(i.ENIExcludeDescriptions); patternErr != nil {
+		err = multierr.Append(err, patternErr)
+	}
+
+	for resourceType, price := range i.CostPrices {
+		if _, ok := pricedReportTypes[resourceType]; !ok {
+			err = multierr.Append(err, fmt.Errorf("%w: %s", ErrUnknownCostPriceType, resourceType))
+			continue
+		}
+		if price < 0 {
+			err = multierr.Append(err, ErrInvalidCostPrice)
+			break
+		}
+	}
+
+	if i.Preview && i.Commit {
+		err = multierr.Append(err, ErrPreviewWithCommit)
+	}
+
+	if i.PlanFile != "" && i.Commit {
+		err = multierr.Append(err, ErrPlanWithCommit)
+	}
+
+	if i.PlanFile != "" && i.ApplyPlan != "" {
+		err = multierr.Append(err, ErrPlanWithApplyPlan)
+	}
+
+	if i.ApplyPlan != "" && !i.Commit {
+		err = multierr.Append(err, ErrApplyPlanWithoutCommit)
+	}
+
 	return err
 }
+
+// parseIgnoreTags parses a comma separated list of ignore tags. Each entry is either
+// a tag key, which matches any value, or a key=value pair where value can be "*".
+func parseIgnoreTags(value string) ([]IgnoreTag, error) {
+	ignoreTags := []IgnoreTag{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, tagValue, _ := strings.Cut(entry, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidIgnoreTag, entry)
+		}
+		ignoreTags = append(ignoreTags, IgnoreTag{Key: key, Value: strings.TrimSpace(tagValue)})
+	}
+
+	return ignoreTags, nil
+}
+
+// splitList returns the trimmed values of the list, ignoring the empty ones.
+func splitList(values []string) []string {
+	list := []string{}
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
+
+// parseIDs returns the set of the ids, ignoring the empty ones.
+func parseIDs(ids []string) map[string]bool {
+	set := map[string]bool{}
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			set[id] = true
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,29 @@
+package action
+
+// Logger is used by the action to write what it's doing. Messages are formatted
+// with fmt.Sprintf semantics.
+type Logger interface {
+	Debug(msg string, a ...interface{})
+	Info(msg string, a ...interface{})
+	Warn(msg string, a ...interface{})
+	Error(msg string, a ...interface{})
+}
+
+// stdoutLogger is the default Logger, it writes to stdout using the package log functions.
+type stdoutLogger struct{}
+
+func (stdoutLogger) Debug(msg string, a ...interface{}) { LogDebug(msg, a...) }
+func (stdoutLogger) Info(msg string, a ...interface{})  { Log(msg, a...) }
+func (stdoutLogger) Warn(msg string, a ...interface{})  { LogWarning(msg, a...) }
+func (stdoutLogger) Error(msg string, a ...interface{}) { LogError(msg, a...) }
+
+// prefixedLogger prepends a prefix to the messages, to tell apart the lines of work running concurrently.
+type prefixedLogger struct {
+	Logger
+	prefix string
+}
+
+func (l prefixedLogger) Debug(msg string, a ...interface{}) { l.Logger.Debug(l.prefix+msg, a...) }
+func (l prefixedLogger) Info(msg string, a ...interface{})  { l.Logger.Info(l.prefix+msg, a...) }
+func (l prefixedLogger) Warn(msg string, a ...interface{})  { l.Logger.Warn(l.prefix+msg, a...) }
+func (l prefixedLogger) Error(msg string, a ...interface{}) { l.Logger.Error(l.prefix+msg, a...) }
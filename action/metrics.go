@@ -0,0 +1,69 @@
+package action
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// metricsJob is the job the metrics are pushed to the pushgateway as.
+const metricsJob = "aws_janitor"
+
+// Metrics exposes what the action did as prometheus metrics. It's fed by the report,
+// so it counts the same resources as the summary and the json report.
+type Metrics struct {
+	registry    *prometheus.Registry
+	resources   *prometheus.CounterVec
+	runDuration prometheus.Histogram
+}
+
+// NewMetrics creates the metrics, registered on their own registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		resources: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "aws_janitor_resources_total",
+			Help: "Number of resources marked, deleted, skipped or failed, per resource type.",
+		}, []string{"resource_type", "action"}),
+		runDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "aws_janitor_run_duration_seconds",
+			Help:    "Duration of the cleanup runs.",
+			Buckets: prometheus.ExponentialBuckets(30, 2, 10),
+		}),
+	}
+	m.registry.MustRegister(m.resources, m.runDuration)
+
+	return m
+}
+
+// Handler returns the http handler serving the metrics to be scraped.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Push pushes the metrics to the pushgateway at url, for runs that end before being scraped.
+func (m *Metrics) Push(url string) error {
+	if err := push.New(url, metricsJob).Gatherer(m.registry).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+
+	return nil
+}
+
+func (m *Metrics) countResource(action, resourceType string) {
+	if m == nil {
+		return
+	}
+	m.resources.WithLabelValues(resourceType, action).Inc()
+}
+
+func (m *Metrics) observeRun(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.runDuration.Observe(duration.Seconds())
+}
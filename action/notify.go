@@ -0,0 +1,165 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// WebhookFormatJSON posts the notification as is.
+	WebhookFormatJSON = "json"
+	// WebhookFormatSlack posts the notification as a slack message.
+	WebhookFormatSlack = "slack"
+
+	webhookTimeout = 30 * time.Second
+)
+
+// Notification summarizes a run, it's what is posted to the webhook.
+type Notification struct {
+	Accounts  []string                  `json:"accounts"`
+	Regions   []string                  `json:"regions"`
+	Commit    bool                      `json:"commit"`
+	Resources map[string]ResourceCounts `json:"resources"`
+	Error     string                    `json:"error,omitempty"`
+}
+
+// ResourceCounts holds how many resources of a type were handled in each way.
+type ResourceCounts struct {
+	Marked      int `json:"marked"`
+	Deleted     int `json:"deleted"`
+	Scheduled   int `json:"scheduled"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+	WouldMark   int `json:"would_mark"`
+	WouldDelete int `json:"would_delete"`
+}
+
+// counts returns, per resource type, how many resources were handled in each way.
+func (r *Report) counts() map[string]ResourceCounts {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := map[string]ResourceCounts{}
+	for resourceType, rr := range r.Resources {
+		counts[resourceType] = ResourceCounts{
+			Marked:      len(rr.Marked),
+			Deleted:     len(rr.Deleted),
+			Scheduled:   len(rr.Scheduled),
+			Skipped:     len(rr.Skipped),
+			Failed:      len(rr.Failed),
+			WouldMark:   len(rr.WouldMark),
+			WouldDelete: len(rr.WouldDelete),
+		}
+	}
+
+	return counts
+}
+
+// notify posts the summary of the run to the webhook, if one is set. Failing to
+// notify is only logged, it never fails the run.
+func (a *action) notify(ctx context.Context, accounts, inputRegions []string, runErr error) {
+	if a.webhookURL == "" {
+		return
+	}
+
+	// the run may have stopped because its deadline was exceeded, which mustn't
+	// prevent the notification from being sent.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookTimeout)
+	defer cancel()
+
+	notification := Notification{
+		Accounts:  accounts,
+		Regions:   inputRegions,
+		Commit:    a.commit,
+		Resources: a.report.counts(),
+	}
+	if runErr != nil {
+		notification.Error = runErr.Error()
+	}
+
+	if err := a.postNotification(ctx, notification); err != nil {
+		a.logger.Warn("failed to send the notification: %s", err.Error())
+		return
+	}
+	a.logger.Debug("notification sent")
+}
+
+func (a *action) postNotification(ctx context.Context, notification Notification) error {
+	var payload interface{} = notification
+	if a.webhookFormat == WebhookFormatSlack {
+		payload = slackMessage(notification)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// slackMessage formats the notification as a slack incoming webhook message, with
+// the same counts as the summary.
+func slackMessage(notification Notification) map[string]string {
+	mode := "dry run"
+	if notification.Commit {
+		mode = "commit"
+	}
+
+	lines := []string{}
+	if len(notification.Accounts) > 0 {
+		lines = append(lines, fmt.Sprintf("*aws janitor* (%s) finished for accounts %s in %s", mode, strings.Join(notification.Accounts, ", "), strings.Join(notification.Regions, ", ")))
+	} else {
+		lines = append(lines, fmt.Sprintf("*aws janitor* (%s) finished in %s", mode, strings.Join(notification.Regions, ", ")))
+	}
+
+	if len(notification.Resources) == 0 {
+		lines = append(lines, "no resources found")
+	}
+	for _, resourceType := range sortedKeys(notification.Resources) {
+		counts := notification.Resources[resourceType]
+		if notification.Commit {
+			lines = append(lines, fmt.Sprintf("• %s: %d marked, %d deleted, %d scheduled, %d skipped, %d failed", resourceType, counts.Marked, counts.Deleted, counts.Scheduled, counts.Skipped, counts.Failed))
+		} else {
+			lines = append(lines, fmt.Sprintf("• %s: %d would be marked, %d would be deleted, %d skipped, %d failed", resourceType, counts.WouldMark, counts.WouldDelete, counts.Skipped, counts.Failed))
+		}
+	}
+
+	if notification.Error != "" {
+		lines = append(lines, "errors: "+notification.Error)
+	}
+
+	return map[string]string{"text": strings.Join(lines, "\n")}
+}
+
+func sortedKeys(resources map[string]ResourceCounts) []string {
+	keys := make([]string, 0, len(resources))
+	for key := range resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
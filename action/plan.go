@@ -0,0 +1,100 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// Plan holds the resources a run would delete. It's written by a plan run and applied by a later
+// run, which only deletes these resources, provided they still match the selection.
+type Plan struct {
+	// RunID identifies the run the plan was computed by.
+	RunID     string            `json:"run_id"`
+	CreatedAt time.Time         `json:"created_at"`
+	Resources []plannedDeletion `json:"resources"`
+}
+
+// writePlan runs the cleaners in dry-mode, account by account, and writes the resources that
+// would be deleted to the plan file. No plan is written when some cleaners failed, as it would
+// be incomplete.
+func (a *action) writePlan(ctx context.Context, input *Input, stages [][]Cleaner, accounts []*account, inputRegions []string) error {
+	deletions, err := a.runStagesByAccount(ctx, input, stages, accounts, inputRegions)
+	if err != nil {
+		return fmt.Errorf("failed looking for the resources to delete, no plan was written: %w", err)
+	}
+
+	data, err := json.MarshalIndent(Plan{RunID: a.runID, CreatedAt: time.Now().UTC(), Resources: deletions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	if err := os.WriteFile(a.planFile, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write plan to %s: %w", a.planFile, err)
+	}
+	a.logger.Info("Wrote the plan of the %d resources to delete to %s", len(deletions), a.planFile)
+
+	return nil
+}
+
+// loadPlan reads the plan to apply and approves the deletion of the resources it holds, and only
+// them. Nothing is marked for deletion while applying a plan: only the marked resources are deleted,
+// along with the unmarked ones of the immediate types the plan holds. ErrTooManyDeletions is returned
+// when the plan holds more resources than the maximum number of deletions.
+func (a *action) loadPlan() error {
+	data, err := os.ReadFile(a.applyPlanFile)
+	if err != nil {
+		return fmt.Errorf("failed to read plan %s: %w", a.applyPlanFile, err)
+	}
+
+	plan := Plan{}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidPlan, a.applyPlanFile, err.Error())
+	}
+
+	if a.maxDeletions > 0 && len(plan.Resources) > a.maxDeletions {
+		err := fmt.Errorf("%w: the plan holds %d resources, the maximum is %d", ErrTooManyDeletions, len(plan.Resources), a.maxDeletions)
+		a.logger.Error("%s, aborting", err.Error())
+		return err
+	}
+
+	a.logger.Info("Applying plan %s of run %s, computed at %s: only the %d resources it holds are deleted, and nothing is marked",
+		a.applyPlanFile, plan.RunID, plan.CreatedAt.Format(time.RFC3339), len(plan.Resources))
+	a.mode = ModeDeleteOnly
+	a.approved = map[string]bool{}
+	for _, entry := range plan.Resources {
+		a.approved[approvalKey(entry.Account, entry.Region, entry.Type, entry.ID)] = true
+	}
+
+	return nil
+}
+
+// runStagesByAccount runs the stages like runStages, one account at a time, and returns the
+// resources that would be deleted along with the account they're in. It's only meant for dry runs.
+func (a *action) runStagesByAccount(ctx context.Context, input *Input, stages [][]Cleaner, accounts []*account, inputRegions []string) ([]plannedDeletion, error) {
+	var errs error
+	deletions := []plannedDeletion{}
+	// the report doesn't record the accounts, the resources found since the previous
+	// account are the ones of the current account. They're counted as the same resource, e.g.
+	// a role name, can be in several accounts.
+	known := map[plannedDeletion]int{}
+	for _, acc := range accounts {
+		errs = multierr.Append(errs, a.runStages(ctx, input, stages, []*account{acc}, inputRegions))
+
+		found := map[plannedDeletion]int{}
+		for _, deletion := range a.report.toDelete() {
+			found[deletion]++
+			if found[deletion] > known[deletion] {
+				known[deletion]++
+				deletion.Account = acc.ID
+				deletions = append(deletions, deletion)
+			}
+		}
+	}
+
+	return deletions, errs
+}
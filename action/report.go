@@ -0,0 +1,389 @@
+package action
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// Report collects what the cleaners did with the resources they considered.
+// It's safe for concurrent use.
+type Report struct {
+	mu sync.Mutex
+	// RunID identifies the run the report is about, as written on the resources it marked.
+	RunID     string                     `json:"run_id,omitempty"`
+	Resources map[string]*ResourceReport `json:"resources"`
+	// Stacks holds the cloudformation stacks whose resources were skipped, keyed by region and stack name.
+	Stacks map[string]*StackReport `json:"cloudformation_stacks,omitempty"`
+	// FailedCleaners holds the cleaners that didn't finish, e.g. because they timed out.
+	FailedCleaners []CleanerFailure `json:"failed_cleaners,omitempty"`
+	// CostEstimate is only set when the cost of the deleted resources is estimated.
+	CostEstimate *CostEstimate `json:"cost_estimate,omitempty"`
+	// metrics, when set, counts the resources along with the report.
+	metrics *Metrics
+	// sizes holds the size of the resources priced by size, e.g. volumes in GiB, keyed by
+	// resource type, region and id.
+	sizes map[string]int64
+}
+
+// StackReport lists the resources skipped because they're managed by a cloudformation stack,
+// which keeps them alive until the stack itself is deleted.
+type StackReport struct {
+	Name      string   `json:"name"`
+	Region    string   `json:"region"`
+	Resources []string `json:"resources"`
+}
+
+// CleanerFailure identifies a cleaner that didn't finish, the region it ran in and why.
+type CleanerFailure struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	Reason string `json:"reason"`
+}
+
+// ResourceReport holds the entries for a single resource type.
+type ResourceReport struct {
+	Marked  []ReportEntry `json:"marked"`
+	Deleted []ReportEntry `json:"deleted"`
+	Skipped []ReportEntry `json:"skipped"`
+	Failed  []ReportEntry `json:"failed"`
+	// Scheduled holds the resources whose deletion was requested but only happens after a waiting period.
+	Scheduled []ReportEntry `json:"scheduled,omitempty"`
+	// WouldMark and WouldDelete are only filled when running in dry-mode.
+	WouldMark   []ReportEntry `json:"would_mark,omitempty"`
+	WouldDelete []ReportEntry `json:"would_delete,omitempty"`
+}
+
+// ReportEntry identifies a resource, the region it's in and, for skipped or failed ones, why.
+// For scheduled ones, the reason holds when the deletion happens.
+type ReportEntry struct {
+	ID     string `json:"id"`
+	Region string `json:"region"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// NewReport creates an empty report.
+func NewReport() *Report {
+	return &Report{
+		Resources: map[string]*ResourceReport{},
+		Stacks:    map[string]*StackReport{},
+		sizes:     map[string]int64{},
+	}
+}
+
+// event logs what was done with a resource and counts it in the metrics.
+func (r *Report) event(action, region, resourceType, id, reason string) {
+	logResourceEvent(action, region, resourceType, id, reason)
+	if r != nil {
+		r.metrics.countResource(action, resourceType)
+	}
+}
+
+func (r *Report) marked(region, resourceType, id string) {
+	r.event("marked", region, resourceType, id, "")
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.Marked = append(rr.Marked, ReportEntry{ID: id, Region: region})
+	})
+}
+
+func (r *Report) deleted(region, resourceType, id string) {
+	r.event("deleted", region, resourceType, id, "")
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.Deleted = append(rr.Deleted, ReportEntry{ID: id, Region: region})
+	})
+}
+
+func (r *Report) scheduled(region, resourceType, id, reason string) {
+	r.event("scheduled", region, resourceType, id, reason)
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.Scheduled = append(rr.Scheduled, ReportEntry{ID: id, Region: region, Reason: reason})
+	})
+}
+
+func (r *Report) skipped(region, resourceType, id, reason string) {
+	r.event("skipped", region, resourceType, id, reason)
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.Skipped = append(rr.Skipped, ReportEntry{ID: id, Region: region, Reason: reason})
+	})
+}
+
+// managedByStack records a resource skipped because it's managed by the cloudformation stack stackName.
+func (r *Report) managedByStack(region, resourceType, id, stackName string) {
+	r.skipped(region, resourceType, id, "managed by cloudformation stack "+stackName)
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := region + "/" + stackName
+	stack, ok := r.Stacks[key]
+	if !ok {
+		stack = &StackReport{Name: stackName, Region: region}
+		r.Stacks[key] = stack
+	}
+	stack.Resources = append(stack.Resources, resourceType+" "+id)
+}
+
+func (r *Report) failed(region, resourceType, id, reason string) {
+	r.event("failed", region, resourceType, id, reason)
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.Failed = append(rr.Failed, ReportEntry{ID: id, Region: region, Reason: reason})
+	})
+}
+
+// cleanerFailed records a cleaner that didn't finish.
+func (r *Report) cleanerFailed(region, name, reason string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.FailedCleaners = append(r.FailedCleaners, CleanerFailure{Name: name, Region: region, Reason: reason})
+}
+
+func (r *Report) wouldMark(region, resourceType, id string) {
+	r.event("would_mark", region, resourceType, id, "")
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.WouldMark = append(rr.WouldMark, ReportEntry{ID: id, Region: region})
+	})
+}
+
+func (r *Report) wouldDelete(region, resourceType, id string) {
+	r.event("would_delete", region, resourceType, id, "")
+	r.add(resourceType, func(rr *ResourceReport) {
+		rr.WouldDelete = append(rr.WouldDelete, ReportEntry{ID: id, Region: region})
+	})
+}
+
+// size records the size of a resource, which its cost is estimated from.
+func (r *Report) size(region, resourceType, id string, size int64) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sizes[resourceType+"/"+region+"/"+id] = size
+}
+
+func (r *Report) add(resourceType string, fn func(*ResourceReport)) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rr, ok := r.Resources[resourceType]
+	if !ok {
+		rr = &ResourceReport{Marked: []ReportEntry{}, Deleted: []ReportEntry{}, Skipped: []ReportEntry{}, Failed: []ReportEntry{}}
+		r.Resources[resourceType] = rr
+	}
+	fn(rr)
+}
+
+// Write serializes the report as json to the file at path, or to stdout if path is "-".
+func (r *Report) Write(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+
+	if path == "-" {
+		logMu.Lock()
+		defer logMu.Unlock()
+		fmt.Println(string(data)) //nolint: forbidigo
+		return nil
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Err returns the failures of the resources, combined, or nil if none failed. Each of them
+// wraps ErrResourceFailed.
+func (r *Report) Err() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs error
+	for _, resourceType := range r.resourceTypes() {
+		for _, entry := range r.Resources[resourceType].Failed {
+			errs = multierr.Append(errs, fmt.Errorf("%w: %s %s in region %s: %s", ErrResourceFailed, resourceType, entry.ID, entry.Region, entry.Reason))
+		}
+	}
+
+	return errs
+}
+
+// estimateCost sets the cost estimate of the report from the hourly prices of the resource types,
+// counting the deleted resources or, when not committing, the ones that would be deleted.
+func (r *Report) estimateCost(prices map[string]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	estimate := &CostEstimate{ByType: map[string]float64{}}
+	for resourceType, price := range prices {
+		reportType := pricedReportTypes[resourceType]
+		rr, ok := r.Resources[reportType]
+		if !ok {
+			continue
+		}
+
+		units := int64(0)
+		for _, entries := range [][]ReportEntry{rr.Deleted, rr.WouldDelete} {
+			for _, entry := range entries {
+				if size, ok := r.sizes[reportType+"/"+entry.Region+"/"+entry.ID]; ok {
+					units += size
+				} else {
+					units++
+				}
+			}
+		}
+		if units == 0 {
+			continue
+		}
+
+		savings := price * float64(units) * hoursPerMonth
+		estimate.ByType[resourceType] = savings
+		estimate.MonthlySavings += savings
+	}
+	r.CostEstimate = estimate
+}
+
+// plannedDeletion is a resource that would be deleted. The account is only known to the
+// runs going through the accounts one by one.
+type plannedDeletion struct {
+	Account string `json:"account,omitempty"`
+	Region  string `json:"region"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+}
+
+// toDelete returns the resources that would be deleted, sorted by resource type.
+func (r *Report) toDelete() []plannedDeletion {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deletions := []plannedDeletion{}
+	for _, resourceType := range r.resourceTypes() {
+		for _, entry := range r.Resources[resourceType].WouldDelete {
+			deletions = append(deletions, plannedDeletion{Type: resourceType, ID: entry.ID, Region: entry.Region})
+		}
+	}
+
+	return deletions
+}
+
+// resourceTypes returns the resource types in the report, sorted. The caller must hold the lock.
+func (r *Report) resourceTypes() []string {
+	resourceTypes := make([]string, 0, len(r.Resources))
+	for resourceType := range r.Resources {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+
+	return resourceTypes
+}
+
+// PrintSummary logs, per resource type, how many resources were marked, deleted, scheduled
+// for deletion, skipped or failed. When not committing, it logs how many would have been marked or deleted instead.
+// The mode is logged along with the summary, unless resources are marked and deleted, and so
+// are the estimated savings when the cost was estimated.
+func (r *Report) PrintSummary(logger Logger, commit bool, mode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if mode != ModeMarkAndDelete {
+		logger.Info("Summary (%s mode):", mode)
+	} else {
+		logger.Info("Summary:")
+	}
+	for _, failure := range r.FailedCleaners {
+		logger.Info("  cleaner %s failed in %s: %s", failure.Name, failure.Region, failure.Reason)
+	}
+	if len(r.Resources) == 0 {
+		logger.Info("  no resources found")
+		return
+	}
+
+	for _, resourceType := range r.resourceTypes() {
+		rr := r.Resources[resourceType]
+		if commit {
+			logger.Info("  %s: %d marked, %d deleted, %d scheduled, %d skipped, %d failed", resourceType, len(rr.Marked), len(rr.Deleted), len(rr.Scheduled), len(rr.Skipped), len(rr.Failed))
+		} else {
+			logger.Info("  %s: %d would be marked, %d would be deleted, %d skipped, %d failed", resourceType, len(rr.WouldMark), len(rr.WouldDelete), len(rr.Skipped), len(rr.Failed))
+		}
+	}
+
+	if r.CostEstimate != nil {
+		logger.Info("  estimated monthly savings: %s", r.CostEstimate)
+	}
+}
+
+// PrintPreview logs, per resource type, the resources that would be marked for
+// future deletion and the ones that would be deleted.
+func (r *Report) PrintPreview(logger Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, resourceType := range r.resourceTypes() {
+		rr := r.Resources[resourceType]
+		if len(rr.WouldMark) == 0 && len(rr.WouldDelete) == 0 {
+			continue
+		}
+
+		logger.Info("Preview for %s:", resourceType)
+		logger.Info("  would be marked for future deletion (%d):", len(rr.WouldMark))
+		for _, entry := range rr.WouldMark {
+			logger.Info("    - %s (%s)", entry.ID, entry.Region)
+		}
+		logger.Info("  would be deleted (%d):", len(rr.WouldDelete))
+		for _, entry := range rr.WouldDelete {
+			logger.Info("    - %s (%s)", entry.ID, entry.Region)
+		}
+	}
+}
+
+// PrintStacks logs the cloudformation stacks that keep skipped resources alive, along
+// with these resources, so they can be torn down.
+func (r *Report) PrintStacks(logger Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.Stacks) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(r.Stacks))
+	for key := range r.Stacks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	logger.Info("CloudFormation stacks keeping resources alive (%d):", len(keys))
+	for _, key := range keys {
+		stack := r.Stacks[key]
+		logger.Info("  - %s (%s): %s", stack.Name, stack.Region, strings.Join(stack.Resources, ", "))
+	}
+}
@@ -0,0 +1,67 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const (
+	retryBaseDelay = 1 * time.Second
+	retryMaxDelay  = 30 * time.Second
+)
+
+// throttlingErrorCodes are the aws error codes returned when requests are being throttled.
+var throttlingErrorCodes = map[string]struct{}{
+	"Throttling":                             {},
+	"ThrottlingException":                    {},
+	"ThrottledException":                     {},
+	"RequestThrottledException":              {},
+	"TooManyRequestsException":               {},
+	"ProvisionedThroughputExceededException": {},
+	"RequestLimitExceeded":                   {},
+	"BandwidthLimitExceeded":                 {},
+	"RequestThrottled":                       {},
+	"SlowDown":                               {},
+	"EC2ThrottledException":                  {},
+}
+
+func isThrottlingError(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	_, ok := throttlingErrorCodes[aerr.Code()]
+	return ok
+}
+
+// retryOnThrottling calls fn until it succeeds, fails with an error that isn't caused by
+// throttling or the maximum number of retries is reached. It backs off exponentially,
+// with jitter, between attempts.
+func (a *action) retryOnThrottling(ctx context.Context, fn func(context.Context) error) error {
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !isThrottlingError(err) || attempt >= a.maxRetries {
+			return err
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		a.logger.Debug("request throttled, retrying in %v (attempt %d/%d)", wait, attempt+1, a.maxRetries)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package action
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type tagOperator int
+
+const (
+	// tagExists matches the resources carrying the tag, with any value.
+	tagExists tagOperator = iota
+	// tagNotExists matches the resources without the tag.
+	tagNotExists
+	// tagIn matches the resources carrying the tag with one of the values, = is tagIn with a single value.
+	tagIn
+	// tagNotIn matches the resources without the tag or with another value, != is tagNotIn with a single value.
+	tagNotIn
+)
+
+// tagCondition is a predicate on a single tag of a resource.
+type tagCondition struct {
+	Key      string
+	Operator tagOperator
+	Values   []string
+}
+
+func (c tagCondition) matches(tags Tags) bool {
+	value, ok := tags[c.Key]
+	switch c.Operator {
+	case tagExists:
+		return ok
+	case tagNotExists:
+		return !ok
+	case tagIn:
+		return ok && slices.Contains(c.Values, value)
+	case tagNotIn:
+		return !ok || !slices.Contains(c.Values, value)
+	}
+	return false
+}
+
+// tagExpression is a predicate on the tags of a resource, e.g. `env in (dev,test) and keep != true`.
+// It's a disjunction of conjunctions of conditions: `and` binds tighter than `or`. An empty
+// expression matches any resource.
+type tagExpression [][]tagCondition
+
+func (e tagExpression) matches(tags Tags) bool {
+	if len(e) == 0 {
+		return true
+	}
+
+	for _, conditions := range e {
+		if allTagConditions(conditions, tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func allTagConditions(conditions []tagCondition, tags Tags) bool {
+	for _, condition := range conditions {
+		if !condition.matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTagExpression parses a tag expression made of conditions joined by `and` and `or`. A
+// condition is one of:
+//
+//	key                  the tag exists
+//	!key                 the tag doesn't exist
+//	key = value          the tag has the value
+//	key != value         the tag doesn't exist or has another value
+//	key in (v1, v2)      the tag has one of the values
+//	key not in (v1, v2)  the tag doesn't exist or has none of the values
+//
+// Keys and values holding spaces or operators can be quoted with " or '.
+func parseTagExpression(value string) (tagExpression, error) {
+	tokens, err := tokenizeTagExpression(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTagSelector, err.Error())
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &tagExpressionParser{tokens: tokens}
+	expression, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTagSelector, err.Error())
+	}
+	return expression, nil
+}
+
+// tagToken is a token of a tag expression. Quoted tokens are never operators nor keywords.
+type tagToken struct {
+	Text   string
+	Quoted bool
+}
+
+func (t tagToken) is(text string) bool {
+	return !t.Quoted && strings.EqualFold(t.Text, text)
+}
+
+func tokenizeTagExpression(value string) ([]tagToken, error) {
+	tokens := []tagToken{}
+	for i := 0; i < len(value); {
+		switch c := value[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(value[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote at %d", i)
+			}
+			tokens = append(tokens, tagToken{Text: value[i+1 : i+1+end], Quoted: true})
+			i += end + 2
+		case strings.HasPrefix(value[i:], "!=") || strings.HasPrefix(value[i:], "=="):
+			tokens = append(tokens, tagToken{Text: value[i : i+2]})
+			i += 2
+		case strings.IndexByte("=!(),", c) >= 0:
+			tokens = append(tokens, tagToken{Text: value[i : i+1]})
+			i++
+		default:
+			end := i
+			for end < len(value) && strings.IndexByte(" \t\n=!(),\"'", value[end]) < 0 {
+				end++
+			}
+			tokens = append(tokens, tagToken{Text: value[i:end]})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+type tagExpressionParser struct {
+	tokens []tagToken
+	pos    int
+}
+
+func (p *tagExpressionParser) peek() (tagToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return tagToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *tagExpressionParser) next() (tagToken, bool) {
+	token, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return token, ok
+}
+
+// accept consumes the next token if it's text.
+func (p *tagExpressionParser) accept(text string) bool {
+	if token, ok := p.peek(); ok && token.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *tagExpressionParser) parseExpression() (tagExpression, error) {
+	expression := tagExpression{}
+	for {
+		conditions, err := p.parseConjunction()
+		if err != nil {
+			return nil, err
+		}
+		expression = append(expression, conditions)
+
+		if !p.accept("or") {
+			break
+		}
+	}
+
+	if token, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", token.Text)
+	}
+	return expression, nil
+}
+
+func (p *tagExpressionParser) parseConjunction() ([]tagCondition, error) {
+	conditions := []tagCondition{}
+	for {
+		condition, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+
+		if !p.accept("and") {
+			return conditions, nil
+		}
+	}
+}
+
+func (p *tagExpressionParser) parseCondition() (tagCondition, error) {
+	if p.accept("!") {
+		key, err := p.parseWord("tag key")
+		if err != nil {
+			return tagCondition{}, err
+		}
+		return tagCondition{Key: key, Operator: tagNotExists}, nil
+	}
+
+	key, err := p.parseWord("tag key")
+	if err != nil {
+		return tagCondition{}, err
+	}
+
+	switch {
+	case p.accept("=") || p.accept("=="):
+		value, err := p.parseWord("tag value")
+		if err != nil {
+			return tagCondition{}, err
+		}
+		return tagCondition{Key: key, Operator: tagIn, Values: []string{value}}, nil
+	case p.accept("!="):
+		value, err := p.parseWord("tag value")
+		if err != nil {
+			return tagCondition{}, err
+		}
+		return tagCondition{Key: key, Operator: tagNotIn, Values: []string{value}}, nil
+	case p.accept("in"):
+		values, err := p.parseValues()
+		if err != nil {
+			return tagCondition{}, err
+		}
+		return tagCondition{Key: key, Operator: tagIn, Values: values}, nil
+	case p.accept("not"):
+		if !p.accept("in") {
+			return tagCondition{}, fmt.Errorf("expected in after not for tag %s", key)
+		}
+		values, err := p.parseValues()
+		if err != nil {
+			return tagCondition{}, err
+		}
+		return tagCondition{Key: key, Operator: tagNotIn, Values: values}, nil
+	}
+
+	return tagCondition{Key: key, Operator: tagExists}, nil
+}
+
+// parseValues parses a parenthesized, comma separated list of values.
+func (p *tagExpressionParser) parseValues() ([]string, error) {
+	if !p.accept("(") {
+		return nil, fmt.Errorf("expected ( before the list of values")
+	}
+
+	values := []string{}
+	for {
+		value, err := p.parseWord("tag value")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if p.accept(")") {
+			return values, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("expected , or ) in the list of values")
+		}
+	}
+}
+
+// parseWord parses a key or a value, which is either quoted or neither an operator nor and/or.
+func (p *tagExpressionParser) parseWord(what string) (string, error) {
+	token, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("expected %s at the end", what)
+	}
+	if strings.ContainsAny(token.Text, "=!(),") && !token.Quoted || token.is("and") || token.is("or") {
+		return "", fmt.Errorf("expected %s, got %q", what, token.Text)
+	}
+	return token.Text, nil
+}
@@ -0,0 +1,282 @@
+package action
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/acm"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudfront"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/efs"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+	"github.com/aws/aws-sdk-go/service/elasticbeanstalk"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/redshift"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/sfn"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// Tags is a flattened view of the tags of a resource, keyed by tag key, so the
+// same checks can be applied regardless of the service the resource belongs to.
+type Tags map[string]string
+
+// tagsFrom flattens the tags of any service, read by keyValue.
+func tagsFrom[T any](tags []*T, keyValue func(*T) (*string, *string)) Tags {
+	t := Tags{}
+	for _, tag := range tags {
+		key, value := keyValue(tag)
+		t[aws.StringValue(key)] = aws.StringValue(value)
+	}
+	return t
+}
+
+// tagListFrom builds the tags of any service, made by newTag, in the order of their keys.
+func tagListFrom[T any](tags Tags, newTag func(key, value *string) *T) []*T {
+	list := []*T{}
+	for _, key := range tags.keys() {
+		list = append(list, newTag(aws.String(key), aws.String(tags[key])))
+	}
+	return list
+}
+
+func ec2Tags(tags []*ec2.Tag) Tags {
+	return tagsFrom(tags, func(t *ec2.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+// tagEC2Resource sets tags on an ec2 resource, e.g. a vpc, a security group or a network interface.
+func (a *action) tagEC2Resource(ctx context.Context, id string, tags Tags, client ec2iface.EC2API) error {
+	_, err := client.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+		Resources: []*string{&id},
+		Tags:      ec2TagList(tags),
+	})
+
+	return err
+}
+
+func autoscalingTags(tags []*autoscaling.TagDescription) Tags {
+	return tagsFrom(tags, func(t *autoscaling.TagDescription) (*string, *string) { return t.Key, t.Value })
+}
+
+func elbTags(descs []*elb.TagDescription) Tags {
+	t := Tags{}
+	for _, desc := range descs {
+		maps.Copy(t, tagsFrom(desc.Tags, func(tag *elb.Tag) (*string, *string) { return tag.Key, tag.Value }))
+	}
+	return t
+}
+
+func elbv2Tags(descs []*elbv2.TagDescription) Tags {
+	t := Tags{}
+	for _, desc := range descs {
+		maps.Copy(t, tagsFrom(desc.Tags, func(tag *elbv2.Tag) (*string, *string) { return tag.Key, tag.Value }))
+	}
+	return t
+}
+
+func cloudformationTags(tags []*cloudformation.Tag) Tags {
+	return tagsFrom(tags, func(t *cloudformation.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func rdsTags(tags []*rds.Tag) Tags {
+	return tagsFrom(tags, func(t *rds.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func s3Tags(tags []*s3.Tag) Tags {
+	return tagsFrom(tags, func(t *s3.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func ecrTags(tags []*ecr.Tag) Tags {
+	return tagsFrom(tags, func(t *ecr.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func snsTags(tags []*sns.Tag) Tags {
+	return tagsFrom(tags, func(t *sns.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func secretsManagerTags(tags []*secretsmanager.Tag) Tags {
+	return tagsFrom(tags, func(t *secretsmanager.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func route53Tags(tags []*route53.Tag) Tags {
+	return tagsFrom(tags, func(t *route53.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func efsTags(tags []*efs.Tag) Tags {
+	return tagsFrom(tags, func(t *efs.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func elasticacheTags(tags []*elasticache.Tag) Tags {
+	return tagsFrom(tags, func(t *elasticache.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func beanstalkTags(tags []*elasticbeanstalk.Tag) Tags {
+	return tagsFrom(tags, func(t *elasticbeanstalk.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func acmTags(tags []*acm.Tag) Tags {
+	return tagsFrom(tags, func(t *acm.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+// isManagedByCloudFormation returns true if the tags show the resource was created by a
+// cloudformation stack, in which case it should be cleaned by deleting the stack.
+func isManagedByCloudFormation(tags Tags) bool {
+	_, hasName := tags["aws:cloudformation:stack-name"]
+	_, hasId := tags["aws:cloudformation:stack-id"]
+	return hasName || hasId
+}
+
+// cloudFormationStack returns the name of the stack managing the resource, falling back
+// to the stack id when the name tag is missing.
+func cloudFormationStack(tags Tags) string {
+	if name, ok := tags["aws:cloudformation:stack-name"]; ok {
+		return name
+	}
+	return tags["aws:cloudformation:stack-id"]
+}
+
+func cloudfrontTags(tags []*cloudfront.Tag) Tags {
+	return tagsFrom(tags, func(t *cloudfront.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func kinesisTags(tags []*kinesis.Tag) Tags {
+	return tagsFrom(tags, func(t *kinesis.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func redshiftTags(tags []*redshift.Tag) Tags {
+	return tagsFrom(tags, func(t *redshift.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func eventbridgeTags(tags []*eventbridge.Tag) Tags {
+	return tagsFrom(tags, func(t *eventbridge.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func ecsTags(tags []*ecs.Tag) Tags {
+	return tagsFrom(tags, func(t *ecs.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+func glueTags(tags map[string]*string) Tags {
+	t := Tags{}
+	for key, value := range tags {
+		t[key] = aws.StringValue(value)
+	}
+	return t
+}
+
+func sfnTags(tags []*sfn.Tag) Tags {
+	return tagsFrom(tags, func(t *sfn.Tag) (*string, *string) { return t.Key, t.Value })
+}
+
+// keys returns the keys of the tags in order, so the tag lists built from them are stable.
+func (t Tags) keys() []string {
+	keys := make([]string, 0, len(t))
+	for key := range t {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func ec2TagList(tags Tags) []*ec2.Tag {
+	return tagListFrom(tags, func(key, value *string) *ec2.Tag { return &ec2.Tag{Key: key, Value: value} })
+}
+
+func acmTagList(tags Tags) []*acm.Tag {
+	return tagListFrom(tags, func(key, value *string) *acm.Tag { return &acm.Tag{Key: key, Value: value} })
+}
+
+func beanstalkTagList(tags Tags) []*elasticbeanstalk.Tag {
+	return tagListFrom(tags, func(key, value *string) *elasticbeanstalk.Tag { return &elasticbeanstalk.Tag{Key: key, Value: value} })
+}
+
+func cloudformationTagList(tags Tags) []*cloudformation.Tag {
+	return tagListFrom(tags, func(key, value *string) *cloudformation.Tag { return &cloudformation.Tag{Key: key, Value: value} })
+}
+
+func cloudfrontTagList(tags Tags) []*cloudfront.Tag {
+	return tagListFrom(tags, func(key, value *string) *cloudfront.Tag { return &cloudfront.Tag{Key: key, Value: value} })
+}
+
+func dynamodbTagList(tags Tags) []*dynamodb.Tag {
+	return tagListFrom(tags, func(key, value *string) *dynamodb.Tag { return &dynamodb.Tag{Key: key, Value: value} })
+}
+
+func ecrTagList(tags Tags) []*ecr.Tag {
+	return tagListFrom(tags, func(key, value *string) *ecr.Tag { return &ecr.Tag{Key: key, Value: value} })
+}
+
+func ecsTagList(tags Tags) []*ecs.Tag {
+	return tagListFrom(tags, func(key, value *string) *ecs.Tag { return &ecs.Tag{Key: key, Value: value} })
+}
+
+func efsTagList(tags Tags) []*efs.Tag {
+	return tagListFrom(tags, func(key, value *string) *efs.Tag { return &efs.Tag{Key: key, Value: value} })
+}
+
+func elasticacheTagList(tags Tags) []*elasticache.Tag {
+	return tagListFrom(tags, func(key, value *string) *elasticache.Tag { return &elasticache.Tag{Key: key, Value: value} })
+}
+
+func elbTagList(tags Tags) []*elb.Tag {
+	return tagListFrom(tags, func(key, value *string) *elb.Tag { return &elb.Tag{Key: key, Value: value} })
+}
+
+func elbv2TagList(tags Tags) []*elbv2.Tag {
+	return tagListFrom(tags, func(key, value *string) *elbv2.Tag { return &elbv2.Tag{Key: key, Value: value} })
+}
+
+func eventbridgeTagList(tags Tags) []*eventbridge.Tag {
+	return tagListFrom(tags, func(key, value *string) *eventbridge.Tag { return &eventbridge.Tag{Key: key, Value: value} })
+}
+
+func iamTagList(tags Tags) []*iam.Tag {
+	return tagListFrom(tags, func(key, value *string) *iam.Tag { return &iam.Tag{Key: key, Value: value} })
+}
+
+func rdsTagList(tags Tags) []*rds.Tag {
+	return tagListFrom(tags, func(key, value *string) *rds.Tag { return &rds.Tag{Key: key, Value: value} })
+}
+
+func redshiftTagList(tags Tags) []*redshift.Tag {
+	return tagListFrom(tags, func(key, value *string) *redshift.Tag { return &redshift.Tag{Key: key, Value: value} })
+}
+
+func route53TagList(tags Tags) []*route53.Tag {
+	return tagListFrom(tags, func(key, value *string) *route53.Tag { return &route53.Tag{Key: key, Value: value} })
+}
+
+func s3TagList(tags Tags) []*s3.Tag {
+	return tagListFrom(tags, func(key, value *string) *s3.Tag { return &s3.Tag{Key: key, Value: value} })
+}
+
+func secretsManagerTagList(tags Tags) []*secretsmanager.Tag {
+	return tagListFrom(tags, func(key, value *string) *secretsmanager.Tag { return &secretsmanager.Tag{Key: key, Value: value} })
+}
+
+func sfnTagList(tags Tags) []*sfn.Tag {
+	return tagListFrom(tags, func(key, value *string) *sfn.Tag { return &sfn.Tag{Key: key, Value: value} })
+}
+
+func snsTagList(tags Tags) []*sns.Tag {
+	return tagListFrom(tags, func(key, value *string) *sns.Tag { return &sns.Tag{Key: key, Value: value} })
+}
+
+func kmsTagList(tags Tags) []*kms.Tag {
+	return tagListFrom(tags, func(key, value *string) *kms.Tag { return &kms.Tag{TagKey: key, TagValue: value} })
+}
@@ -3,26 +3,162 @@ package action
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 )
 
-func waitUntil(ctx context.Context, timeout, interval time.Duration, check func(context.Context) (bool, error)) error {
-	waitFor := time.Now().Add(timeout)
-	for {
-		if time.Now().After(waitFor) {
-			return fmt.Errorf("timeout exceeded")
-		}
+// waitConfig is how a wait polls. By default, it checks every interval.
+type waitConfig struct {
+	// backoff multiplies the interval after each check, up to maxInterval, when greater than 1.
+	backoff     float64
+	maxInterval time.Duration
+	// jitter randomizes each interval by up to this fraction of it, e.g. 0.2 for +/- 20%, so
+	// concurrent waits don't poll in sync.
+	jitter float64
+	// random returns a number in [0, 1), it's only used for the jitter.
+	random func() float64
+	clock  clock
+}
+
+// waitOption configures how a wait polls.
+type waitOption func(*waitConfig)
+
+// withBackoff multiplies the polling interval by factor after each check, up to maxInterval.
+func withBackoff(factor float64, maxInterval time.Duration) waitOption {
+	return func(c *waitConfig) {
+		c.backoff = factor
+		c.maxInterval = maxInterval
+	}
+}
+
+// withJitter randomizes each polling interval by up to fraction of it.
+func withJitter(fraction float64) waitOption {
+	return func(c *waitConfig) {
+		c.jitter = fraction
+	}
+}
+
+// withClock sets the clock scheduling the checks, and the random source of the jitter, instead
+// of the real ones.
+func withClock(clk clock, random func() float64) waitOption {
+	return func(c *waitConfig) {
+		c.clock = clk
+		c.random = random
+	}
+}
+
+// clock schedules the checks of a wait.
+type clock interface {
+	NewTicker(d time.Duration) ticker
+}
+
+// ticker is the part of time.Ticker used to schedule the checks.
+type ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) NewTicker(d time.Duration) ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// nextInterval returns the interval to wait after the one just waited, before the jitter is applied.
+func (c *waitConfig) nextInterval(interval time.Duration) time.Duration {
+	if c.backoff <= 1 {
+		return interval
+	}
+
+	next := time.Duration(float64(interval) * c.backoff)
+	if c.maxInterval > 0 && next > c.maxInterval {
+		next = c.maxInterval
+	}
+	return next
+}
+
+// jittered returns the interval randomized by up to the jitter fraction of it.
+func (c *waitConfig) jittered(interval time.Duration) time.Duration {
+	if c.jitter <= 0 {
+		return interval
+	}
+
+	if jittered := interval + time.Duration((2*c.random()-1)*c.jitter*float64(interval)); jittered > 0 {
+		return jittered
+	}
+	return interval
+}
+
+func waitUntil(ctx context.Context, timeout, interval time.Duration, check func(context.Context) (bool, error), opts ...waitOption) error {
+	return waitUntilState(ctx, timeout, interval, func(ctx context.Context) (bool, string, error) {
 		done, err := check(ctx)
+		return done, "", err
+	}, opts...)
+}
+
+// waitUntilState is waitUntil for checks that also report the state they observed, e.g. "nat-123
+// still in state deleting", so the timeout error tells what was still being waited on.
+//
+// The first check is made right away, the next ones every interval, unless opts add backoff or
+// jitter to it. The timeout is enforced by the context given to the checks, so a check stuck in
+// an api call doesn't outlive it.
+func waitUntilState(ctx context.Context, timeout, interval time.Duration, check func(context.Context) (bool, string, error), opts ...waitOption) error {
+	config := &waitConfig{random: rand.Float64, clock: realClock{}}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// a single ticker keeps the checks on schedule, however long each of them takes. It's
+	// only reset when the interval changes, with backoff or jitter.
+	fixed := config.backoff <= 1 && config.jitter <= 0
+	ticker := config.clock.NewTicker(config.jittered(interval))
+	defer ticker.Stop()
+
+	lastState := ""
+	for {
+		done, state, err := check(waitCtx)
 		if err != nil {
+			if ctx.Err() == nil && waitCtx.Err() != nil {
+				return waitTimeoutError(lastState)
+			}
 			return err
 		}
 		if done {
 			return nil
 		}
+		lastState = state
+
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(interval):
+		case <-waitCtx.Done():
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return waitTimeoutError(lastState)
+		case <-ticker.C():
+		}
+
+		if !fixed {
+			interval = config.nextInterval(interval)
+			ticker.Reset(config.jittered(interval))
 		}
 	}
 }
+
+func waitTimeoutError(lastState string) error {
+	if lastState != "" {
+		return fmt.Errorf("timeout exceeded: %s", lastState)
+	}
+	return fmt.Errorf("timeout exceeded")
+}
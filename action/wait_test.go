@@ -0,0 +1,237 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock records the intervals the waits are scheduled with. Its tickers tick right away,
+// unless it's stalled, in which case they never tick.
+type fakeClock struct {
+	intervals []time.Duration
+	// ticks is how many ticks were waited for.
+	ticks   int
+	stalled bool
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) ticker {
+	c.intervals = append(c.intervals, d)
+	return &fakeTicker{clock: c}
+}
+
+type fakeTicker struct {
+	clock *fakeClock
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	if t.clock.stalled {
+		return nil
+	}
+
+	t.clock.ticks++
+	c := make(chan time.Time, 1)
+	c <- time.Time{}
+	return c
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.clock.intervals = append(t.clock.intervals, d)
+}
+
+func (t *fakeTicker) Stop() {}
+
+// doneAfter returns a check that is done on its nth call, and counts its calls in calls.
+func doneAfter(n int, calls *int) func(context.Context) (bool, error) {
+	return func(context.Context) (bool, error) {
+		*calls++
+		return *calls >= n, nil
+	}
+}
+
+func assertIntervals(t *testing.T, got, want []time.Duration) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got intervals %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got intervals %v, want %v", got, want)
+		}
+	}
+}
+
+func TestWaitUntilFixedInterval(t *testing.T) {
+	clk := &fakeClock{}
+	calls := 0
+	if err := waitUntil(context.Background(), time.Minute, time.Second, doneAfter(4, &calls), withClock(clk, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the ticker keeps its interval, it's never reset.
+	assertIntervals(t, clk.intervals, []time.Duration{time.Second})
+	if calls != 4 {
+		t.Fatalf("got %d checks, want 4", calls)
+	}
+}
+
+func TestWaitUntilBackoff(t *testing.T) {
+	clk := &fakeClock{}
+	calls := 0
+	if err := waitUntil(context.Background(), time.Minute, time.Second, doneAfter(6, &calls),
+		withBackoff(2, 5*time.Second), withClock(clk, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the interval doubles after each check, up to the max interval.
+	assertIntervals(t, clk.intervals, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second, 5 * time.Second,
+	})
+}
+
+func TestWaitUntilBackoffWithoutMaxInterval(t *testing.T) {
+	clk := &fakeClock{}
+	calls := 0
+	if err := waitUntil(context.Background(), time.Minute, time.Second, doneAfter(4, &calls),
+		withBackoff(3, 0), withClock(clk, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assertIntervals(t, clk.intervals, []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 27 * time.Second})
+}
+
+func TestWaitUntilJitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		random float64
+		want   time.Duration
+	}{
+		{name: "lowest", random: 0, want: 800 * time.Millisecond},
+		{name: "middle", random: 0.5, want: time.Second},
+		{name: "highest", random: 0.75, want: 1100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := &fakeClock{}
+			calls := 0
+			if err := waitUntil(context.Background(), time.Minute, time.Second, doneAfter(3, &calls),
+				withJitter(0.2), withClock(clk, func() float64 { return tt.random })); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			assertIntervals(t, clk.intervals, []time.Duration{tt.want, tt.want, tt.want})
+		})
+	}
+}
+
+func TestWaitUntilJitterBounds(t *testing.T) {
+	randoms := []float64{0, 0.1, 0.3, 0.5, 0.7, 0.9, 0.999999}
+	next := 0
+	random := func() float64 {
+		r := randoms[next%len(randoms)]
+		next++
+		return r
+	}
+
+	clk := &fakeClock{}
+	calls := 0
+	if err := waitUntil(context.Background(), time.Minute, 10*time.Second, doneAfter(20, &calls),
+		withBackoff(1.5, 40*time.Second), withJitter(0.25), withClock(clk, random)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// each interval is jittered around the backed off one, which is never above the max interval.
+	interval := 10 * time.Second
+	for i, got := range clk.intervals {
+		if i > 0 {
+			interval = (&waitConfig{backoff: 1.5, maxInterval: 40 * time.Second}).nextInterval(interval)
+		}
+		low, high := time.Duration(0.75*float64(interval)), time.Duration(1.25*float64(interval))
+		if got < low || got > high {
+			t.Fatalf("interval %d is %s, want between %s and %s", i, got, low, high)
+		}
+	}
+	if len(clk.intervals) != 20 {
+		t.Fatalf("got %d intervals, want 20", len(clk.intervals))
+	}
+}
+
+func TestWaitUntilStateImmediateSuccess(t *testing.T) {
+	clk := &fakeClock{}
+	calls := 0
+	if err := waitUntilState(context.Background(), time.Minute, time.Second, func(context.Context) (bool, string, error) {
+		calls++
+		return true, "", nil
+	}, withClock(clk, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != 1 || clk.ticks != 0 {
+		t.Fatalf("got %d checks after %d ticks, want 1 check and no tick", calls, clk.ticks)
+	}
+}
+
+func TestWaitUntilStateEventualSuccess(t *testing.T) {
+	clk := &fakeClock{}
+	// the ticks waited for before each check, the first check is made before any tick.
+	ticksAtCheck := []int{}
+	if err := waitUntilState(context.Background(), time.Minute, time.Second, func(context.Context) (bool, string, error) {
+		ticksAtCheck = append(ticksAtCheck, clk.ticks)
+		return len(ticksAtCheck) == 3, "still pending", nil
+	}, withClock(clk, nil)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(ticksAtCheck) != 3 || ticksAtCheck[0] != 0 || ticksAtCheck[1] != 1 || ticksAtCheck[2] != 2 {
+		t.Fatalf("got checks after ticks %v, want [0 1 2]", ticksAtCheck)
+	}
+}
+
+func TestWaitUntilStateTimeout(t *testing.T) {
+	clk := &fakeClock{stalled: true}
+	calls := 0
+	err := waitUntilState(context.Background(), 20*time.Millisecond, time.Second, func(context.Context) (bool, string, error) {
+		calls++
+		return false, "nat-123 still in state deleting", nil
+	}, withClock(clk, nil))
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+
+	if want := "timeout exceeded: nat-123 still in state deleting"; err.Error() != want {
+		t.Fatalf("got error %q, want %q", err.Error(), want)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d checks, want 1", calls)
+	}
+}
+
+func TestWaitUntilStateTimeoutDuringCheck(t *testing.T) {
+	calls := 0
+	err := waitUntilState(context.Background(), 20*time.Millisecond, time.Millisecond, func(ctx context.Context) (bool, string, error) {
+		calls++
+		if calls == 1 {
+			return false, "still deleting", nil
+		}
+		// a check stuck in an api call is aborted by the deadline of its context.
+		<-ctx.Done()
+		return false, "", ctx.Err()
+	}, withClock(&fakeClock{}, nil))
+
+	if want := "timeout exceeded: still deleting"; err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+}
+
+func TestWaitUntilStateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := waitUntilState(ctx, time.Minute, time.Second, func(context.Context) (bool, string, error) {
+		return false, "still deleting", nil
+	}, withClock(&fakeClock{stalled: true}, nil))
+	if err != context.Canceled {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+}
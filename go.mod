@@ -3,9 +3,22 @@ module github.com/rancher-sandbox/aws-janito
 go 1.21.0
 
 require (
-	github.com/aws/aws-sdk-go v1.47.1 // indirect
+	github.com/aws/aws-sdk-go v1.47.1
+	github.com/caarlos0/env/v9 v9.0.0
+	github.com/prometheus/client_golang v1.19.1
+	go.uber.org/multierr v1.11.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/aws/smithy-go v1.16.0 // indirect
-	github.com/caarlos0/env/v9 v9.0.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
-	go.uber.org/multierr v1.11.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
 )
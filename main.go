@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/rancher-sandbox/aws-janito/action"
 )
@@ -16,11 +17,52 @@ func main() {
 	if err := input.Validate(); err != nil {
 		action.LogErrorAndExit("failed input validation: %s", err.Error())
 	}
+	action.SetLogFormat(input.LogFormat)
 
-	a := action.New(input.Commit)
+	var metrics *action.Metrics
+	if input.MetricsAddress != "" || input.MetricsPushgateway != "" {
+		metrics = action.NewMetrics()
+	}
+	if input.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go func() {
+			if err := http.ListenAndServe(input.MetricsAddress, mux); err != nil { //nolint: gosec
+				action.LogWarning("failed to serve metrics on %s: %s", input.MetricsAddress, err.Error())
+			}
+		}()
+	}
+
+	a := action.New(input.Commit,
+		action.WithWorkers(input.Workers),
+		action.WithRegionWorkers(input.RegionWorkers),
+		action.WithVPCWorkers(input.VPCWorkers),
+		action.WithMaxRetries(input.MaxRetries),
+		action.WithRateLimit(input.RateLimit),
+		action.WithPreview(input.Preview),
+		action.WithMode(input.Mode),
+		action.WithDeletionTag(input.DeletionTag),
+		action.WithMarkReason(input.MarkReason),
+		action.WithStackReport(input.ReportCloudFormationStacks),
+		action.WithConfirmation(input.Confirm, input.ConfirmToken),
+		action.WithMaxDeletions(input.MaxDeletions, input.MaxDeletionsAction),
+		action.WithPlan(input.PlanFile, input.ApplyPlan),
+		action.WithCostEstimate(input.CostEstimate, input.CostPrices),
+		action.WithMetrics(metrics),
+		action.WithWebhook(input.WebhookURL, input.WebhookFormat),
+	)
 
 	ctx := context.Background()
-	if err := a.Cleanup(ctx, input); err != nil {
+	err = a.Cleanup(ctx, input)
+
+	// failing to push the metrics must not fail the run.
+	if input.MetricsPushgateway != "" {
+		if pushErr := metrics.Push(input.MetricsPushgateway); pushErr != nil {
+			action.LogWarning("%s", pushErr.Error())
+		}
+	}
+
+	if err != nil {
 		action.LogErrorAndExit("failed to cleanup aws resources: %s", err.Error())
 	}
 }